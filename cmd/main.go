@@ -5,13 +5,20 @@ import (
 
 	"base-app/config"
 	"base-app/controller"
+	"base-app/middleware"
 	"base-app/pkg/db"
+	"base-app/pkg/mailer"
+	"base-app/pkg/passwordhash"
+	"base-app/pkg/passwordpolicy"
 	"base-app/pkg/redis"
+	"base-app/pkg/signer"
+	"base-app/pkg/token"
 	"base-app/repository"
 	"base-app/router"
 	"base-app/service"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 func main() {
@@ -23,18 +30,160 @@ func main() {
 	db.Migrate()
 	redis.Connect(cfg)
 
+	// Nạp khóa ký JWT và chạy nền việc đọc lại / xoay vòng khóa
+	keys, err := token.NewKeySet(token.KeySetConfig{
+		Dir:              cfg.JWTKeysDir,
+		Algorithm:        cfg.JWTSigningAlg,
+		ActiveKID:        cfg.JWTActiveKID,
+		RotationInterval: cfg.JWTKeyRotationInterval,
+		ReloadInterval:   cfg.JWTKeysReloadInterval,
+		MaxTokenTTL:      max(cfg.AccessTokenTTL, cfg.OAuthAccessTokenTTL), // khóa cũ được giữ tới khi access token / ID token cuối cùng hết hạn
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to load JWT keys: %v", err)
+	}
+	keys.Start()
+	tokens := token.NewManager(keys, cfg.JWTIssuer, cfg.JWTAudience)
+
+	// Secret ký link gửi qua email; chỉ môi trường dev mới được dùng secret ngẫu nhiên
+	// (link sẽ mất hiệu lực sau khi khởi động lại và không dùng chung được giữa các instance)
+	if cfg.AppSecret == "" {
+		if !cfg.IsDevelopment() {
+			log.Fatalf("❌ APP_SECRET is required when APP_ENV is %q", cfg.AppEnv)
+		}
+		log.Printf("⚠️  APP_SECRET is not set, using a random secret")
+		cfg.AppSecret = uuid.New().String()
+	}
+	linkSigner := signer.New(cfg.AppSecret)
+
+	// Mailer: smtp | file | memory
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("❌ Failed to init mailer: %v", err)
+	}
+
+	// Chính sách mật khẩu khi đăng ký / đổi / đặt lại mật khẩu
+	if cfg.PasswordCheckBreached && cfg.PasswordBreachedList == "" && !cfg.IsDevelopment() {
+		log.Printf("⚠️  PASSWORD_BREACHED_LIST is not set, only the small built-in list of common passwords is checked")
+	}
+	passwordPolicy, err := passwordpolicy.New(passwordpolicy.Options{
+		MinLength:           cfg.PasswordMinLength,
+		MinCharacterClasses: cfg.PasswordMinCharacterClasses,
+		CheckBreached:       cfg.PasswordCheckBreached,
+		BreachedList:        cfg.PasswordBreachedList,
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to init password policy: %v", err)
+	}
+	passwordHasher, err := passwordhash.New(passwordhash.Options{
+		Algorithm:         cfg.PasswordHashAlgorithm,
+		Argon2Memory:      cfg.PasswordArgon2Memory,
+		Argon2Iterations:  cfg.PasswordArgon2Iterations,
+		Argon2Parallelism: cfg.PasswordArgon2Parallelism,
+		BcryptCost:        cfg.PasswordBcryptCost,
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to init password hasher: %v", err)
+	}
+
 	// Khởi tạo tầng repository, service, controller
 	userRepo := repository.NewUserRepository(db.DB)
 	redisRepo := repository.NewRedisRepository(redis.RDB)
-	userService := service.NewUserService(userRepo, redisRepo, cfg)
+	mfaRepo := repository.NewMFARepository(db.DB)
+	oauthRepo := repository.NewOAuthRepository(db.DB)
+	identityRepo := repository.NewIdentityRepository(db.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(db.DB)
+	lockoutRepo := repository.NewLockoutRepository(db.DB)
+	roleRepo := repository.NewRoleRepository(db.DB)
+	orgRepo := repository.NewOrganizationRepository(db.DB)
+	invitationRepo := repository.NewInvitationRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
+	deviceRepo := repository.NewDeviceRepository(db.DB)
+	auditService := service.NewAuditService(auditRepo)
+	mfaService := service.NewMFAService(userRepo, mfaRepo, redisRepo, auditService, cfg)
+	verificationService := service.NewVerificationService(userRepo, redisRepo, mail, linkSigner, cfg)
+	passwordResetService := service.NewPasswordResetService(userRepo, redisRepo, mail, passwordPolicy, passwordHasher, auditService, cfg)
+	lockoutService := service.NewLockoutService(lockoutRepo, redisRepo, auditService, cfg)
+	invitationService := service.NewInvitationService(invitationRepo, orgRepo, userRepo, mail, cfg)
+	sessionService := service.NewSessionService(redisRepo, auditService)
+	deviceService := service.NewDeviceService(deviceRepo, userRepo, redisRepo, apiKeyRepo, identityRepo, passwordResetService, auditService, mail, linkSigner, cfg)
+	userService := service.NewUserService(userRepo, redisRepo, tokens, mfaService, verificationService, identityRepo, lockoutService, orgRepo, invitationService, auditService, passwordPolicy, passwordHasher, deviceService, cfg)
+	oauthService := service.NewOAuthService(oauthRepo, userRepo, redisRepo, tokens, cfg)
+	federatedService := service.NewFederatedService(identityRepo, userRepo, redisRepo, userService, cfg)
+	roleService := service.NewRoleService(roleRepo, userRepo, redisRepo, auditService, cfg)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, roleService, auditService, cfg)
+	organizationService := service.NewOrganizationService(orgRepo, userRepo, redisRepo, userService)
+	accountDeletionService := service.NewAccountDeletionService(userRepo, userService, apiKeyRepo, oauthRepo, deviceRepo, auditService, cfg)
+	adminUserService := service.NewAdminUserService(userRepo, redisRepo, orgRepo, userService, roleService, accountDeletionService, auditService)
+	dataExportService := service.NewDataExportService(userRepo, redisRepo, mail, linkSigner, cfg)
 	userController := controller.NewUserController(userService)
+	mfaController := controller.NewMFAController(mfaService)
+	verificationController := controller.NewVerificationController(verificationService)
+	passwordResetController := controller.NewPasswordResetController(passwordResetService)
+	jwksController := controller.NewJWKSController(keys)
+	oauthController := controller.NewOAuthController(oauthService)
+	federatedController := controller.NewFederatedController(federatedService)
+	apiKeyController := controller.NewAPIKeyController(apiKeyService)
+	sessionController := controller.NewSessionController(sessionService)
+	deviceController := controller.NewDeviceController(deviceService)
+	lockoutController := controller.NewLockoutController(lockoutService)
+	roleController := controller.NewRoleController(roleService)
+	organizationController := controller.NewOrganizationController(organizationService)
+	invitationController := controller.NewInvitationController(invitationService)
+	adminUserController := controller.NewAdminUserController(adminUserService)
+	dataExportController := controller.NewDataExportController(dataExportService)
+	auditController := controller.NewAuditController(auditService)
+
+	// Vai trò mặc định (admin, user) phải tồn tại trước khi kiểm tra quyền
+	if err := roleService.EnsureDefaults(); err != nil {
+		log.Fatalf("❌ Failed to seed roles: %v", err)
+	}
+
+	// Mỗi subsystem đóng góp một section (<section>.json) vào file export dữ liệu người dùng
+	dataExportService.Register("profile", userService)
+	dataExportService.Register("sessions", sessionService)
+	dataExportService.Register("devices", deviceService)
+	dataExportService.Register("login_history", service.ExportContributorFunc(auditService.ExportLoginHistory))
+	dataExportService.Register("lockout_events", lockoutService)
+	dataExportService.Register("identities", federatedService)
+	dataExportService.Register("organizations", organizationService)
+	dataExportService.Register("api_keys", apiKeyService)
+	dataExportService.Register("oauth_consents", oauthService)
+	dataExportService.Register("audit_events", auditService)
+
+	// Chạy nền việc xóa vĩnh viễn tài khoản đã hết thời gian ân hạn
+	accountDeletionService.Start()
 
 	// Khởi tạo Fiber app
 	app := fiber.New()
 
+	// IP, User-Agent và device cookie của request được truyền xuống service qua c.UserContext()
+	app.Use(middleware.ClientInfo())
+
+	// Middleware xác thực: JWT (chữ ký theo kid, issuer, audience, hạn dùng, trạng thái thu hồi trong Redis) hoặc API key
+	authRequired := middleware.Authenticate(tokens, redisRepo, apiKeyService)
+
+	// Step-up: thao tác nhạy cảm cần token có auth_time trong STEP_UP_MAX_AGE
+	recentAuth := middleware.RequireRecentAuth(cfg.StepUpMaxAge)
+
+	// Giới hạn tần suất request theo policy của từng nhóm route (RATE_LIMIT_POLICIES)
+	limiter := middleware.NewRateLimiter(redisRepo, cfg)
+
+	// Phân quyền theo quyền của vai trò (Postgres, cache trong Redis)
+	authz := middleware.NewAuthorizer(roleService)
+
 	// Cấu hình routes
 	// router.LogRoutes(app, userController)
-	router.SetupRoutes(app, userController)
+	router.SetupRoutes(app, userController, mfaController, verificationController, passwordResetController, jwksController, apiKeyController, sessionController, authRequired, recentAuth, limiter)
+	router.SetupOAuthRoutes(app, oauthController, authRequired, authz, limiter)
+	router.SetupFederatedRoutes(app, federatedController, authRequired)
+	router.SetupLockoutRoutes(app, lockoutController, authRequired, authz)
+	router.SetupRoleRoutes(app, roleController, authRequired, authz)
+	router.SetupOrganizationRoutes(app, organizationController, invitationController, authRequired)
+	router.SetupAdminUserRoutes(app, adminUserController, authRequired, recentAuth, authz)
+	router.SetupDataExportRoutes(app, dataExportController, authRequired)
+	router.SetupDeviceRoutes(app, deviceController, authRequired)
+	router.SetupAuditRoutes(app, auditController, authRequired, authz)
 
 	// xuat router.
 	for _, route := range app.GetRoutes() {
@@ -2,14 +2,118 @@
 package config
 
 import (
+	"encoding/json"
+	"log"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/joho/godotenv/autoload" // auto_ Load .env file
 )
 
 type Config struct {
-	Port      string
-	JWTSecret string
+	Port string
+
+	// Môi trường chạy: development (mặc định) | staging | production...
+	// Ngoài development, các cấu hình chỉ hợp với máy dev (ví dụ mailer trong bộ nhớ) phải được chọn tường minh
+	AppEnv string
+
+	// Secret dùng để ký các link gửi qua email (xác thực email, ...) và URL của frontend
+	AppSecret  string
+	AppBaseURL string
+
+	// Khóa ký JWT (RS256/ES256/EdDSA), mỗi file <kid>.pem trong JWTKeysDir là một khóa
+	JWTKeysDir             string
+	JWTSigningAlg          string
+	JWTActiveKID           string
+	JWTKeyRotationInterval time.Duration
+	JWTKeysReloadInterval  time.Duration
+	JWTIssuer              string
+	JWTAudience            string
+
+	// Thời gian sống của access token và refresh token
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Xác thực hai lớp: tên hiển thị trong app authenticator và thời gian sống của token "MFA pending"
+	MFAIssuer       string
+	MFAChallengeTTL time.Duration
+
+	// Email: driver smtp | file | memory, bắt buộc khai báo ngoài môi trường development
+	MailDriver    string
+	MailFrom      string
+	MailOutboxDir string
+	SMTPHost      string
+	SMTPPort      string
+	SMTPUser      string
+	SMTPPass      string
+
+	// Xác thực email: thời hạn link và có cho phép tài khoản chưa xác thực đăng nhập hay không
+	EmailVerificationTTL time.Duration
+	AllowUnverifiedLogin bool
+
+	// Thời hạn của link đặt lại mật khẩu
+	PasswordResetTTL time.Duration
+
+	// Chính sách mật khẩu: độ dài tối thiểu, số nhóm ký tự tối thiểu (chữ thường, chữ hoa, chữ số, ký tự đặc biệt)
+	// và danh sách mật khẩu đã bị lộ (file hoặc thư mục range file, để trống thì dùng danh sách đi kèm)
+	PasswordMinLength           int
+	PasswordMinCharacterClasses int
+	PasswordCheckBreached       bool
+	PasswordBreachedList        string
+
+	// Băm mật khẩu: argon2id (mặc định, định dạng PHC) hoặc bcrypt; mật khẩu băm bằng thuật toán / tham số cũ
+	// được băm lại khi người dùng đăng nhập
+	PasswordHashAlgorithm     string
+	PasswordArgon2Memory      int // KiB
+	PasswordArgon2Iterations  int
+	PasswordArgon2Parallelism int
+	PasswordBcryptCost        int
+
+	// Authorization server (OAuth 2.0 / OpenID Connect)
+	OIDCIssuer          string        // URL công khai của service, phải trùng với iss trong ID token
+	OAuthConsentURL     string        // trang consent của frontend
+	OAuthCodeTTL        time.Duration // thời gian sống của authorization code
+	OAuthAccessTokenTTL time.Duration // thời gian sống của access token / ID token cấp cho client
+
+	// Đăng nhập qua identity provider bên ngoài (Google, Microsoft, Keycloak, ...)
+	OIDCProviders     []OIDCProvider
+	FederatedLoginTTL time.Duration // thời gian sống của state / nonce / PKCE verifier trong lúc chờ IdP redirect về
+
+	// Chống dò mật khẩu: khóa tạm thời sau N lần sai trong cửa sổ thời gian, độ trễ tăng dần giữa các lần sai
+	LoginMaxFailures     int // theo tài khoản
+	LoginIPMaxFailures   int // theo IP
+	LoginFailureWindow   time.Duration
+	LoginLockoutDuration time.Duration
+	LoginDelayBase       time.Duration
+	LoginDelayMax        time.Duration
+
+	// Giới hạn tần suất request theo từng nhóm route (xem RateLimitPolicy), ghi đè qua RATE_LIMIT_POLICIES
+	RateLimitEnabled bool
+	RateLimits       map[string]RateLimitPolicy
+
+	// Thời gian cache quyền của vai trò trong Redis
+	RolePermissionCacheTTL time.Duration
+
+	// Thao tác nhạy cảm (đổi mật khẩu, đổi email, xóa tài khoản) cần xác thực trong khoảng thời gian này
+	StepUpMaxAge time.Duration
+
+	// Gửi email khi đăng nhập từ thiết bị mới, link "không phải tôi" trong email còn hiệu lực trong DeviceReportTTL
+	NewDeviceNotifications bool
+	DeviceReportTTL        time.Duration
+
+	// Thời gian sống của token khi quản trị viên đăng nhập dưới danh nghĩa người dùng (không có refresh token)
+	ImpersonationTTL time.Duration
+
+	// Thời hạn của lời mời tham gia tổ chức
+	InvitationTTL time.Duration
+
+	// Tài khoản tự xóa được khôi phục nếu đăng nhập lại trong thời gian ân hạn; sau đó bị xóa vĩnh viễn
+	AccountDeletionGracePeriod time.Duration
+	AccountPurgeInterval       time.Duration // chu kỳ chạy nền xóa vĩnh viễn tài khoản đã hết thời gian ân hạn
+
+	// Thời gian giữ file export dữ liệu người dùng (cũng là thời hạn của link tải)
+	DataExportTTL time.Duration
 
 	DBHost  string
 	DBPort  string
@@ -23,11 +127,181 @@ type Config struct {
 	RedisPass string
 }
 
+// OIDCProvider là cấu hình một identity provider OpenID Connect bên ngoài.
+// Các endpoint được đọc từ discovery document của Issuer nếu không khai báo.
+type OIDCProvider struct {
+	Name         string   `json:"name"`  // định danh dùng trong URL, ví dụ "google"
+	Label        string   `json:"label"` // tên hiển thị trên nút đăng nhập
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+
+	AuthURL  string `json:"auth_url"`
+	TokenURL string `json:"token_url"`
+	JWKSURL  string `json:"jwks_url"`
+}
+
+// Thuật toán và khóa đếm của RateLimitPolicy
+const (
+	RateLimitSlidingWindow = "sliding_window"
+	RateLimitTokenBucket   = "token_bucket"
+
+	RateLimitKeyIP     = "ip"      // theo địa chỉ IP
+	RateLimitKeyUser   = "user"    // theo người dùng đã xác thực, chưa xác thực thì theo IP
+	RateLimitKeyAPIKey = "api_key" // theo API key, request dùng JWT thì theo người dùng, chưa xác thực thì theo IP
+)
+
+// RateLimitPolicy là giới hạn tần suất request của một nhóm route.
+// sliding_window: tối đa Limit request trong Window bất kỳ.
+// token_bucket: bucket chứa tối đa Burst token (mặc định bằng Limit), được nạp lại Limit token mỗi Window.
+type RateLimitPolicy struct {
+	Algorithm string
+	Limit     int
+	Window    time.Duration
+	Burst     int
+	Key       string
+}
+
+// UnmarshalJSON chỉ ghi đè các trường có trong JSON, window dạng "1m", "1h"...
+func (p *RateLimitPolicy) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Algorithm string `json:"algorithm"`
+		Limit     int    `json:"limit"`
+		Window    string `json:"window"`
+		Burst     int    `json:"burst"`
+		Key       string `json:"key"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.Algorithm != "" {
+		p.Algorithm = raw.Algorithm
+	}
+	if raw.Limit != 0 {
+		p.Limit = raw.Limit
+	}
+	if raw.Window != "" {
+		window, err := time.ParseDuration(raw.Window)
+		if err != nil {
+			return err
+		}
+		p.Window = window
+	}
+	if raw.Burst != 0 {
+		p.Burst = raw.Burst
+	}
+	if raw.Key != "" {
+		p.Key = raw.Key
+	}
+	return nil
+}
+
+// valid kiểm tra policy có dùng được hay không
+func (p RateLimitPolicy) valid() bool {
+	if p.Algorithm != RateLimitSlidingWindow && p.Algorithm != RateLimitTokenBucket {
+		return false
+	}
+	if p.Key != RateLimitKeyIP && p.Key != RateLimitKeyUser && p.Key != RateLimitKeyAPIKey {
+		return false
+	}
+	return p.Limit > 0 && p.Window > 0 && p.Burst >= 0
+}
+
+// defaultRateLimits - chặt trên đăng ký / đăng nhập và các endpoint gửi email hoặc nhận mã, rộng hơn trên /user/*
+var defaultRateLimits = map[string]RateLimitPolicy{
+	"register":            {Algorithm: RateLimitSlidingWindow, Limit: 5, Window: time.Hour, Key: RateLimitKeyIP},
+	"login":               {Algorithm: RateLimitSlidingWindow, Limit: 10, Window: time.Minute, Key: RateLimitKeyIP},
+	"mfa_verify":          {Algorithm: RateLimitSlidingWindow, Limit: 10, Window: time.Minute, Key: RateLimitKeyIP},
+	"forgot_password":     {Algorithm: RateLimitSlidingWindow, Limit: 5, Window: time.Hour, Key: RateLimitKeyIP},
+	"resend_verification": {Algorithm: RateLimitSlidingWindow, Limit: 5, Window: time.Hour, Key: RateLimitKeyIP},
+	"refresh":             {Algorithm: RateLimitTokenBucket, Limit: 60, Window: time.Minute, Burst: 20, Key: RateLimitKeyIP},
+	"oauth_token":         {Algorithm: RateLimitTokenBucket, Limit: 120, Window: time.Minute, Burst: 30, Key: RateLimitKeyIP},
+	"user":                {Algorithm: RateLimitTokenBucket, Limit: 300, Window: time.Minute, Burst: 60, Key: RateLimitKeyAPIKey},
+}
+
 func LoadConfig() Config {
 
 	return Config{
-		Port:      os.Getenv("PORT"),
-		JWTSecret: os.Getenv("JWT_SECRET"),
+		Port:   os.Getenv("PORT"),
+		AppEnv: getString("APP_ENV", "development"),
+
+		AppSecret:  os.Getenv("APP_SECRET"),
+		AppBaseURL: getString("APP_BASE_URL", "http://localhost:"+os.Getenv("PORT")),
+
+		JWTKeysDir:             os.Getenv("JWT_KEYS_DIR"),
+		JWTSigningAlg:          getString("JWT_SIGNING_ALG", "RS256"),
+		JWTActiveKID:           os.Getenv("JWT_ACTIVE_KID"),
+		JWTKeyRotationInterval: getDuration("JWT_KEY_ROTATION_INTERVAL", 0),
+		JWTKeysReloadInterval:  getDuration("JWT_KEYS_RELOAD_INTERVAL", time.Minute),
+		JWTIssuer:              getString("JWT_ISSUER", "base-app"),
+		JWTAudience:            getString("JWT_AUDIENCE", "base-app-api"),
+
+		AccessTokenTTL:  getDuration("ACCESS_TOKEN_TTL", time.Hour),
+		RefreshTokenTTL: getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		MFAIssuer:       getString("MFA_ISSUER", "base-app"),
+		MFAChallengeTTL: getDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+
+		MailDriver:    os.Getenv("MAIL_DRIVER"),
+		MailFrom:      getString("MAIL_FROM", "no-reply@localhost"),
+		MailOutboxDir: getString("MAIL_OUTBOX_DIR", "outbox"),
+		SMTPHost:      os.Getenv("SMTP_HOST"),
+		SMTPPort:      getString("SMTP_PORT", "587"),
+		SMTPUser:      os.Getenv("SMTP_USER"),
+		SMTPPass:      os.Getenv("SMTP_PASSWORD"),
+
+		EmailVerificationTTL: getDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		AllowUnverifiedLogin: getBool("AUTH_ALLOW_UNVERIFIED_LOGIN", false),
+
+		PasswordResetTTL: getDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+
+		PasswordMinLength:           getInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordMinCharacterClasses: getInt("PASSWORD_MIN_CHARACTER_CLASSES", 2),
+		PasswordCheckBreached:       getBool("PASSWORD_CHECK_BREACHED", true),
+		PasswordBreachedList:        os.Getenv("PASSWORD_BREACHED_LIST"),
+
+		PasswordHashAlgorithm:     getString("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		PasswordArgon2Memory:      getInt("PASSWORD_ARGON2_MEMORY", 19*1024),
+		PasswordArgon2Iterations:  getInt("PASSWORD_ARGON2_ITERATIONS", 2),
+		PasswordArgon2Parallelism: getInt("PASSWORD_ARGON2_PARALLELISM", 1),
+		PasswordBcryptCost:        getInt("PASSWORD_BCRYPT_COST", 10),
+
+		OIDCIssuer:          getString("OIDC_ISSUER", "http://localhost:"+os.Getenv("PORT")),
+		OAuthConsentURL:     getString("OAUTH_CONSENT_URL", getString("APP_BASE_URL", "http://localhost:"+os.Getenv("PORT"))+"/oauth/consent"),
+		OAuthCodeTTL:        getDuration("OAUTH_CODE_TTL", 2*time.Minute),
+		OAuthAccessTokenTTL: getDuration("OAUTH_ACCESS_TOKEN_TTL", time.Hour),
+
+		OIDCProviders:     getOIDCProviders("OIDC_PROVIDERS"),
+		FederatedLoginTTL: getDuration("FEDERATED_LOGIN_TTL", 10*time.Minute),
+
+		LoginMaxFailures:     getInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:   getInt("LOGIN_IP_MAX_FAILURES", 50),
+		LoginFailureWindow:   getDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockoutDuration: getDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginDelayBase:       getDuration("LOGIN_DELAY_BASE", time.Second),
+		LoginDelayMax:        getDuration("LOGIN_DELAY_MAX", 30*time.Second),
+
+		RateLimitEnabled: getBool("RATE_LIMIT_ENABLED", true),
+		RateLimits:       getRateLimitPolicies("RATE_LIMIT_POLICIES"),
+
+		RolePermissionCacheTTL: getDuration("ROLE_PERMISSION_CACHE_TTL", 10*time.Minute),
+
+		StepUpMaxAge: getDuration("STEP_UP_MAX_AGE", 15*time.Minute),
+
+		NewDeviceNotifications: getBool("NEW_DEVICE_NOTIFICATIONS", true),
+		DeviceReportTTL:        getDuration("DEVICE_REPORT_LINK_TTL", 7*24*time.Hour),
+
+		ImpersonationTTL: getDuration("IMPERSONATION_TTL", 15*time.Minute),
+
+		InvitationTTL: getDuration("INVITATION_TTL", 7*24*time.Hour),
+
+		AccountDeletionGracePeriod: getDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+		AccountPurgeInterval:       getDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+
+		DataExportTTL: getDuration("DATA_EXPORT_TTL", 24*time.Hour),
 
 		DBHost:  os.Getenv("DATABASE_HOST"),
 		DBPort:  os.Getenv("DATABASE_PORT"),
@@ -41,3 +315,91 @@ func LoadConfig() Config {
 		RedisPass: os.Getenv("REDIS_PASSWORD"),
 	}
 }
+
+// IsDevelopment cho biết ứng dụng đang chạy ở môi trường development
+func (c Config) IsDevelopment() bool {
+	return c.AppEnv == "" || c.AppEnv == "development"
+}
+
+// getString đọc biến môi trường, trả về giá trị mặc định nếu trống
+func getString(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getBool đọc biến môi trường dạng true/false, 1/0...
+func getBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getInt đọc biến môi trường dạng số nguyên dương, trả về giá trị mặc định nếu trống hoặc sai định dạng
+func getInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// getDuration đọc biến môi trường dạng "15m", "720h"... và trả về giá trị mặc định nếu trống hoặc sai định dạng
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// getOIDCProviders đọc danh sách identity provider dạng JSON, ví dụ:
+// OIDC_PROVIDERS=[{"name":"google","issuer":"https://accounts.google.com","client_id":"...","client_secret":"...","redirect_url":"..."}]
+func getOIDCProviders(key string) []OIDCProvider {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	var providers []OIDCProvider
+	if err := json.Unmarshal([]byte(value), &providers); err != nil {
+		log.Printf("⚠️  %s is not valid JSON, external login is disabled: %v", key, err)
+		return nil
+	}
+	return providers
+}
+
+// getRateLimitPolicies đọc các policy ghi đè dạng JSON theo tên, trường nào không khai báo thì giữ mặc định, ví dụ:
+// RATE_LIMIT_POLICIES={"login":{"limit":20},"user":{"algorithm":"sliding_window","limit":600,"window":"1m"}}
+func getRateLimitPolicies(key string) map[string]RateLimitPolicy {
+	policies := make(map[string]RateLimitPolicy, len(defaultRateLimits))
+	for name, policy := range defaultRateLimits {
+		policies[name] = policy
+	}
+
+	value := os.Getenv(key)
+	if value == "" {
+		return policies
+	}
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		log.Printf("⚠️  %s is not valid JSON, using default rate limits: %v", key, err)
+		return policies
+	}
+
+	for name, raw := range overrides {
+		policy := policies[name]
+		if err := json.Unmarshal(raw, &policy); err != nil || !policy.valid() {
+			log.Printf("⚠️  %s: invalid rate limit policy %q ignored", key, name)
+			continue
+		}
+		policies[name] = policy
+	}
+	return policies
+}
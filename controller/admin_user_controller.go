@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/model"
+	"base-app/pkg/response"
+	"base-app/repository"
+	service "base-app/service"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminUserController struct {
+	service *service.AdminUserService
+}
+
+// NewAdminUserController tạo controller quản trị người dùng
+func NewAdminUserController(service *service.AdminUserService) *AdminUserController {
+	return &AdminUserController{service: service}
+}
+
+// List là endpoint tìm kiếm người dùng,
+// lọc theo ?q=&role=&status=&verified=&created_after=&created_before= và phân trang bằng ?cursor=&limit=
+func (ac *AdminUserController) List(c *fiber.Ctx) error {
+	filter := repository.UserFilter{
+		Search: c.Query("q"),
+		Role:   c.Query("role"),
+		Status: c.Query("status"),
+		Cursor: c.Query("cursor"),
+		Limit:  c.QueryInt("limit"),
+	}
+
+	switch filter.Status {
+	case "", model.UserStatusActive, model.UserStatusDisabled, model.UserStatusPendingDeletion:
+	default:
+		return response.ErrorResponse("Invalid status", fiber.StatusBadRequest)
+	}
+	if verified := c.Query("verified"); verified != "" {
+		value, err := strconv.ParseBool(verified)
+		if err != nil {
+			return response.ErrorResponse("Invalid verified filter", fiber.StatusBadRequest)
+		}
+		filter.EmailVerified = &value
+	}
+
+	var err error
+	if filter.CreatedAfter, err = queryTime(c, "created_after"); err != nil {
+		return err
+	}
+	if filter.CreatedBefore, err = queryTime(c, "created_before"); err != nil {
+		return err
+	}
+
+	page, err := ac.service.List(filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Users", page))
+}
+
+// Get là endpoint xem chi tiết một người dùng
+func (ac *AdminUserController) Get(c *fiber.Ctx) error {
+	user, err := ac.service.Get(c.Params("id"))
+	if err != nil {
+		return adminUserError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("User", user))
+}
+
+// Disable là endpoint vô hiệu hóa tài khoản người dùng
+func (ac *AdminUserController) Disable(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := ac.service.Disable(c.UserContext(), claims.UserID(), c.Params("id"))
+	if err != nil {
+		return adminUserError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("User disabled", user))
+}
+
+// Enable là endpoint kích hoạt lại tài khoản người dùng
+func (ac *AdminUserController) Enable(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := ac.service.Enable(c.UserContext(), claims.UserID(), c.Params("id"))
+	if err != nil {
+		return adminUserError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("User enabled", user))
+}
+
+// Delete là endpoint xóa vĩnh viễn tài khoản người dùng
+func (ac *AdminUserController) Delete(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	if err := ac.service.Delete(c.UserContext(), claims.UserID(), c.Params("id")); err != nil {
+		return adminUserError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("User account deleted permanently", nil))
+}
+
+// Impersonate là endpoint cấp token ngắn hạn để quản trị viên đăng nhập dưới danh nghĩa người dùng
+func (ac *AdminUserController) Impersonate(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := ac.service.Impersonate(c.UserContext(), claims.UserID(), c.Params("id"))
+	if err != nil {
+		return adminUserError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Impersonation token issued", result))
+}
+
+// queryTime - đọc tham số thời gian dạng RFC 3339 (hoặc YYYY-MM-DD) từ query string
+func queryTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", value); err != nil {
+			return nil, response.ErrorResponse("Invalid "+key+", expected RFC 3339 or YYYY-MM-DD", fiber.StatusBadRequest)
+		}
+	}
+	return &t, nil
+}
+
+// adminUserError - ánh xạ lỗi của AdminUserService sang HTTP status
+func adminUserError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	case errors.Is(err, service.ErrCannotDisableSelf), errors.Is(err, service.ErrCannotDeleteSelf), errors.Is(err, service.ErrCannotImpersonateSelf):
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	case errors.Is(err, service.ErrCannotImpersonatePrivileged), errors.Is(err, service.ErrCannotManagePrivileged):
+		return response.ErrorResponse(err.Error(), fiber.StatusForbidden)
+	case errors.Is(err, service.ErrAccountPendingDeletion), errors.Is(err, service.ErrAccountDisabled):
+		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
+	default:
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+}
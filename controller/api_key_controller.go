@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/model"
+	"base-app/pkg/response"
+	service "base-app/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyController struct {
+	service *service.APIKeyService
+}
+
+// NewAPIKeyController tạo controller quản lý API key (personal access token)
+func NewAPIKeyController(service *service.APIKeyService) *APIKeyController {
+	return &APIKeyController{service: service}
+}
+
+// Create là endpoint tạo API key mới; key gốc chỉ được trả về một lần
+func (ac *APIKeyController) Create(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input service.CreateAPIKeyInput
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	key, plaintext, err := ac.service.Create(c.UserContext(), claims.UserID(), input)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	data := apiKeyResponse(key)
+	data["key"] = plaintext
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse("API key created, copy it now - it will not be shown again", data))
+}
+
+// List là endpoint liệt kê API key của người dùng
+func (ac *APIKeyController) List(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	keys, err := ac.service.List(claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	data := make([]fiber.Map, 0, len(keys))
+	for i := range keys {
+		data = append(data, apiKeyResponse(&keys[i]))
+	}
+	return c.JSON(response.SuccessResponse("API keys", data))
+}
+
+// Revoke là endpoint thu hồi API key
+func (ac *APIKeyController) Revoke(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	if err := ac.service.Revoke(c.UserContext(), claims.UserID(), c.Params("id")); err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+
+	return c.JSON(response.SuccessResponse("API key revoked", nil))
+}
+
+// apiKeyResponse - thông tin API key trả về client (không bao gồm hash)
+func apiKeyResponse(key *model.APIKey) fiber.Map {
+	return fiber.Map{
+		"id":           key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       key.ScopeList(),
+		"expires_at":   key.ExpiresAt,
+		"last_used_at": key.LastUsedAt,
+		"revoked_at":   key.RevokedAt,
+		"created_at":   key.CreatedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/model"
+	"base-app/pkg/response"
+	"base-app/repository"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditController struct {
+	service *service.AuditService
+}
+
+// NewAuditController tạo controller truy vấn audit log
+func NewAuditController(service *service.AuditService) *AuditController {
+	return &AuditController{service: service}
+}
+
+// List là endpoint quản trị truy vấn audit log,
+// lọc theo ?actor_id=&target_id=&user_id=&impersonator_id=&action=&result=&ip=&since=&until= và phân trang bằng ?cursor=&limit=
+func (ac *AuditController) List(c *fiber.Ctx) error {
+	filter := repository.AuditFilter{
+		ActorID:  c.Query("actor_id"),
+		TargetID: c.Query("target_id"),
+		UserID:   c.Query("user_id"),
+		Action:   c.Query("action"),
+		Result:   c.Query("result"),
+		IP:       c.Query("ip"),
+		Cursor:   c.Query("cursor"),
+		Limit:    c.QueryInt("limit"),
+
+		ImpersonatorID: c.Query("impersonator_id"),
+	}
+
+	switch filter.Result {
+	case "", model.AuditResultSuccess, model.AuditResultFailure:
+	default:
+		return response.ErrorResponse("Invalid result", fiber.StatusBadRequest)
+	}
+
+	var err error
+	if filter.Since, err = queryTime(c, "since"); err != nil {
+		return err
+	}
+	if filter.Until, err = queryTime(c, "until"); err != nil {
+		return err
+	}
+
+	page, err := ac.service.List(filter)
+	if err != nil {
+		return auditError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Audit events", page))
+}
+
+// MySecurityActivity là endpoint người dùng xem hoạt động bảo mật của chính mình (đăng nhập, đổi mật khẩu, ...)
+func (ac *AuditController) MySecurityActivity(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := ac.service.ListForUser(claims.UserID(), c.Query("cursor"), c.QueryInt("limit"))
+	if err != nil {
+		return auditError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Security activity", page))
+}
+
+// auditError - ánh xạ lỗi truy vấn audit log sang HTTP status
+func auditError(err error) error {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+	return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DataExportController struct {
+	service *service.DataExportService
+}
+
+// NewDataExportController tạo controller export dữ liệu người dùng
+func NewDataExportController(service *service.DataExportService) *DataExportController {
+	return &DataExportController{service: service}
+}
+
+// Request là endpoint yêu cầu export toàn bộ dữ liệu; file được tạo ở nền, link tải gửi qua email
+func (dc *DataExportController) Request(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	export, err := dc.service.Request(c.UserContext(), claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(response.SuccessResponse("Data export requested, the download link will be sent by email", export))
+}
+
+// Get là endpoint xem trạng thái export, có download_url khi đã sẵn sàng
+func (dc *DataExportController) Get(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	export, err := dc.service.Get(c.UserContext(), claims.UserID(), c.Params("id"))
+	if errors.Is(err, service.ErrDataExportNotFound) {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Data export", export))
+}
+
+// Download là endpoint tải file ZIP bằng link đã ký (không cần JWT)
+func (dc *DataExportController) Download(c *fiber.Ctx) error {
+	filename, archive, err := dc.service.Download(c.UserContext(), c.Query("token"))
+	if errors.Is(err, service.ErrInvalidExportLink) {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+	if errors.Is(err, service.ErrDataExportNotReady) {
+		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(archive)
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeviceController struct {
+	service *service.DeviceService
+}
+
+// NewDeviceController tạo controller quản lý thiết bị đã biết
+func NewDeviceController(service *service.DeviceService) *DeviceController {
+	return &DeviceController{service: service}
+}
+
+// List là endpoint liệt kê các thiết bị người dùng đã từng đăng nhập
+func (dc *DeviceController) List(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	devices, err := dc.service.List(claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Known devices", devices))
+}
+
+// Forget là endpoint quên một thiết bị, lần đăng nhập sau từ thiết bị đó sẽ được báo qua email
+func (dc *DeviceController) Forget(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	err = dc.service.Forget(c.UserContext(), claims.UserID(), c.Params("id"))
+	if errors.Is(err, service.ErrDeviceNotFound) {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Device forgotten", nil))
+}
+
+// ReportNotMe là endpoint của link "không phải tôi" trong email đăng nhập từ thiết bị mới
+func (dc *DeviceController) ReportNotMe(c *fiber.Ctx) error {
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&input); err != nil || input.Token == "" {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	err := dc.service.ReportNotMe(c.UserContext(), input.Token)
+	if errors.Is(err, service.ErrInvalidDeviceReport) {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("The device has been signed out. Check your email to reset your password", nil))
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// federatedBindingCookie gắn luồng đăng nhập / liên kết IdP với trình duyệt đã bắt đầu nó
+const (
+	federatedBindingCookie = "oidc_binding"
+	federatedBindingPath   = "/api/v1/auth/oidc"
+)
+
+type FederatedController struct {
+	service *service.FederatedService
+}
+
+// NewFederatedController tạo controller đăng nhập qua identity provider bên ngoài
+func NewFederatedController(service *service.FederatedService) *FederatedController {
+	return &FederatedController{service: service}
+}
+
+// ListProviders là endpoint liệt kê các identity provider để frontend hiển thị nút đăng nhập
+func (fc *FederatedController) ListProviders(c *fiber.Ctx) error {
+	return c.JSON(response.SuccessResponse("Identity providers", fc.service.Providers()))
+}
+
+// Login là endpoint chuyển hướng người dùng tới trang đăng nhập của identity provider
+func (fc *FederatedController) Login(c *fiber.Ctx) error {
+	login, err := fc.service.BeginLogin(c.UserContext(), c.Params("provider"), "")
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+	setFederatedBinding(c, login)
+	return c.Redirect(login.AuthURL, fiber.StatusFound)
+}
+
+// Callback là redirect URL đăng ký với identity provider, trả về token giống /auth/login
+func (fc *FederatedController) Callback(c *fiber.Ctx) error {
+	// Người dùng từ chối hoặc IdP báo lỗi
+	if idpError := c.Query("error"); idpError != "" {
+		return response.ErrorResponse("identity provider returned an error: "+idpError, fiber.StatusBadRequest)
+	}
+
+	binding := c.Cookies(federatedBindingCookie)
+	clearFederatedBinding(c)
+
+	result, err := fc.service.CompleteLogin(c.UserContext(), c.Params("provider"), c.Query("state"), c.Query("code"), binding)
+	if errors.Is(err, service.ErrIdentityEmailConflict) {
+		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
+	}
+	if errors.Is(err, service.ErrEmailNotVerified) || errors.Is(err, service.ErrAccountDisabled) || errors.Is(err, service.ErrPasswordResetRequired) {
+		return response.ErrorResponse(err.Error(), fiber.StatusForbidden)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusUnauthorized)
+	}
+
+	if result.Linked {
+		return c.JSON(response.SuccessResponse("Identity linked successfully", result.Identity))
+	}
+
+	if result.Login.MFARequired {
+		return c.JSON(response.SuccessResponse("Two-factor authentication required", fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    result.Login.MFAToken,
+		}))
+	}
+
+	return c.JSON(response.SuccessResponse("Login successful", fiber.Map{
+		"token":         result.Login.Tokens.AccessToken,
+		"refresh_token": result.Login.Tokens.RefreshToken,
+		"token_type":    result.Login.Tokens.TokenType,
+		"expires_in":    result.Login.Tokens.ExpiresIn,
+	}))
+}
+
+// LinkIdentity là endpoint bắt đầu liên kết thêm một tài khoản identity provider vào tài khoản đang đăng nhập
+func (fc *FederatedController) LinkIdentity(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	login, err := fc.service.BeginLogin(c.UserContext(), c.Params("provider"), claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+	setFederatedBinding(c, login)
+
+	return c.JSON(response.SuccessResponse("Continue at the identity provider", fiber.Map{
+		"authorization_url": login.AuthURL,
+	}))
+}
+
+// ListIdentities là endpoint liệt kê các tài khoản identity provider đã liên kết
+func (fc *FederatedController) ListIdentities(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	identities, err := fc.service.ListIdentities(claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Linked identities", identities))
+}
+
+// UnlinkIdentity là endpoint hủy liên kết một tài khoản identity provider
+func (fc *FederatedController) UnlinkIdentity(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	if err := fc.service.Unlink(claims.UserID(), c.Params("id")); err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+
+	return c.JSON(response.SuccessResponse("Identity unlinked", nil))
+}
+
+// setFederatedBinding - lưu binding của luồng đăng nhập IdP vào cookie HttpOnly, chỉ gửi kèm các route /auth/oidc.
+// SameSite=Lax vẫn cho phép cookie đi theo redirect (GET) từ IdP về callback.
+func setFederatedBinding(c *fiber.Ctx, login *service.FederatedLogin) {
+	c.Cookie(&fiber.Cookie{
+		Name:     federatedBindingCookie,
+		Value:    login.Binding,
+		Path:     federatedBindingPath,
+		Expires:  login.ExpiresAt,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// clearFederatedBinding - xóa cookie binding sau khi callback đã dùng
+func clearFederatedBinding(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     federatedBindingCookie,
+		Path:     federatedBindingPath,
+		Expires:  time.Unix(0, 0),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
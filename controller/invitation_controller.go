@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InvitationController struct {
+	service *service.InvitationService
+}
+
+// NewInvitationController tạo controller lời mời tham gia tổ chức
+func NewInvitationController(service *service.InvitationService) *InvitationController {
+	return &InvitationController{service: service}
+}
+
+// Create là endpoint mời một email tham gia tổ chức đang hoạt động
+func (ic *InvitationController) Create(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	invitation, err := ic.service.Create(c.UserContext(), claims.OrgID, claims.UserID(), input.Email, input.Role)
+	if err != nil {
+		return invitationError(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse("Invitation sent", invitation))
+}
+
+// ListPending là endpoint liệt kê lời mời còn hiệu lực của tổ chức đang hoạt động
+func (ic *InvitationController) ListPending(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	invitations, err := ic.service.ListPending(claims.OrgID, claims.UserID())
+	if err != nil {
+		return invitationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Pending invitations", invitations))
+}
+
+// Revoke là endpoint thu hồi lời mời
+func (ic *InvitationController) Revoke(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	if err := ic.service.Revoke(claims.OrgID, claims.UserID(), c.Params("id")); err != nil {
+		return invitationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Invitation revoked", nil))
+}
+
+// Resend là endpoint gửi lại lời mời với link mới
+func (ic *InvitationController) Resend(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := ic.service.Resend(c.UserContext(), claims.OrgID, claims.UserID(), c.Params("id"))
+	if err != nil {
+		return invitationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Invitation resent", invitation))
+}
+
+// Preview là endpoint công khai xem thông tin lời mời theo token trong link
+func (ic *InvitationController) Preview(c *fiber.Ctx) error {
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	preview, err := ic.service.Preview(input.Token)
+	if err != nil {
+		return invitationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Invitation", preview))
+}
+
+// Accept là endpoint người dùng đã đăng nhập chấp nhận lời mời.
+// Người chưa có tài khoản gửi invitation_token kèm /auth/register.
+func (ic *InvitationController) Accept(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	membership, err := ic.service.Accept(claims.UserID(), input.Token)
+	if err != nil {
+		return invitationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Invitation accepted", membership))
+}
+
+// invitationError - ánh xạ lỗi của InvitationService sang HTTP status
+func invitationError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvitationNotFound), errors.Is(err, service.ErrOrganizationNotFound):
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	case errors.Is(err, service.ErrNotOrganizationMember), errors.Is(err, service.ErrOrgPermissionDenied),
+		errors.Is(err, service.ErrInvitationEmailMismatch):
+		return response.ErrorResponse(err.Error(), fiber.StatusForbidden)
+	case errors.Is(err, service.ErrInvitationExists), errors.Is(err, service.ErrAlreadyMember):
+		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
+	default:
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"base-app/pkg/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type JWKSController struct {
+	keys *token.KeySet
+}
+
+// NewJWKSController tạo controller công bố khóa công khai
+func NewJWKSController(keys *token.KeySet) *JWKSController {
+	return &JWKSController{keys: keys}
+}
+
+// GetJWKS là endpoint trả về các khóa công khai dùng để xác thực JWT (RFC 7517)
+func (jc *JWKSController) GetJWKS(c *fiber.Ctx) error {
+	// Cho phép cache ngắn để các service khác nhận khóa mới kịp thời khi xoay vòng
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(jc.keys.JWKS())
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LockoutController struct {
+	service *service.LockoutService
+}
+
+// NewLockoutController tạo controller quản trị khóa đăng nhập
+func NewLockoutController(service *service.LockoutService) *LockoutController {
+	return &LockoutController{service: service}
+}
+
+// List là endpoint liệt kê các tài khoản / IP đang bị khóa đăng nhập
+func (lc *LockoutController) List(c *fiber.Ctx) error {
+	lockouts, err := lc.service.ListLockouts(c.UserContext())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Active lockouts", lockouts))
+}
+
+// Clear là endpoint mở khóa một tài khoản (kind=account, value=email) hoặc một IP (kind=ip)
+func (lc *LockoutController) Clear(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	// Email có thể chứa ký tự đã được mã hóa trên URL (ví dụ %2B)
+	value, err := url.PathUnescape(c.Params("value"))
+	if err != nil {
+		return response.ErrorResponse("Invalid lockout value", fiber.StatusBadRequest)
+	}
+
+	err = lc.service.ClearLockout(c.UserContext(), c.Params("kind"), value, claims.UserID())
+	if errors.Is(err, service.ErrLockoutNotFound) {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Lockout cleared", nil))
+}
+
+// ListEvents là endpoint xem lịch sử khóa / mở khóa, lọc theo ?kind=&value=&limit=
+func (lc *LockoutController) ListEvents(c *fiber.Ctx) error {
+	events, err := lc.service.ListEvents(c.Query("kind"), c.Query("value"), c.QueryInt("limit"))
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Lockout events", events))
+}
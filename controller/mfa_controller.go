@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MFAController struct {
+	service *service.MFAService
+}
+
+// NewMFAController tạo controller quản lý xác thực hai lớp
+func NewMFAController(service *service.MFAService) *MFAController {
+	return &MFAController{service: service}
+}
+
+// mfaCodeInput là body chung của các endpoint cần mã TOTP / mã khôi phục
+type mfaCodeInput struct {
+	Code string `json:"code"`
+}
+
+// EnrollTOTP là endpoint bắt đầu bật TOTP, trả về secret và URI otpauth:// để hiển thị mã QR
+func (mc *MFAController) EnrollTOTP(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	enrollment, err := mc.service.BeginTOTPEnrollment(c.UserContext(), claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Scan the QR code with your authenticator app, then confirm with a code", fiber.Map{
+		"secret":           enrollment.Secret,
+		"provisioning_uri": enrollment.ProvisioningURI,
+		"qr_payload":       enrollment.ProvisioningURI,
+	}))
+}
+
+// ConfirmTOTP là endpoint xác nhận mã đầu tiên để bật TOTP, trả về mã khôi phục (chỉ hiển thị một lần)
+func (mc *MFAController) ConfirmTOTP(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input mfaCodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	codes, err := mc.service.ConfirmTOTPEnrollment(c.UserContext(), claims.UserID(), input.Code)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Two-factor authentication enabled", fiber.Map{
+		"recovery_codes": codes,
+	}))
+}
+
+// DisableTOTP là endpoint tắt TOTP, yêu cầu mã TOTP hoặc mã khôi phục
+func (mc *MFAController) DisableTOTP(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input mfaCodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	if err := mc.service.DisableTOTP(c.UserContext(), claims.UserID(), input.Code); err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Two-factor authentication disabled", nil))
+}
+
+// RegenerateRecoveryCodes là endpoint sinh lại mã khôi phục, các mã cũ hết hiệu lực
+func (mc *MFAController) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input mfaCodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	codes, err := mc.service.RegenerateRecoveryCodes(c.UserContext(), claims.UserID(), input.Code)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Recovery codes regenerated", fiber.Map{
+		"recovery_codes": codes,
+	}))
+}
+
+// GetRecoveryCodesStatus là endpoint trả về số mã khôi phục còn lại
+func (mc *MFAController) GetRecoveryCodesStatus(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	remaining, err := mc.service.RecoveryCodesRemaining(claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Recovery codes status fetched successfully", fiber.Map{
+		"remaining": remaining,
+	}))
+}
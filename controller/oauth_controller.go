@@ -0,0 +1,301 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/model"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OAuthController struct {
+	service *service.OAuthService
+}
+
+// NewOAuthController tạo controller cho authorization server OAuth 2.0 / OIDC
+func NewOAuthController(service *service.OAuthService) *OAuthController {
+	return &OAuthController{service: service}
+}
+
+// ======================= PROTOCOL ENDPOINTS =======================
+
+// Discovery là endpoint /.well-known/openid-configuration
+func (oc *OAuthController) Discovery(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(oc.service.Discovery())
+}
+
+// Authorize là authorization endpoint: kiểm tra yêu cầu rồi chuyển người dùng tới trang consent của frontend
+func (oc *OAuthController) Authorize(c *fiber.Ctx) error {
+	var req service.AuthorizeRequest
+	if err := c.QueryParser(&req); err != nil {
+		return oauthErrorJSON(c, &service.OAuthError{Code: "invalid_request", Description: "invalid query parameters", Status: fiber.StatusBadRequest})
+	}
+
+	if _, _, err := oc.service.ValidateAuthorizeRequest(req); err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Redirectable {
+			return c.Redirect(oc.service.ErrorRedirectURL(req, oauthErr), fiber.StatusFound)
+		}
+		return oauthErrorJSON(c, err)
+	}
+
+	return c.Redirect(oc.service.ConsentURL(string(c.Request().URI().QueryString())), fiber.StatusFound)
+}
+
+// Token là token endpoint: đổi authorization code lấy access token và ID token
+func (oc *OAuthController) Token(c *fiber.Ctx) error {
+	// Token endpoint không được cache (RFC 6749 mục 5.1)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderPragma, "no-cache")
+
+	req := service.TokenRequest{
+		GrantType:    c.FormValue("grant_type"),
+		Code:         c.FormValue("code"),
+		RedirectURI:  c.FormValue("redirect_uri"),
+		ClientID:     c.FormValue("client_id"),
+		ClientSecret: c.FormValue("client_secret"),
+		CodeVerifier: c.FormValue("code_verifier"),
+	}
+
+	// client_secret_basic được ưu tiên hơn client_secret_post
+	if clientID, clientSecret, ok := basicAuth(c); ok {
+		req.ClientID = clientID
+		req.ClientSecret = clientSecret
+	}
+
+	resp, err := oc.service.Exchange(c.UserContext(), req)
+	if err != nil {
+		return oauthErrorJSON(c, err)
+	}
+	return c.JSON(resp)
+}
+
+// UserInfo là endpoint trả về claims của người dùng theo access token OAuth
+func (oc *OAuthController) UserInfo(c *fiber.Ctx) error {
+	// Access token qua header Bearer hoặc tham số form access_token (RFC 6750)
+	accessToken := c.FormValue("access_token")
+	if header := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(header, "Bearer ") {
+		accessToken = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	if accessToken == "" {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return oauthErrorJSON(c, &service.OAuthError{Code: "invalid_token", Description: "access token is required", Status: fiber.StatusUnauthorized})
+	}
+
+	info, err := oc.service.UserInfo(c.UserContext(), accessToken)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="`+oauthErr.Code+`"`)
+		}
+		return oauthErrorJSON(c, err)
+	}
+	return c.JSON(info)
+}
+
+// ======================= CONSENT (frontend) =======================
+
+// GetConsent trả về thông tin client và scope để frontend hiển thị màn hình consent
+func (oc *OAuthController) GetConsent(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var req service.AuthorizeRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.ErrorResponse("Invalid query parameters", fiber.StatusBadRequest)
+	}
+
+	details, err := oc.service.AuthorizeDetails(c.UserContext(), claims.UserID(), req)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Authorization request", fiber.Map{
+		"client": fiber.Map{
+			"client_id":   details.Client.ID,
+			"name":        details.Client.Name,
+			"first_party": details.Client.FirstParty,
+		},
+		"scopes":           details.Scopes,
+		"consent_required": details.ConsentRequired,
+	}))
+}
+
+// DecideConsent nhận quyết định đồng ý / từ chối của người dùng, trả về URL để frontend redirect về client
+func (oc *OAuthController) DecideConsent(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		service.AuthorizeRequest
+		Approve bool `json:"approve"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	// auth_time trong ID token là thời điểm xác thực của phiên, không phải lúc access token được cấp lại.
+	// Phiên cũ chưa có auth_time phải xác thực lại để không khai báo sai với relying party.
+	authTime := claims.AuthenticatedAt()
+	if authTime.IsZero() {
+		return middleware.InsufficientAuthentication(c)
+	}
+
+	redirectTo, err := oc.service.Authorize(c.UserContext(), claims.UserID(), authTime, input.AuthorizeRequest, input.Approve)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Authorization completed", fiber.Map{
+		"redirect_to": redirectTo,
+	}))
+}
+
+// ListConsents là endpoint liệt kê các ứng dụng người dùng đã cấp quyền
+func (oc *OAuthController) ListConsents(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	consents, err := oc.service.ListConsents(claims.UserID())
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Authorized applications", consents))
+}
+
+// RevokeConsent là endpoint thu hồi quyền đã cấp cho một ứng dụng
+func (oc *OAuthController) RevokeConsent(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	if err := oc.service.RevokeConsent(c.UserContext(), claims.UserID(), c.Params("client_id")); err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+
+	return c.JSON(response.SuccessResponse("Application access revoked", nil))
+}
+
+// ======================= CLIENT MANAGEMENT (admin) =======================
+
+// RegisterClient là endpoint đăng ký client mới; client_secret chỉ được trả về một lần
+func (oc *OAuthController) RegisterClient(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input service.RegisterClientInput
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	client, secret, err := oc.service.RegisterClient(claims.UserID(), input)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	data := clientResponse(client)
+	if secret != "" {
+		data["client_secret"] = secret
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse("Client registered, store the client secret now - it will not be shown again", data))
+}
+
+// ListClients là endpoint liệt kê các client đã đăng ký
+func (oc *OAuthController) ListClients(c *fiber.Ctx) error {
+	clients, err := oc.service.ListClients()
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	data := make([]fiber.Map, 0, len(clients))
+	for i := range clients {
+		data = append(data, clientResponse(&clients[i]))
+	}
+	return c.JSON(response.SuccessResponse("OAuth clients", data))
+}
+
+// DeleteClient là endpoint xóa client và các consent liên quan
+func (oc *OAuthController) DeleteClient(c *fiber.Ctx) error {
+	if err := oc.service.DeleteClient(c.Params("id")); err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+	return c.JSON(response.SuccessResponse("Client deleted", nil))
+}
+
+// ======================= HELPERS =======================
+
+// clientResponse - thông tin client trả về cho admin (không bao gồm secret hash)
+func clientResponse(client *model.OAuthClient) fiber.Map {
+	return fiber.Map{
+		"client_id":     client.ID,
+		"name":          client.Name,
+		"type":          client.Type,
+		"redirect_uris": client.RedirectURIList(),
+		"scopes":        client.ScopeList(),
+		"first_party":   client.FirstParty,
+		"owner_id":      client.OwnerID,
+		"created_at":    client.CreatedAt,
+	}
+}
+
+// basicAuth - đọc client_id / client_secret từ header Authorization: Basic (RFC 6749 mục 2.3.1)
+func basicAuth(c *fiber.Ctx) (string, string, bool) {
+	header := c.Get(fiber.HeaderAuthorization)
+	if !strings.HasPrefix(header, "Basic ") {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(header, "Basic ")))
+	if err != nil {
+		return "", "", false
+	}
+	rawID, rawSecret, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", "", false
+	}
+
+	// client_id và client_secret được form-urlencode trước khi ghép
+	clientID, err := url.QueryUnescape(rawID)
+	if err != nil {
+		return "", "", false
+	}
+	clientSecret, err := url.QueryUnescape(rawSecret)
+	if err != nil {
+		return "", "", false
+	}
+	return clientID, clientSecret, true
+}
+
+// oauthErrorJSON - trả lỗi theo định dạng RFC 6749 mục 5.2
+func oauthErrorJSON(c *fiber.Ctx, err error) error {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		// Lỗi nội bộ (Redis, DB, ...) chỉ ghi log, không lộ chi tiết cho client
+		fmt.Printf("warning: oauth %s %s failed: %v\n", c.Method(), c.Path(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":             "server_error",
+			"error_description": "server_error",
+		})
+	}
+	return c.Status(oauthErr.Status).JSON(fiber.Map{
+		"error":             oauthErr.Code,
+		"error_description": oauthErr.Description,
+	})
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrganizationController struct {
+	service *service.OrganizationService
+}
+
+// NewOrganizationController tạo controller quản lý tổ chức và thành viên
+func NewOrganizationController(service *service.OrganizationService) *OrganizationController {
+	return &OrganizationController{service: service}
+}
+
+// Create là endpoint tạo tổ chức mới, người tạo là owner
+func (oc *OrganizationController) Create(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	org, err := oc.service.Create(claims.UserID(), input.Name)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse("Organization created", org))
+}
+
+// List là endpoint liệt kê các tổ chức của người dùng
+func (oc *OrganizationController) List(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	orgs, err := oc.service.List(claims.UserID(), claims.OrgID)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Organizations", orgs))
+}
+
+// Switch là endpoint đổi tổ chức đang hoạt động, trả về access token mới
+func (oc *OrganizationController) Switch(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+	currentToken, err := middleware.CurrentToken(c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := oc.service.Switch(c.UserContext(), claims.UserID(), claims.SessionID, currentToken, c.Params("id"))
+	if err != nil {
+		return organizationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Organization switched", fiber.Map{
+		"token":           tokens.AccessToken,
+		"token_type":      tokens.TokenType,
+		"expires_in":      tokens.ExpiresIn,
+		"organization_id": c.Params("id"),
+	}))
+}
+
+// Current là endpoint xem tổ chức đang hoạt động
+func (oc *OrganizationController) Current(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	org, err := oc.service.Current(claims.OrgID, claims.UserID())
+	if err != nil {
+		return organizationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Organization", org))
+}
+
+// ListMembers là endpoint liệt kê thành viên của tổ chức đang hoạt động
+func (oc *OrganizationController) ListMembers(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	members, err := oc.service.ListMembers(claims.OrgID, claims.UserID())
+	if err != nil {
+		return organizationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Members", members))
+}
+
+// UpdateMemberRole là endpoint đổi vai trò của thành viên trong tổ chức đang hoạt động
+func (oc *OrganizationController) UpdateMemberRole(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	if err := oc.service.UpdateMemberRole(claims.OrgID, claims.UserID(), c.Params("user_id"), input.Role); err != nil {
+		return organizationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Member role updated", nil))
+}
+
+// RemoveMember là endpoint xóa thành viên (hoặc tự rời) khỏi tổ chức đang hoạt động
+func (oc *OrganizationController) RemoveMember(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	if err := oc.service.RemoveMember(claims.OrgID, claims.UserID(), c.Params("user_id")); err != nil {
+		return organizationError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Member removed", nil))
+}
+
+// organizationError - ánh xạ lỗi của OrganizationService sang HTTP status
+func organizationError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrMemberNotFound):
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	case errors.Is(err, service.ErrNotOrganizationMember), errors.Is(err, service.ErrOrgPermissionDenied):
+		return response.ErrorResponse(err.Error(), fiber.StatusForbidden)
+	case errors.Is(err, service.ErrLastOrganizationOwner):
+		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
+	default:
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+}
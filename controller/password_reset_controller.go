@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordResetController struct {
+	service *service.PasswordResetService
+}
+
+// NewPasswordResetController tạo controller quên / đặt lại mật khẩu
+func NewPasswordResetController(service *service.PasswordResetService) *PasswordResetController {
+	return &PasswordResetController{service: service}
+}
+
+// ForgotPassword là endpoint yêu cầu gửi link đặt lại mật khẩu
+func (pc *PasswordResetController) ForgotPassword(c *fiber.Ctx) error {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	err := pc.service.ForgotPassword(c.UserContext(), input.Email)
+	if errors.Is(err, service.ErrTooManyRequests) {
+		return response.ErrorResponse(err.Error(), fiber.StatusTooManyRequests)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	// Cùng một phản hồi dù email có tồn tại hay không
+	return c.JSON(response.SuccessResponse("If an account with that email exists, a password reset link has been sent", nil))
+}
+
+// ResetPassword là endpoint đặt mật khẩu mới bằng token trong link
+func (pc *PasswordResetController) ResetPassword(c *fiber.Ctx) error {
+	var input struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+
+	if err := c.BodyParser(&input); err != nil || input.Token == "" {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	err := pc.service.ResetPassword(c.UserContext(), input.Token, input.NewPassword)
+	if violations, ok := passwordViolations(err); ok {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(violations)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Password has been reset, please log in again", nil))
+}
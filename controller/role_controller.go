@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RoleController struct {
+	service *service.RoleService
+}
+
+// NewRoleController tạo controller quản trị vai trò và quyền
+func NewRoleController(service *service.RoleService) *RoleController {
+	return &RoleController{service: service}
+}
+
+// List là endpoint liệt kê vai trò kèm quyền
+func (rc *RoleController) List(c *fiber.Ctx) error {
+	roles, err := rc.service.List()
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Roles", roles))
+}
+
+// Get là endpoint xem chi tiết một vai trò
+func (rc *RoleController) Get(c *fiber.Ctx) error {
+	role, err := rc.service.Get(c.Params("name"))
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+
+	return c.JSON(response.SuccessResponse("Role", role))
+}
+
+// Create là endpoint tạo vai trò mới
+func (rc *RoleController) Create(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input service.CreateRoleInput
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	role, err := rc.service.Create(c.UserContext(), claims.UserID(), input)
+	if errors.Is(err, service.ErrRoleExists) {
+		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.SuccessResponse("Role created", role))
+}
+
+// Delete là endpoint xóa vai trò
+func (rc *RoleController) Delete(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	err = rc.service.Delete(c.UserContext(), claims.UserID(), c.Params("name"))
+	if err != nil {
+		return roleError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Role deleted", nil))
+}
+
+// GrantPermission là endpoint cấp quyền cho vai trò
+func (rc *RoleController) GrantPermission(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Permission string `json:"permission"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	role, err := rc.service.Grant(c.UserContext(), claims.UserID(), c.Params("name"), input.Permission)
+	if err != nil {
+		return roleError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Permission granted", role))
+}
+
+// RevokePermission là endpoint thu hồi quyền của vai trò
+func (rc *RoleController) RevokePermission(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	permission, err := url.PathUnescape(c.Params("permission"))
+	if err != nil {
+		return response.ErrorResponse("Invalid permission", fiber.StatusBadRequest)
+	}
+
+	role, err := rc.service.Revoke(c.UserContext(), claims.UserID(), c.Params("name"), permission)
+	if err != nil {
+		return roleError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Permission revoked", role))
+}
+
+// AssignRole là endpoint gán vai trò cho người dùng
+func (rc *RoleController) AssignRole(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	user, err := rc.service.AssignRole(c.UserContext(), claims.UserID(), c.Params("id"), input.Role)
+	if err != nil {
+		return roleError(err)
+	}
+
+	return c.JSON(response.SuccessResponse("Role assigned", fiber.Map{
+		"id":    user.ID,
+		"email": user.Email,
+		"role":  user.Role,
+	}))
+}
+
+// roleError - ánh xạ lỗi của RoleService sang HTTP status
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	case errors.Is(err, service.ErrSystemRole), errors.Is(err, service.ErrRoleInUse):
+		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
+	default:
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+}
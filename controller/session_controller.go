@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"base-app/middleware"
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionController struct {
+	service *service.SessionService
+}
+
+// NewSessionController tạo controller quản lý phiên đăng nhập
+func NewSessionController(service *service.SessionService) *SessionController {
+	return &SessionController{service: service}
+}
+
+// List là endpoint liệt kê các thiết bị đang đăng nhập (không trả về token)
+func (sc *SessionController) List(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := sc.service.List(c.UserContext(), claims.UserID(), claims.SessionID)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Active sessions", sessions))
+}
+
+// Revoke là endpoint đăng xuất một thiết bị từ xa
+func (sc *SessionController) Revoke(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+
+	err = sc.service.Revoke(c.UserContext(), claims.UserID(), c.Params("id"))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusInternalServerError)
+	}
+
+	return c.JSON(response.SuccessResponse("Session signed out", nil))
+}
@@ -1,12 +1,15 @@
 package controller
 
 import (
+	"base-app/middleware"
+	"base-app/pkg/passwordpolicy"
 	"base-app/pkg/response"
 	service "base-app/service"
-	"context"
+	"errors"
+	"math"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type UserController struct {
@@ -24,6 +27,8 @@ func (uc *UserController) Register(c *fiber.Ctx) error {
 		Name     string `json:"name"`
 		Email    string `json:"email"`
 		Password string `json:"password"`
+
+		InvitationToken string `json:"invitation_token"` // đăng ký từ lời mời tham gia tổ chức
 	}
 
 	// Parse JSON body
@@ -32,7 +37,13 @@ func (uc *UserController) Register(c *fiber.Ctx) error {
 	}
 
 	// Gọi service để đăng ký user
-	user, err := uc.service.Register(c.Context(), input.Name, input.Email, input.Password)
+	user, err := uc.service.Register(c.UserContext(), input.Name, input.Email, input.Password, input.InvitationToken)
+	if violations, ok := passwordViolations(err); ok {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(violations)
+	}
+	if errors.Is(err, service.ErrInvalidInvitation) || errors.Is(err, service.ErrInvitationEmailMismatch) {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
 	if err != nil {
 		return response.ErrorResponse(err.Error(), fiber.StatusConflict)
 	}
@@ -43,6 +54,8 @@ func (uc *UserController) Register(c *fiber.Ctx) error {
 		"name":       user.Name,
 		"email":      user.Email,
 		"created_at": user.CreatedAt,
+
+		"email_verified": user.EmailVerified,
 	}))
 }
 
@@ -59,37 +72,126 @@ func (uc *UserController) Login(c *fiber.Ctx) error {
 	}
 
 	// Gọi service để login
-	token, err := uc.service.Login(context.Background(), input.Email, input.Password)
+	result, err := uc.service.Login(c.UserContext(), input.Email, input.Password)
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return response.ErrorResponse(err.Error(), fiber.StatusTooManyRequests)
+	}
+	if errors.Is(err, service.ErrEmailNotVerified) || errors.Is(err, service.ErrAccountDisabled) || errors.Is(err, service.ErrPasswordResetRequired) {
+		return response.ErrorResponse(err.Error(), fiber.StatusForbidden)
+	}
 	if err != nil {
 		return response.ErrorResponse(err.Error(), fiber.StatusUnauthorized)
 	}
 
+	// Tài khoản bật MFA: client cần gửi mfa_token cùng mã TOTP tới /auth/mfa/verify
+	if result.MFARequired {
+		return c.Status(fiber.StatusOK).JSON(response.SuccessResponse("Two-factor authentication required", fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		}))
+	}
+
 	// Trả về token nếu thành công
 	return c.Status(fiber.StatusOK).JSON(response.SuccessResponse("Login successful", fiber.Map{
-		"token": token,
+		"token":         result.Tokens.AccessToken,
+		"refresh_token": result.Tokens.RefreshToken,
+		"token_type":    result.Tokens.TokenType,
+		"expires_in":    result.Tokens.ExpiresIn,
 	}))
 }
 
-// GetProfile là endpoint lấy thông tin người dùng từ JWT
-func (uc *UserController) GetProfile(c *fiber.Ctx) error {
-	userToken := c.Locals("user")
-	token, ok := userToken.(*jwt.Token)
-	if !ok {
-		return response.ErrorResponse("Invalid token", fiber.StatusUnauthorized)
+// VerifyMFA là endpoint bước 2 của đăng nhập: đổi mfa_token + mã TOTP (hoặc mã khôi phục) lấy token
+func (uc *UserController) VerifyMFA(c *fiber.Ctx) error {
+	var input struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+
+	// Parse JSON body
+	if err := c.BodyParser(&input); err != nil || input.MFAToken == "" {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	tokens, err := uc.service.VerifyMFALogin(c.UserContext(), input.MFAToken, input.Code)
+	if errors.Is(err, service.ErrAccountDisabled) || errors.Is(err, service.ErrPasswordResetRequired) {
+		return response.ErrorResponse(err.Error(), fiber.StatusForbidden)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusUnauthorized)
+	}
+
+	return c.JSON(response.SuccessResponse("Login successful", fiber.Map{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	}))
+}
+
+// RefreshToken là endpoint đổi refresh token lấy cặp token mới
+func (uc *UserController) RefreshToken(c *fiber.Ctx) error {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return response.ErrorResponse("Invalid token claims", fiber.StatusUnauthorized)
+	// Parse JSON body
+	if err := c.BodyParser(&input); err != nil || input.RefreshToken == "" {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
 	}
 
-	userID, ok := claims["sub"].(string)
-	if !ok {
-		return response.ErrorResponse("Invalid token subject", fiber.StatusUnauthorized)
+	// Gọi service để xoay vòng token
+	tokens, err := uc.service.RefreshToken(c.UserContext(), input.RefreshToken)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusUnauthorized)
+	}
+
+	return c.JSON(response.SuccessResponse("Token refreshed successfully", fiber.Map{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	}))
+}
+
+// Logout là endpoint đăng xuất phiên hiện tại
+func (uc *UserController) Logout(c *fiber.Ctx) error {
+	token, err := middleware.CurrentToken(c)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.service.Logout(c.UserContext(), token); err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusUnauthorized)
+	}
+
+	return c.JSON(response.SuccessResponse("Logged out successfully", nil))
+}
+
+// LogoutAll là endpoint đăng xuất khỏi tất cả các phiên của người dùng
+func (uc *UserController) LogoutAll(c *fiber.Ctx) error {
+	token, err := middleware.CurrentToken(c)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.service.LogoutAll(c.UserContext(), token); err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusUnauthorized)
+	}
+
+	return c.JSON(response.SuccessResponse("Logged out from all sessions successfully", nil))
+}
+
+// GetProfile là endpoint lấy thông tin người dùng từ JWT
+func (uc *UserController) GetProfile(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
 	}
 
 	// Gọi service để lấy thông tin user
-	user, err := uc.service.GetUserProfile(c.Context(), userID)
+	user, err := uc.service.GetUserProfile(c.UserContext(), claims.UserID())
 	if err != nil {
 		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
 	}
@@ -106,20 +208,9 @@ func (uc *UserController) GetProfile(c *fiber.Ctx) error {
 
 // UpdateProfile là endpoint để cập nhật thông tin người dùng
 func (uc *UserController) UpdateProfile(c *fiber.Ctx) error {
-	userToken := c.Locals("user")
-	token, ok := userToken.(*jwt.Token)
-	if !ok {
-		return response.ErrorResponse("Invalid token", fiber.StatusUnauthorized)
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return response.ErrorResponse("Invalid token claims", fiber.StatusUnauthorized)
-	}
-
-	userID, ok := claims["sub"].(string)
-	if !ok {
-		return response.ErrorResponse("Invalid token subject", fiber.StatusUnauthorized)
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
 	}
 
 	var input struct {
@@ -132,7 +223,10 @@ func (uc *UserController) UpdateProfile(c *fiber.Ctx) error {
 	}
 
 	// Gọi service để cập nhật thông tin user
-	user, err := uc.service.UpdateUserProfile(c.Context(), userID, input.Name, input.Email)
+	user, err := uc.service.UpdateUserProfile(c.UserContext(), claims.UserID(), input.Name, input.Email, claims.AuthenticatedAt())
+	if errors.Is(err, service.ErrReauthenticationRequired) {
+		return middleware.InsufficientAuthentication(c)
+	}
 	if err != nil {
 		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
 	}
@@ -147,22 +241,48 @@ func (uc *UserController) UpdateProfile(c *fiber.Ctx) error {
 	}))
 }
 
-// ChangePassword là endpoint để thay đổi mật khẩu người dùng
-func (uc *UserController) ChangePassword(c *fiber.Ctx) error {
-	userToken := c.Locals("user")
-	token, ok := userToken.(*jwt.Token)
-	if !ok {
-		return response.ErrorResponse("Invalid token", fiber.StatusUnauthorized)
+// Reauthenticate là endpoint xác thực lại trong phiên hiện tại (mật khẩu và / hoặc mã MFA)
+// trước các thao tác nhạy cảm; trả về access token mới có auth_time là thời điểm hiện tại
+func (uc *UserController) Reauthenticate(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
+	}
+	if claims.SessionID == "" {
+		return response.ErrorResponse("This token does not belong to a login session", fiber.StatusBadRequest)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return response.ErrorResponse("Invalid token claims", fiber.StatusUnauthorized)
+	var input struct {
+		Password string `json:"password"`
+		Code     string `json:"code"` // mã TOTP / mã khôi phục, bắt buộc nếu đã bật MFA
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	tokens, err := uc.service.Reauthenticate(c.UserContext(), claims.UserID(), claims.SessionID, input.Password, input.Code)
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return response.ErrorResponse(err.Error(), fiber.StatusTooManyRequests)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusUnauthorized)
 	}
 
-	userID, ok := claims["sub"].(string)
-	if !ok {
-		return response.ErrorResponse("Invalid token subject", fiber.StatusUnauthorized)
+	// Refresh token của phiên giữ nguyên, chỉ thay access token
+	return c.JSON(response.SuccessResponse("Reauthenticated successfully", fiber.Map{
+		"token":      tokens.AccessToken,
+		"token_type": tokens.TokenType,
+		"expires_in": tokens.ExpiresIn,
+	}))
+}
+
+// ChangePassword là endpoint để thay đổi mật khẩu người dùng
+func (uc *UserController) ChangePassword(c *fiber.Ctx) error {
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
 	}
 
 	var input struct {
@@ -175,37 +295,44 @@ func (uc *UserController) ChangePassword(c *fiber.Ctx) error {
 	}
 
 	// Gọi service để thay đổi mật khẩu
-	err := uc.service.ChangePassword(c.Context(), userID, input.OldPassword, input.NewPassword)
+	err = uc.service.ChangePassword(c.UserContext(), claims.UserID(), input.OldPassword, input.NewPassword)
+	if violations, ok := passwordViolations(err); ok {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(violations)
+	}
 	if err != nil {
 		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
 	}
 
-	return c.JSON(response.SuccessResponse("Password updated successfully", nil))
+	return c.JSON(response.SuccessResponse("Password updated successfully, please log in again", nil))
 }
 
-// DeleteAccount là endpoint để xóa tài khoản người dùng
+// DeleteAccount là endpoint để người dùng tự xóa tài khoản.
+// Tài khoản bị đăng xuất khỏi mọi phiên và bị xóa vĩnh viễn sau thời gian ân hạn nếu không đăng nhập lại.
 func (uc *UserController) DeleteAccount(c *fiber.Ctx) error {
-	userToken := c.Locals("user")
-	token, ok := userToken.(*jwt.Token)
-	if !ok {
-		return response.ErrorResponse("Invalid token", fiber.StatusUnauthorized)
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return response.ErrorResponse("Invalid token claims", fiber.StatusUnauthorized)
-	}
-
-	userID, ok := claims["sub"].(string)
-	if !ok {
-		return response.ErrorResponse("Invalid token subject", fiber.StatusUnauthorized)
+	claims, err := middleware.CurrentClaims(c)
+	if err != nil {
+		return err
 	}
 
-	// Gọi service để xóa tài khoản user
-	err := uc.service.ForceDeletedUserAccount(c.Context(), userID)
+	// Gọi service để đánh dấu xóa tài khoản user
+	purgeAt, err := uc.service.DeleteAccount(c.UserContext(), claims.UserID())
 	if err != nil {
 		return response.ErrorResponse(err.Error(), fiber.StatusNotFound)
 	}
 
-	return c.JSON(response.SuccessResponse("User account deleted successfully", nil))
+	return c.JSON(response.SuccessResponse("User account scheduled for deletion, log in again before purge_at to restore it", fiber.Map{
+		"purge_at": purgeAt,
+	}))
+}
+
+// passwordViolations - body 422 liệt kê từng vi phạm chính sách mật khẩu theo trường,
+// ok = false nếu err không phải lỗi chính sách mật khẩu
+func passwordViolations(err error) (fiber.Map, bool) {
+	var violationErr *passwordpolicy.ViolationError
+	if !errors.As(err, &violationErr) {
+		return nil, false
+	}
+	return response.CustomResponse(false, "Password does not meet the password policy", fiber.Map{
+		"errors": violationErr.Violations,
+	}), true
 }
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"base-app/pkg/response"
+	service "base-app/service"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type VerificationController struct {
+	service *service.VerificationService
+}
+
+// NewVerificationController tạo controller xác thực email
+func NewVerificationController(service *service.VerificationService) *VerificationController {
+	return &VerificationController{service: service}
+}
+
+// VerifyEmail là endpoint xác thực email bằng token trong link đã gửi
+func (vc *VerificationController) VerifyEmail(c *fiber.Ctx) error {
+	var input struct {
+		Token string `json:"token"`
+	}
+
+	if err := c.BodyParser(&input); err != nil || input.Token == "" {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	user, err := vc.service.VerifyEmail(c.UserContext(), input.Token)
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	return c.JSON(response.SuccessResponse("Email verified successfully", fiber.Map{
+		"id":                user.ID,
+		"email":             user.Email,
+		"email_verified":    user.EmailVerified,
+		"email_verified_at": user.EmailVerifiedAt,
+	}))
+}
+
+// ResendVerification là endpoint gửi lại email xác thực (giới hạn số lần gửi)
+func (vc *VerificationController) ResendVerification(c *fiber.Ctx) error {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.BodyParser(&input); err != nil {
+		return response.ErrorResponse("Invalid request body", fiber.StatusBadRequest)
+	}
+
+	err := vc.service.ResendVerification(c.UserContext(), input.Email)
+	if errors.Is(err, service.ErrTooManyRequests) {
+		return response.ErrorResponse(err.Error(), fiber.StatusTooManyRequests)
+	}
+	if err != nil {
+		return response.ErrorResponse(err.Error(), fiber.StatusBadRequest)
+	}
+
+	// Cùng một phản hồi dù email có tồn tại hay không
+	return c.JSON(response.SuccessResponse("If the account exists and is not verified, a verification email has been sent", nil))
+}
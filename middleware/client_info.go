@@ -0,0 +1,54 @@
+// File: middleware/client_info.go
+package middleware
+
+import (
+	"base-app/pkg/clientinfo"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// DeviceCookieName là cookie nhận diện trình duyệt, dùng để phát hiện đăng nhập từ thiết bị mới
+	DeviceCookieName   = "device_id"
+	deviceCookieMaxAge = 400 * 24 * time.Hour
+)
+
+// ClientInfo gắn IP, User-Agent và device cookie của request vào c.UserContext() để tầng service
+// ghi nhận thiết bị khi tạo phiên đăng nhập
+func ClientInfo() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		info := clientinfo.Info{
+			IP:        c.IP(),
+			UserAgent: c.Get(fiber.HeaderUserAgent),
+		}
+		if deviceID, err := uuid.Parse(c.Cookies(DeviceCookieName)); err == nil {
+			info.DeviceID = deviceID.String()
+		}
+		c.SetUserContext(clientinfo.With(c.UserContext(), info))
+		return c.Next()
+	}
+}
+
+// DeviceCookie cấp device cookie cho trình duyệt chưa có, dùng trên các route đăng nhập.
+// Phải đặt sau ClientInfo.
+func DeviceCookie() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		info := clientinfo.From(c.UserContext())
+		if info.DeviceID == "" {
+			info.DeviceID = uuid.New().String()
+			c.Cookie(&fiber.Cookie{
+				Name:     DeviceCookieName,
+				Value:    info.DeviceID,
+				Path:     "/",
+				MaxAge:   int(deviceCookieMaxAge.Seconds()),
+				Secure:   c.Protocol() == "https",
+				HTTPOnly: true,
+				SameSite: fiber.CookieSameSiteLaxMode,
+			})
+			c.SetUserContext(clientinfo.With(c.UserContext(), info))
+		}
+		return c.Next()
+	}
+}
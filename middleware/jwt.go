@@ -2,22 +2,204 @@
 package middleware
 
 import (
-	"base-app/utils"
+	"base-app/model"
+	"base-app/pkg/clientinfo"
+	"base-app/pkg/token"
+	"base-app/repository"
+	"context"
+	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func JWTMiddleware(c *fiber.Ctx) error {
-	header := c.Get("Authorization")
-	if header == "" || !strings.HasPrefix(header, "Bearer ") {
-		return c.SendStatus(fiber.StatusUnauthorized)
+const (
+	claimsKey = "claims"
+	tokenKey  = "token"
+)
+
+// APIKeyVerifier xác thực API key (personal access token) và trả về claims của chủ sở hữu
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*token.Claims, error)
+}
+
+// Authenticate là middleware xác thực duy nhất cho các route cần đăng nhập:
+//   - lấy token từ header Authorization: Bearer <token> (hoặc API key qua Bearer / X-API-Key)
+//   - kiểm tra chữ ký (theo kid), thời hạn, issuer và audience
+//   - từ chối token đã bị thu hồi (không còn tồn tại trong Redis)
+//   - API key chỉ gọi được endpoint GET khi có scope read, các method khác khi có scope write
+//
+// Claims đã định kiểu được lưu vào context, handler lấy ra qua CurrentClaims.
+func Authenticate(tokens *token.Manager, redisRepo repository.RedisRepository, apiKeys APIKeyVerifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenStr, ok := bearerToken(c)
+		if !ok {
+			tokenStr = strings.TrimSpace(c.Get("X-API-Key"))
+			if tokenStr == "" {
+				return unauthorized(c)
+			}
+		}
+
+		if strings.HasPrefix(tokenStr, model.APIKeyPrefix) {
+			return authenticateAPIKey(c, apiKeys, tokenStr)
+		}
+
+		claims, err := tokens.Parse(tokenStr)
+		if err != nil {
+			return unauthorized(c)
+		}
+
+		if !redisRepo.IsTokenValid(c.UserContext(), tokenStr) {
+			return unauthorized(c)
+		}
+
+		// Ghi nhận hoạt động của phiên (hiển thị "last seen" trong danh sách phiên)
+		if claims.SessionID != "" {
+			if err := redisRepo.TouchSession(c.UserContext(), claims.SessionID, c.IP(), time.Now(), 0); err != nil {
+				fmt.Printf("warning: failed to update session last seen in Redis: %v\n", err)
+			}
+		}
+
+		// Request dưới danh nghĩa người dùng: ghi log và đánh dấu để audit log ghi nhận quản trị viên thực hiện
+		if claims.IsImpersonated() {
+			log.Printf("🎭 [impersonation] admin=%s user=%s %s %s", claims.ActorID(), claims.UserID(), c.Method(), c.Path())
+			info := clientinfo.From(c.UserContext())
+			info.ImpersonatorID = claims.ActorID()
+			c.SetUserContext(clientinfo.With(c.UserContext(), info))
+		}
+
+		c.Locals(claimsKey, claims)
+		c.Locals(tokenKey, tokenStr)
+		return c.Next()
 	}
-	tokenStr := strings.TrimPrefix(header, "Bearer ")
-	user, err := utils.ParseJWT(tokenStr)
+}
+
+// authenticateAPIKey xác thực API key và kiểm tra scope theo HTTP method
+func authenticateAPIKey(c *fiber.Ctx, apiKeys APIKeyVerifier, key string) error {
+	if apiKeys == nil {
+		return unauthorized(c)
+	}
+
+	claims, err := apiKeys.VerifyAPIKey(c.UserContext(), key)
 	if err != nil {
-		return c.SendStatus(fiber.StatusUnauthorized)
+		return unauthorized(c)
+	}
+
+	required := model.APIKeyScopeWrite
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		required = model.APIKeyScopeRead
 	}
-	c.Locals("user", user)
+	if !claims.HasScope(required) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "API key does not have the " + required + " scope",
+		})
+	}
+
+	c.Locals(claimsKey, claims)
 	return c.Next()
 }
+
+// RequireSession chặn API key và token impersonation ở các thao tác nhạy cảm
+// (quản lý API key, mật khẩu, MFA, xóa tài khoản, ...). Phải đặt sau Authenticate.
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := CurrentClaims(c)
+		if err != nil {
+			return unauthorized(c)
+		}
+		if claims.IsAPIKey() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "This action requires an interactive session, API keys are not allowed",
+			})
+		}
+		if claims.IsImpersonated() {
+			return forbiddenWhileImpersonating(c)
+		}
+		return c.Next()
+	}
+}
+
+// ForbidImpersonation chặn token impersonation nhưng vẫn cho phép API key, dùng cho thao tác nhạy cảm
+// mà API key được phép gọi (ví dụ đổi email). Phải đặt sau Authenticate.
+func ForbidImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := CurrentClaims(c)
+		if err != nil {
+			return unauthorized(c)
+		}
+		if claims.IsImpersonated() {
+			return forbiddenWhileImpersonating(c)
+		}
+		return c.Next()
+	}
+}
+
+// RequireRecentAuth (step-up) chỉ cho phép token có auth_time trong khoảng maxAge vừa qua, dùng cho thao tác
+// nhạy cảm (đổi mật khẩu, xóa tài khoản). Người dùng lấy token mới qua POST /auth/reauthenticate.
+// Phải đặt sau Authenticate.
+func RequireRecentAuth(maxAge time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := CurrentClaims(c)
+		if err != nil {
+			return unauthorized(c)
+		}
+		if !claims.AuthenticatedWithin(maxAge) {
+			return InsufficientAuthentication(c)
+		}
+		return c.Next()
+	}
+}
+
+// InsufficientAuthentication - trả lỗi 401 yêu cầu xác thực lại (RFC 9470)
+func InsufficientAuthentication(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="insufficient_user_authentication", error_description="A recent authentication is required"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error":              "Recent authentication required, please reauthenticate",
+		"reauthenticate_url": "/api/v1/auth/reauthenticate",
+	})
+}
+
+// CurrentClaims trả về claims của token đã được Authenticate xác thực
+func CurrentClaims(c *fiber.Ctx) (*token.Claims, error) {
+	claims, ok := c.Locals(claimsKey).(*token.Claims)
+	if !ok || claims == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	return claims, nil
+}
+
+// CurrentToken trả về access token gốc của request hiện tại
+func CurrentToken(c *fiber.Ctx) (string, error) {
+	tokenStr, ok := c.Locals(tokenKey).(string)
+	if !ok || tokenStr == "" {
+		return "", fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+	return tokenStr, nil
+}
+
+// bearerToken tách token khỏi header Authorization
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	header := c.Get(fiber.HeaderAuthorization)
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", false
+	}
+	tokenStr := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	return tokenStr, tokenStr != ""
+}
+
+// forbiddenWhileImpersonating - trả lỗi 403 cho thao tác không được phép khi đăng nhập dưới danh nghĩa người dùng
+func forbiddenWhileImpersonating(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "This action is not allowed while impersonating a user",
+	})
+}
+
+// unauthorized - trả lỗi 401 thống nhất cho mọi lỗi xác thực
+func unauthorized(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": "Unauthorized or invalid token",
+	})
+}
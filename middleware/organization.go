@@ -0,0 +1,24 @@
+// File: middleware/organization.go
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireOrganization chỉ cho phép request có tổ chức đang hoạt động (claim org_id).
+// Handler lấy organization ID từ claims, không nhận từ client. Phải đặt sau Authenticate.
+func RequireOrganization() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := CurrentClaims(c)
+		if err != nil {
+			return unauthorized(c)
+		}
+
+		if claims.OrgID == "" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "No active organization",
+			})
+		}
+		return c.Next()
+	}
+}
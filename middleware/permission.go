@@ -0,0 +1,54 @@
+// File: middleware/permission.go
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionChecker kiểm tra vai trò có quyền hay không (RoleService)
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+}
+
+// Authorizer tạo các middleware phân quyền theo quyền của vai trò trong claims
+type Authorizer struct {
+	checker PermissionChecker
+}
+
+// NewAuthorizer tạo authorizer
+func NewAuthorizer(checker PermissionChecker) *Authorizer {
+	return &Authorizer{checker: checker}
+}
+
+// RequirePermission chỉ cho phép vai trò có đủ tất cả các quyền, ví dụ RequirePermission("users:read").
+// Token impersonation không dùng được các route quản trị. Phải đặt sau Authenticate.
+func (a *Authorizer) RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := CurrentClaims(c)
+		if err != nil {
+			return unauthorized(c)
+		}
+		if claims.IsImpersonated() {
+			return forbiddenWhileImpersonating(c)
+		}
+
+		for _, permission := range permissions {
+			allowed, err := a.checker.HasPermission(c.UserContext(), claims.Role, permission)
+			if err != nil {
+				fmt.Printf("warning: permission check failed: %v\n", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Internal Server Error",
+				})
+			}
+			if !allowed {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Forbidden",
+				})
+			}
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix là tiền tố của mọi API key, giúp phân biệt với JWT và dễ quét khi bị lộ trong mã nguồn
+const APIKeyPrefix = "bak_"
+
+// Scope của API key
+const (
+	APIKeyScopeRead  = "read"  // gọi các endpoint GET
+	APIKeyScopeWrite = "write" // gọi các endpoint thay đổi dữ liệu
+	APIKeyScopeAdmin = "admin" // dùng quyền admin của chủ sở hữu (nếu có)
+)
+
+// APIKey là personal access token dùng cho script / CI thay cho mật khẩu.
+// Chỉ lưu SHA-256 của key; key gốc chỉ hiển thị một lần khi tạo.
+type APIKey struct {
+	ID         string     `gorm:"primaryKey" json:"id"`
+	UserID     string     `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"`        // vài ký tự đầu của key để người dùng nhận biết
+	KeyHash    string     `gorm:"not null;uniqueIndex" json:"-"` // SHA-256 (hex) của key
+	Scopes     string     `gorm:"type:text;not null" json:"-"`   // phân tách bởi khoảng trắng
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ScopeList trả về danh sách scope của key
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// Active cho biết key còn dùng được (chưa thu hồi, chưa hết hạn)
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
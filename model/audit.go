@@ -0,0 +1,98 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Hành động được ghi vào audit log, dạng "<nhóm>.<hành động>"
+const (
+	AuditActionRegister        = "user.register"
+	AuditActionLogin           = "auth.login"
+	AuditActionMFAVerify       = "auth.mfa_verify"
+	AuditActionTokenRefresh    = "auth.token_refresh"
+	AuditActionLogout          = "auth.logout"
+	AuditActionLogoutAll       = "auth.logout_all"
+	AuditActionReauthenticate  = "auth.reauthenticate"
+	AuditActionNewDevice       = "auth.new_device"
+	AuditActionNewNetwork      = "auth.new_network"
+	AuditActionProfileUpdate   = "user.profile_update"
+	AuditActionPasswordChange  = "user.password_change"
+	AuditActionPasswordReset   = "user.password_reset"
+	AuditActionMFAEnable       = "user.mfa_enable"
+	AuditActionMFADisable      = "user.mfa_disable"
+	AuditActionRecoveryCodes   = "user.mfa_recovery_codes_regenerate"
+	AuditActionAPIKeyCreate    = "user.api_key_create"
+	AuditActionAPIKeyRevoke    = "user.api_key_revoke"
+	AuditActionSessionRevoke   = "user.session_revoke"
+	AuditActionAccountDelete   = "user.account_delete"
+	AuditActionAccountRestore  = "user.account_restore"
+	AuditActionAccountPurge    = "user.account_purge"
+	AuditActionDeviceForget    = "user.device_forget"
+	AuditActionLoginReported   = "user.login_reported"
+	AuditActionUserDisable     = "admin.user_disable"
+	AuditActionUserEnable      = "admin.user_enable"
+	AuditActionUserDelete      = "admin.user_delete"
+	AuditActionUserImpersonate = "admin.user_impersonate"
+	AuditActionRoleAssign      = "admin.user_role_assign"
+	AuditActionRoleCreate      = "admin.role_create"
+	AuditActionRoleDelete      = "admin.role_delete"
+	AuditActionRoleGrant       = "admin.role_permission_grant"
+	AuditActionRoleRevoke      = "admin.role_permission_revoke"
+	AuditActionLockoutClear    = "admin.lockout_clear"
+)
+
+// Kết quả của hành động
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// AuditMetadata là dữ liệu kèm theo của sự kiện, lưu dạng jsonb
+type AuditMetadata map[string]interface{}
+
+// Value - lưu metadata vào Postgres dưới dạng JSON
+func (m AuditMetadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(m)
+	return string(data), err
+}
+
+// Scan - đọc metadata từ cột jsonb
+func (m *AuditMetadata) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported audit metadata type")
+	}
+	return json.Unmarshal(data, m)
+}
+
+// AuditEvent là một bản ghi audit log (chỉ thêm, không sửa / xóa).
+// ActorID là người thực hiện, TargetID là tài khoản bị tác động (có thể trùng nhau).
+// ImpersonatorID là quản trị viên thực sự thực hiện khi hành động diễn ra dưới danh nghĩa người dùng.
+type AuditEvent struct {
+	ID        string `gorm:"primaryKey" json:"id"`
+	ActorID   string `gorm:"index" json:"actor_id,omitempty"`
+	TargetID  string `gorm:"index" json:"target_id,omitempty"`
+	Action    string `gorm:"not null;index" json:"action"`
+	Result    string `gorm:"not null" json:"result"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+
+	ImpersonatorID string `gorm:"index" json:"impersonator_id,omitempty"`
+
+	Metadata  AuditMetadata `gorm:"type:jsonb;not null;default:'{}'" json:"metadata,omitempty"`
+	CreatedAt time.Time     `gorm:"autoCreateTime;index" json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Trạng thái của một yêu cầu export dữ liệu
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport là một yêu cầu export toàn bộ dữ liệu của người dùng, lưu trong Redis cùng file ZIP
+// và tự hết hạn sau DATA_EXPORT_TTL
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
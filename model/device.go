@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// KnownDevice là thiết bị người dùng đã từng đăng nhập, dùng để phát hiện đăng nhập từ thiết bị mới.
+// Fingerprint là SHA-256 của device cookie + trình duyệt / hệ điều hành (client không gửi cookie thì dùng dải IP).
+type KnownDevice struct {
+	ID          string    `gorm:"primaryKey" json:"id"`
+	UserID      string    `gorm:"not null;uniqueIndex:idx_known_devices_user_fingerprint" json:"-"`
+	Fingerprint string    `gorm:"not null;uniqueIndex:idx_known_devices_user_fingerprint" json:"-"`
+	Name        string    `json:"name"` // ví dụ "Chrome on macOS"
+	UserAgent   string    `json:"user_agent"`
+	LastIP      string    `json:"last_ip"`
+	Network     string    `json:"network"` // dải IP (CIDR) của lần đăng nhập gần nhất
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Identity liên kết tài khoản local với một tài khoản ở identity provider bên ngoài (Google, Microsoft, Keycloak, ...).
+// Cặp (Provider, Subject) là duy nhất: sub trong ID token là định danh ổn định, email có thể thay đổi.
+type Identity struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	UserID      string     `gorm:"not null;index" json:"user_id"`
+	Provider    string     `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string     `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email       string     `json:"email"` // email do IdP cung cấp tại lần đăng nhập gần nhất
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Invitation là lời mời tham gia tổ chức gửi qua email.
+// Chỉ lưu SHA-256 của token; token gốc chỉ có trong link gửi cho người được mời và dùng được một lần.
+type Invitation struct {
+	ID             string     `gorm:"primaryKey" json:"id"`
+	OrganizationID string     `gorm:"not null;index" json:"organization_id"`
+	Email          string     `gorm:"not null;index" json:"email"` // chữ thường
+	Role           string     `gorm:"not null" json:"role"`
+	InvitedBy      string     `gorm:"not null" json:"invited_by"`
+	TokenHash      string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	AcceptedBy     string     `json:"accepted_by,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Pending - lời mời chưa được chấp nhận, chưa bị thu hồi và chưa hết hạn
+func (i *Invitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
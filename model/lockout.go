@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Đối tượng bị khóa đăng nhập
+const (
+	LockoutKindAccount = "account" // theo email đăng nhập
+	LockoutKindIP      = "ip"      // theo địa chỉ IP
+)
+
+// Hành động được ghi vào lịch sử khóa
+const (
+	LockoutActionLocked  = "locked"
+	LockoutActionCleared = "cleared"
+)
+
+// Lockout là một khóa đăng nhập tạm thời đang có hiệu lực (lưu trong Redis)
+type Lockout struct {
+	Kind        string    `json:"kind"`
+	Value       string    `json:"value"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// LockoutEvent là lịch sử khóa / mở khóa đăng nhập, lưu trong Postgres
+type LockoutEvent struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	Kind        string     `gorm:"not null;index:idx_lockout_event_subject" json:"kind"`
+	Value       string     `gorm:"not null;index:idx_lockout_event_subject" json:"value"`
+	Action      string     `gorm:"not null" json:"action"`
+	UserID      string     `gorm:"index" json:"user_id,omitempty"` // tài khoản bị khóa (nếu tồn tại)
+	IP          string     `json:"ip,omitempty"`                   // IP của lần thử cuối cùng
+	Failures    int64      `json:"failures,omitempty"`             // số lần sai trong cửa sổ
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	ActorID     string     `json:"actor_id,omitempty"` // admin đã mở khóa
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// RecoveryCode là mã khôi phục dùng một lần khi người dùng mất thiết bị TOTP
+type RecoveryCode struct {
+	ID        string     `gorm:"primaryKey" json:"id"`
+	UserID    string     `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"` // Chỉ lưu SHA-256 của mã, không lưu bản rõ
+	UsedAt    *time.Time `json:"used_at"`           // Thời điểm đã sử dụng, nil nếu còn hiệu lực
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
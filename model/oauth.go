@@ -0,0 +1,50 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	OAuthClientConfidential = "confidential" // có client secret (ứng dụng server-side)
+	OAuthClientPublic       = "public"       // không giữ được secret (SPA, mobile), bắt buộc PKCE
+)
+
+// OAuthClient là ứng dụng được đăng ký để đăng nhập qua authorization server (OIDC)
+type OAuthClient struct {
+	ID           string    `gorm:"primaryKey" json:"client_id"`
+	Name         string    `gorm:"not null" json:"name"`
+	SecretHash   string    `json:"-"`                                         // bcrypt của client secret, rỗng với public client
+	Type         string    `gorm:"not null" json:"type"`                      // confidential | public
+	RedirectURIs string    `gorm:"type:text;not null" json:"-"`               // danh sách redirect URI, phân tách bởi khoảng trắng
+	Scopes       string    `gorm:"type:text;not null" json:"-"`               // scope được phép, phân tách bởi khoảng trắng
+	FirstParty   bool      `gorm:"not null;default:false" json:"first_party"` // ứng dụng nội bộ: bỏ qua màn hình consent
+	OwnerID      string    `gorm:"index" json:"owner_id"`                     // admin đã đăng ký client
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// RedirectURIList trả về danh sách redirect URI đã đăng ký
+func (c *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
+
+// ScopeList trả về danh sách scope được phép
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// IsPublic cho biết client không có secret
+func (c *OAuthClient) IsPublic() bool {
+	return c.Type == OAuthClientPublic
+}
+
+// OAuthConsent lưu các scope người dùng đã đồng ý cấp cho một client
+type OAuthConsent struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	UserID    string    `gorm:"not null;uniqueIndex:idx_oauth_consent_user_client" json:"user_id"`
+	ClientID  string    `gorm:"not null;uniqueIndex:idx_oauth_consent_user_client" json:"client_id"`
+	Scopes    string    `gorm:"type:text;not null" json:"scopes"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// Vai trò của thành viên trong một tổ chức (độc lập với User.Role của hệ thống)
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// OrgRoles là các vai trò hợp lệ, theo thứ tự quyền giảm dần
+var OrgRoles = []string{OrgRoleOwner, OrgRoleAdmin, OrgRoleMember}
+
+// Organization là một tenant (công ty / nhóm khách hàng)
+type Organization struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Slug      string    `gorm:"not null;uniqueIndex" json:"slug"`
+	CreatedBy string    `gorm:"not null" json:"created_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Membership là tư cách thành viên của người dùng trong tổ chức, mỗi tổ chức có vai trò riêng
+type Membership struct {
+	ID             string    `gorm:"primaryKey" json:"id"`
+	OrganizationID string    `gorm:"not null;uniqueIndex:idx_membership_org_user" json:"organization_id"`
+	UserID         string    `gorm:"not null;uniqueIndex:idx_membership_org_user;index" json:"user_id"`
+	Role           string    `gorm:"not null" json:"role"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OrganizationMembership là tổ chức người dùng tham gia kèm vai trò của người dùng trong đó
+type OrganizationMembership struct {
+	Organization
+	Role   string `json:"role"`
+	Active bool   `gorm:"-" json:"active"` // tổ chức đang hoạt động của phiên hiện tại
+}
+
+// Member là thành viên của tổ chức kèm thông tin người dùng
+type Member struct {
+	UserID   string    `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// OrgRoleRank - thứ hạng của vai trò trong tổ chức, số nhỏ hơn là quyền cao hơn (-1 nếu không hợp lệ)
+func OrgRoleRank(role string) int {
+	for i, r := range OrgRoles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Quyền có sẵn, dạng "<tài nguyên>:<hành động>". "*" là mọi quyền, "<tài nguyên>:*" là mọi hành động trên tài nguyên.
+const (
+	PermissionAll                = "*"
+	PermissionUsersRead          = "users:read"
+	PermissionUsersWrite         = "users:write"
+	PermissionUsersImpersonate   = "users:impersonate"
+	PermissionRolesManage        = "roles:manage"
+	PermissionLockoutsManage     = "lockouts:manage"
+	PermissionOAuthClientsManage = "oauth_clients:manage"
+	PermissionAuditRead          = "audit:read"
+)
+
+// Role là vai trò gán cho người dùng (users.role), quyền của vai trò nằm trong bảng role_permissions
+type Role struct {
+	Name        string    `gorm:"primaryKey" json:"name"`
+	Description string    `json:"description"`
+	System      bool      `gorm:"not null;default:false" json:"system"` // vai trò mặc định (admin, user), không xóa được
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Permissions []string `gorm:"-" json:"permissions"`
+}
+
+// RolePermission là một quyền được cấp cho vai trò
+type RolePermission struct {
+	Role       string    `gorm:"primaryKey"`
+	Permission string    `gorm:"primaryKey"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// PermissionGrants kiểm tra quyền granted có bao hàm quyền required hay không
+func PermissionGrants(granted, required string) bool {
+	if granted == PermissionAll || granted == required {
+		return true
+	}
+	resource, ok := strings.CutSuffix(granted, ":*")
+	return ok && strings.HasPrefix(required, resource+":")
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Session là một phiên đăng nhập (một thiết bị), lưu trong Redis.
+// ID trùng với refresh token family của lần đăng nhập đó; không chứa token.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // phiên của request hiện tại
+
+	OrganizationID string `json:"organization_id,omitempty"` // tổ chức đang hoạt động của phiên
+
+	// Lần xác thực gần nhất của phiên (đăng nhập hoặc xác thực lại) và các phương thức đã dùng (RFC 8176)
+	AuthTime time.Time `json:"auth_time"`
+	AMR      []string  `json:"amr,omitempty"`
+}
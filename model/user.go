@@ -9,6 +9,14 @@ const (
 	RoleUser  = "user"
 )
 
+// Trạng thái tài khoản
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled" // bị admin vô hiệu hóa, không đăng nhập được
+
+	UserStatusPendingDeletion = "pending_deletion" // người dùng tự xóa, còn khôi phục được trong thời gian ân hạn
+)
+
 // User mô tả cấu trúc dữ liệu người dùng
 type User struct {
 	ID        string    `gorm:"primaryKey" json:"id"`             // Sử dụng kiểu uint cho ID, PostgreSQL sẽ tự động sinh giá trị (SERIAL)
@@ -18,4 +26,25 @@ type User struct {
 	Role      string    `gorm:"not null" json:"role"`             // Vai trò của người dùng, có thể có giá trị như "admin", "user", v.v.
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"` // Tự động gán thời gian tạo
 	UpdatedAt time.Time `gorm:"autoCreateTime" json:"Updated_at"` // Tự động gán thời gian autoUpdateTime
+
+	// Xác thực email
+	EmailVerified   bool       `gorm:"not null;default:false" json:"email_verified"` // Email đã được xác thực qua link hay chưa
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`                            // Thời điểm xác thực email
+
+	// Xác thực hai lớp (TOTP)
+	MFAEnabled bool   `gorm:"not null;default:false" json:"mfa_enabled"` // Đã bật xác thực hai lớp hay chưa
+	TOTPSecret string `json:"-"`                                         // Secret TOTP (base32), không bao giờ trả về client
+
+	// Tổ chức được chọn gần nhất, dùng làm tổ chức đang hoạt động cho phiên đăng nhập mới
+	ActiveOrganizationID string `json:"active_organization_id,omitempty"`
+
+	// Trạng thái tài khoản (active / disabled)
+	Status     string     `gorm:"not null;default:active;index" json:"status"`
+	DisabledAt *time.Time `json:"disabled_at"`
+
+	// Thời điểm người dùng yêu cầu xóa tài khoản (status = pending_deletion)
+	DeletionRequestedAt *time.Time `gorm:"index" json:"deletion_requested_at,omitempty"`
+
+	// Người dùng báo một lần đăng nhập không phải của mình: không đăng nhập bằng mật khẩu được cho tới khi đổi mật khẩu
+	PasswordResetRequired bool `gorm:"not null;default:false" json:"password_reset_required"`
 }
@@ -0,0 +1,32 @@
+// File: pkg/clientinfo/clientinfo.go
+package clientinfo
+
+import (
+	"context"
+)
+
+// Info là thông tin về client gửi request (IP, User-Agent), được truyền xuống tầng service qua context
+type Info struct {
+	IP        string
+	UserAgent string
+	DeviceID  string // giá trị device cookie, rỗng nếu client không gửi cookie
+
+	// ImpersonatorID là quản trị viên đang đăng nhập dưới danh nghĩa người dùng, rỗng với request thông thường
+	ImpersonatorID string
+}
+
+type contextKey struct{}
+
+// With gắn thông tin client vào context
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// From lấy thông tin client từ context; trả về Info rỗng nếu không có
+func From(ctx context.Context) Info {
+	if ctx == nil {
+		return Info{}
+	}
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
@@ -3,8 +3,47 @@ package db
 
 import (
 	"base-app/model"
+	"log"
 )
 
 func Migrate() {
-	DB.AutoMigrate(&model.User{}) // có thể thêm nhiều model khác ở đây
+	// Bảng users có từ trước khi có xác thực email: ghi nhận trước khi AutoMigrate thêm cột
+	backfillEmailVerified := DB.Migrator().HasTable(&model.User{}) && !DB.Migrator().HasColumn(&model.User{}, "EmailVerified")
+
+	DB.AutoMigrate(
+		&model.User{},
+		&model.RecoveryCode{},
+		&model.OAuthClient{},
+		&model.OAuthConsent{},
+		&model.Identity{},
+		&model.APIKey{},
+		&model.LockoutEvent{},
+		&model.Role{},
+		&model.RolePermission{},
+		&model.Organization{},
+		&model.Membership{},
+		&model.Invitation{},
+		&model.AuditEvent{},
+		&model.KnownDevice{},
+	) // có thể thêm nhiều model khác ở đây
+
+	// Tài khoản tạo trước khi có xác thực email được coi là đã xác thực,
+	// để việc chặn đăng nhập tài khoản chưa xác thực (mặc định) không khóa người dùng cũ.
+	// Chỉ chạy đúng một lần: lúc cột email_verified vừa được thêm.
+	if backfillEmailVerified {
+		if err := DB.Exec(`UPDATE users SET email_verified = true, email_verified_at = created_at WHERE email_verified = false`).Error; err != nil {
+			log.Fatalf("❌ Failed to mark existing users as verified: %v", err)
+		}
+		log.Println("✅ Marked existing users as email verified")
+	}
+
+	// Audit log chỉ được thêm, Postgres từ chối UPDATE / DELETE trên bảng audit_events
+	DB.Exec(`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql`)
+	DB.Exec(`DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events`)
+	DB.Exec(`CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()`)
 }
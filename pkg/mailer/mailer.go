@@ -0,0 +1,58 @@
+// File: pkg/mailer/mailer.go
+package mailer
+
+import (
+	"base-app/config"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message là một email gửi đi (chỉ hỗ trợ nội dung text)
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Mailer là interface gửi email, cho phép thay thế SMTP bằng outbox khi dev / test
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New khởi tạo Mailer theo MAIL_DRIVER: smtp | file | memory.
+// Để trống chỉ hợp lệ ở môi trường development (dùng memory), môi trường khác sẽ lỗi khi khởi động.
+func New(cfg config.Config) (Mailer, error) {
+	switch strings.ToLower(cfg.MailDriver) {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.MailFrom), nil
+	case "file":
+		return NewFileMailer(cfg.MailOutboxDir, cfg.MailFrom)
+	case "memory":
+		return NewMemoryMailer(), nil
+	case "":
+		// Không khai báo driver chỉ được chấp nhận khi dev: ở production email sẽ nằm trong bộ nhớ và không ai nhận được
+		if !cfg.IsDevelopment() {
+			return nil, fmt.Errorf("MAIL_DRIVER must be set when APP_ENV is %q", cfg.AppEnv)
+		}
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", cfg.MailDriver)
+	}
+}
+
+// render tạo nội dung email theo định dạng RFC 5322 (dùng chung cho SMTP và file outbox)
+func render(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + msg.SentAt.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
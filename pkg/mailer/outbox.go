@@ -0,0 +1,89 @@
+// File: pkg/mailer/outbox.go
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer ghi mỗi email thành một file .eml trong thư mục outbox (dùng cho môi trường dev)
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir == "" {
+		dir = "outbox"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create mail outbox dir: %v", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	msg.SentAt = time.Now()
+	name := fmt.Sprintf("%s-%s.eml", msg.SentAt.Format("20060102T150405"), uuid.New().String())
+	path := filepath.Join(m.dir, name)
+
+	if err := os.WriteFile(path, render(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write email to outbox: %v", err)
+	}
+	log.Printf("📧 Email to %s written to %s", msg.To, path)
+	return nil
+}
+
+// MemoryMailer giữ email trong bộ nhớ, dùng cho test và môi trường dev không cần đọc email thật
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	msg.SentAt = time.Now()
+
+	m.mu.Lock()
+	m.messages = append(m.messages, msg)
+	m.mu.Unlock()
+
+	log.Printf("📧 Email to %s stored in memory outbox: %s", msg.To, msg.Subject)
+	return nil
+}
+
+// Messages trả về bản sao các email đã gửi
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Last trả về email gửi gần nhất tới địa chỉ to
+func (m *MemoryMailer) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset xóa toàn bộ email trong outbox
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	m.messages = nil
+	m.mu.Unlock()
+}
@@ -0,0 +1,44 @@
+// File: pkg/mailer/smtp.go
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer gửi email qua SMTP server (tự dùng STARTTLS nếu server hỗ trợ)
+type SMTPMailer struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host, port, user, pass, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, pass, host)
+	}
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, port),
+		host: host,
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid email header")
+	}
+	msg.SentAt = time.Now()
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, render(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send email via SMTP: %v", err)
+	}
+	return nil
+}
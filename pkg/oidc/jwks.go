@@ -0,0 +1,167 @@
+// File: pkg/oidc/jwks.go
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Không tải lại JWKS quá thường xuyên khi gặp kid lạ (tránh bị dùng để spam IdP)
+const jwksMinRefreshInterval = time.Minute
+
+// jwk là một khóa công khai trong JWKS của IdP
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// remoteKeySet lưu cache JWKS của IdP, tải lại khi gặp kid chưa biết (IdP xoay vòng khóa)
+type remoteKeySet struct {
+	client *http.Client
+	url    string
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func newRemoteKeySet(client *http.Client, url string) *remoteKeySet {
+	return &remoteKeySet{client: client, url: url, keys: map[string]interface{}{}}
+}
+
+// key trả về khóa công khai theo kid
+func (r *remoteKeySet) key(ctx context.Context, kid string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key, ok := r.lookup(kid); ok {
+		return key, nil
+	}
+
+	if time.Since(r.fetchedAt) < jwksMinRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if err := r.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	if key, ok := r.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup - token không có kid chỉ được chấp nhận khi JWKS có đúng một khóa
+func (r *remoteKeySet) lookup(kid string) (interface{}, bool) {
+	if kid == "" {
+		if len(r.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range r.keys {
+			return key, true
+		}
+	}
+	key, ok := r.keys[kid]
+	return key, ok
+}
+
+func (r *remoteKeySet) fetch(ctx context.Context) error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, r.client, r.url, &set); err != nil {
+		return fmt.Errorf("could not load jwks: %v", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	r.keys = keys
+	r.fetchedAt = time.Now()
+	return nil
+}
+
+// publicKey chuyển JWK thành khóa công khai dùng cho jwt
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid base64url value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
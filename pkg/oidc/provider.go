@@ -0,0 +1,268 @@
+// File: pkg/oidc/provider.go
+package oidc
+
+import (
+	"base-app/config"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Thuật toán chữ ký chấp nhận cho ID token, không bao giờ chấp nhận HS* / none
+var allowedAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ErrInvalidIDToken - ID token không hợp lệ (chữ ký, issuer, audience, nonce, hạn dùng)
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// IDTokenClaims là các claim cần dùng trong ID token do identity provider phát hành
+type IDTokenClaims struct {
+	Nonce           string `json:"nonce"`
+	Email           string `json:"email"`
+	EmailVerified   any    `json:"email_verified"` // một số IdP trả về chuỗi "true"
+	Name            string `json:"name"`
+	AuthorizedParty string `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// IsEmailVerified cho biết IdP đã xác thực email hay chưa
+func (c *IDTokenClaims) IsEmailVerified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+// tokenResponse là phản hồi của token endpoint của IdP
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// discovery là phần cần dùng của /.well-known/openid-configuration
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider là một identity provider OpenID Connect (authorization code + PKCE)
+type Provider struct {
+	cfg    config.OIDCProvider
+	client *http.Client
+
+	mu         sync.Mutex
+	discovered bool
+	keys       *remoteKeySet
+}
+
+// NewProvider tạo provider từ cấu hình. Discovery document được tải ở lần dùng đầu tiên
+// để service vẫn khởi động được khi IdP tạm thời không truy cập được.
+func NewProvider(cfg config.OIDCProvider) (*Provider, error) {
+	if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc provider requires name, issuer, client_id and redirect_url")
+	}
+	if cfg.Label == "" {
+		cfg.Label = cfg.Name
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name trả về định danh của provider
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// Label trả về tên hiển thị của provider
+func (p *Provider) Label() string {
+	return p.cfg.Label
+}
+
+// AuthCodeURL tạo URL chuyển người dùng tới trang đăng nhập của IdP
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(p.cfg.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.cfg.AuthURL + separator + query.Encode(), nil
+}
+
+// Exchange đổi authorization code lấy ID token rồi xác thực ID token với nonce đã gửi đi
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*IDTokenClaims, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request to %s failed: %v", p.cfg.Name, err)
+	}
+	defer resp.Body.Close()
+
+	var body tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid token response from %s: %v", p.cfg.Name, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("token request to %s failed: %s %s", p.cfg.Name, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("token response from %s does not contain an id_token", p.cfg.Name)
+	}
+
+	return p.VerifyIDToken(ctx, body.IDToken, nonce)
+}
+
+// VerifyIDToken kiểm tra chữ ký (JWKS của IdP), iss, aud, azp, exp và nonce của ID token
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	},
+		jwt.WithValidMethods(allowedAlgorithms),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	// Token có nhiều audience thì azp phải là client của mình
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp does not match client_id", ErrInvalidIDToken)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// discover tải discovery document (một lần) để lấy các endpoint còn thiếu trong cấu hình
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovered {
+		return nil
+	}
+
+	if p.cfg.AuthURL == "" || p.cfg.TokenURL == "" || p.cfg.JWKSURL == "" {
+		wellKnown := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+
+		var doc discovery
+		if err := getJSON(ctx, p.client, wellKnown, &doc); err != nil {
+			return fmt.Errorf("could not load discovery document of %s: %v", p.cfg.Name, err)
+		}
+		if doc.Issuer != p.cfg.Issuer {
+			return fmt.Errorf("issuer mismatch for %s: expected %q, got %q", p.cfg.Name, p.cfg.Issuer, doc.Issuer)
+		}
+
+		if p.cfg.AuthURL == "" {
+			p.cfg.AuthURL = doc.AuthorizationEndpoint
+		}
+		if p.cfg.TokenURL == "" {
+			p.cfg.TokenURL = doc.TokenEndpoint
+		}
+		if p.cfg.JWKSURL == "" {
+			p.cfg.JWKSURL = doc.JWKSURI
+		}
+	}
+
+	if p.cfg.AuthURL == "" || p.cfg.TokenURL == "" || p.cfg.JWKSURL == "" {
+		return fmt.Errorf("oidc provider %s is missing authorization, token or jwks endpoint", p.cfg.Name)
+	}
+
+	p.keys = newRemoteKeySet(p.client, p.cfg.JWKSURL)
+	p.discovered = true
+	return nil
+}
+
+// codeChallenge - BASE64URL(SHA256(code_verifier)) theo RFC 7636
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// getJSON tải và decode một tài liệu JSON
+func getJSON(ctx context.Context, client *http.Client, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
@@ -0,0 +1,208 @@
+package oidc
+
+import (
+	"base-app/config"
+	"base-app/pkg/oidc/oidctest"
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+const (
+	testClientID    = "base-app"
+	testRedirectURL = "https://app.example.com/api/v1/auth/oidc/mock/callback"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636, Appendix B
+	got := codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Fatalf("codeChallenge() = %q, want %q", got, want)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp, provider := newTestProvider(t, testClientID)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid auth url %q: %v", authURL, err)
+	}
+	if got := parsed.Scheme + "://" + parsed.Host + parsed.Path; got != idp.URL+"/authorize" {
+		t.Errorf("authorization endpoint = %q, want %q", got, idp.URL+"/authorize")
+	}
+
+	query := parsed.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        codeChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name          string
+		nonceOverride string
+		verifier      string // code_verifier gửi khi đổi code, rỗng => verifier đã dùng ở AuthCodeURL
+		nonce         string // nonce mong đợi khi xác thực, rỗng => nonce đã gửi
+		reuseCode     bool
+		wantErr       error
+		wantAnyErr    bool
+	}{
+		{name: "valid code and verifier"},
+		{name: "pkce verifier mismatch", verifier: "another-verifier", wantAnyErr: true},
+		{name: "nonce replaced by idp", nonceOverride: "forged-nonce", wantErr: ErrInvalidIDToken},
+		{name: "nonce expected by client differs", nonce: "other-nonce", wantErr: ErrInvalidIDToken},
+		{name: "code used twice", reuseCode: true, wantAnyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp, provider := newTestProvider(t, testClientID)
+			idp.NonceOverride = tt.nonceOverride
+			idp.SetIdentity(oidctest.Identity{Subject: "sub-123", Email: "alice@example.com", EmailVerified: true, Name: "Alice"})
+
+			ctx := context.Background()
+			authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", "verifier")
+			if err != nil {
+				t.Fatalf("AuthCodeURL() error = %v", err)
+			}
+			code, state, err := idp.Authorize(authURL)
+			if err != nil {
+				t.Fatalf("Authorize() error = %v", err)
+			}
+			if state != "state" {
+				t.Fatalf("state = %q, want %q", state, "state")
+			}
+
+			verifier, nonce := "verifier", "nonce"
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if tt.reuseCode {
+				if _, err := provider.Exchange(ctx, code, verifier, nonce); err != nil {
+					t.Fatalf("first Exchange() error = %v", err)
+				}
+			}
+
+			claims, err := provider.Exchange(ctx, code, verifier, nonce)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Exchange() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantAnyErr:
+				if err == nil {
+					t.Fatal("Exchange() error = nil, want an error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("Exchange() error = %v", err)
+			}
+
+			if claims.Subject != "sub-123" || claims.Email != "alice@example.com" || claims.Name != "Alice" {
+				t.Errorf("unexpected claims: %+v", claims)
+			}
+			if !claims.IsEmailVerified() {
+				t.Error("IsEmailVerified() = false, want true")
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp, provider := newTestProvider(t, testClientID)
+	identity := oidctest.Identity{Subject: "sub-123", Email: "alice@example.com"}
+
+	tests := []struct {
+		name     string
+		identity oidctest.Identity
+		audience string
+		nonce    string
+		wantErr  bool
+	}{
+		{name: "valid", identity: identity, audience: testClientID, nonce: "nonce"},
+		{name: "issued for another client", identity: identity, audience: "other-client", nonce: "nonce", wantErr: true},
+		{name: "nonce mismatch", identity: identity, audience: testClientID, nonce: "other", wantErr: true},
+		{name: "missing subject", identity: oidctest.Identity{Email: "alice@example.com"}, audience: testClientID, nonce: "nonce", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawIDToken, err := idp.IssueIDToken(tt.identity, tt.audience, tt.nonce)
+			if err != nil {
+				t.Fatalf("IssueIDToken() error = %v", err)
+			}
+
+			_, err = provider.VerifyIDToken(context.Background(), rawIDToken, "nonce")
+			if tt.wantErr && !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("VerifyIDToken() error = %v, want %v", err, ErrInvalidIDToken)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("VerifyIDToken() error = %v", err)
+			}
+		})
+	}
+
+	// Token giả mạo: chữ ký bị sửa
+	rawIDToken, err := idp.IssueIDToken(identity, testClientID, "nonce")
+	if err != nil {
+		t.Fatalf("IssueIDToken() error = %v", err)
+	}
+	tampered := rawIDToken[:len(rawIDToken)-4] + "AAAA"
+	if _, err := provider.VerifyIDToken(context.Background(), tampered, "nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("VerifyIDToken(tampered) error = %v, want %v", err, ErrInvalidIDToken)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp, err := oidctest.NewServer(testClientID)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	t.Cleanup(idp.Close)
+
+	provider, err := NewProvider(config.OIDCProvider{Name: "mock", Issuer: idp.Issuer() + "/", ClientID: testClientID, RedirectURL: testRedirectURL})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("AuthCodeURL() error = nil, want issuer mismatch")
+	}
+}
+
+func newTestProvider(t *testing.T, clientID string) (*oidctest.Server, *Provider) {
+	t.Helper()
+
+	idp, err := oidctest.NewServer(clientID)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	t.Cleanup(idp.Close)
+
+	provider, err := NewProvider(config.OIDCProvider{Name: "mock", Issuer: idp.Issuer(), ClientID: clientID, RedirectURL: testRedirectURL})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	return idp, provider
+}
@@ -0,0 +1,122 @@
+// File: pkg/passwordhash/argon2id.go
+package passwordhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id băm mật khẩu theo định dạng PHC:
+// $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt base64>$<hash base64>
+type Argon2id struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// argon2Params là tham số đọc được từ một chuỗi băm PHC
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (a *Argon2id) recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (a *Argon2id) hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2id) verify(password, encoded string) (bool, error) {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (a *Argon2id) current(encoded string) bool {
+	params, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false
+	}
+	return params.memory == a.Memory &&
+		params.iterations == a.Iterations &&
+		params.parallelism == a.Parallelism &&
+		uint32(len(params.salt)) == a.SaltLength &&
+		uint32(len(params.key)) == a.KeyLength
+}
+
+// decodeArgon2id - đọc tham số, salt và hash từ chuỗi PHC
+func decodeArgon2id(encoded string) (*argon2Params, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash version: %v", err)
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	params := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash parameters: %v", err)
+	}
+	// Tham số ngoài miền hợp lệ làm argon2.IDKey panic (t=0, p=0) hoặc cho kết quả không chuẩn
+	if err := validateArgon2Params(params.memory, params.iterations, uint32(params.parallelism)); err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash parameters: %v", err)
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt: %v", err)
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash: %v", err)
+	}
+	if len(params.key) == 0 {
+		return nil, fmt.Errorf("invalid argon2id hash")
+	}
+	return params, nil
+}
+
+// validateArgon2Params - kiểm tra tham số theo RFC 9106: t >= 1, p >= 1 và m >= 8*p KiB
+func validateArgon2Params(memory, iterations, parallelism uint32) error {
+	if iterations < 1 {
+		return fmt.Errorf("iterations must be at least 1")
+	}
+	if parallelism < 1 {
+		return fmt.Errorf("parallelism must be at least 1")
+	}
+	if uint64(memory) < 8*uint64(parallelism) {
+		return fmt.Errorf("memory must be at least 8*parallelism KiB")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// File: pkg/passwordhash/hasher.go
+package passwordhash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash - chuỗi băm không thuộc thuật toán nào được hỗ trợ
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// PasswordHasher băm và kiểm tra mật khẩu.
+// Verify trả về needsRehash = true khi mật khẩu đúng nhưng chuỗi băm dùng thuật toán / tham số cũ,
+// khi đó nên băm lại bằng Hash và lưu đè để chuyển dần sang cấu hình hiện tại.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (match bool, needsRehash bool, err error)
+}
+
+// scheme là một thuật toán băm mật khẩu
+type scheme interface {
+	// recognizes cho biết chuỗi băm có thuộc thuật toán này không
+	recognizes(encoded string) bool
+	hash(password string) (string, error)
+	verify(password, encoded string) (bool, error)
+	// current cho biết chuỗi băm có dùng đúng tham số đang cấu hình không
+	current(encoded string) bool
+}
+
+// hasher băm bằng thuật toán đang cấu hình và kiểm tra được mọi thuật toán được hỗ trợ
+type hasher struct {
+	active  scheme
+	schemes []scheme
+}
+
+// Options là tham số băm mật khẩu
+type Options struct {
+	Algorithm         string // argon2id (mặc định) | bcrypt
+	Argon2Memory      int    // KiB
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+}
+
+// New tạo PasswordHasher theo opts; thuật toán mặc định là argon2id.
+// Chuỗi băm của cả hai thuật toán đều kiểm tra được, bất kể thuật toán đang dùng.
+func New(opts Options) (PasswordHasher, error) {
+	if opts.Argon2Parallelism > 255 {
+		return nil, fmt.Errorf("argon2id parallelism must be at most 255")
+	}
+	if opts.Argon2Memory < 0 || opts.Argon2Iterations < 0 || opts.Argon2Parallelism < 0 {
+		return nil, fmt.Errorf("argon2id parameters must not be negative")
+	}
+	if err := validateArgon2Params(uint32(opts.Argon2Memory), uint32(opts.Argon2Iterations), uint32(opts.Argon2Parallelism)); err != nil {
+		return nil, fmt.Errorf("argon2id %v", err)
+	}
+	if opts.BcryptCost < bcrypt.MinCost || opts.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	argon := &Argon2id{
+		Memory:      uint32(opts.Argon2Memory),
+		Iterations:  uint32(opts.Argon2Iterations),
+		Parallelism: uint8(opts.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+	bcryptScheme := &Bcrypt{Cost: opts.BcryptCost}
+
+	h := &hasher{schemes: []scheme{argon, bcryptScheme}}
+	switch strings.ToLower(opts.Algorithm) {
+	case "", "argon2id":
+		h.active = argon
+	case "bcrypt":
+		h.active = bcryptScheme
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", opts.Algorithm)
+	}
+	return h, nil
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.active.hash(password)
+}
+
+func (h *hasher) Verify(password, encoded string) (bool, bool, error) {
+	for _, s := range h.schemes {
+		if !s.recognizes(encoded) {
+			continue
+		}
+		match, err := s.verify(password, encoded)
+		if err != nil || !match {
+			return false, false, err
+		}
+		return true, s != h.active || !s.current(encoded), nil
+	}
+	return false, false, ErrUnknownHash
+}
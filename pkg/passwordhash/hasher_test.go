@@ -0,0 +1,165 @@
+package passwordhash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Chuỗi băm cố định của mật khẩu "password": argon2id m=64,t=2,p=1 với salt "somesaltsomesalt" và bcrypt cost 4
+const (
+	argon2idPassword = "$argon2id$v=19$m=64,t=2,p=1$c29tZXNhbHRzb21lc2FsdA$Gpj7qOY5RCXJvcMzqcdQqvgR3wcPX7SleI4c9NtXk6E"
+	bcryptPassword   = "$2a$04$9sA3KRbk.Wh4PRv6blG2hejnDS6AGqUj3VZlZu5O/0IN1w7JYiBey"
+)
+
+// testOptions - tham số nhỏ để test chạy nhanh
+var testOptions = Options{Algorithm: "argon2id", Argon2Memory: 64, Argon2Iterations: 2, Argon2Parallelism: 1, BcryptCost: 4}
+
+func newTestHasher(t *testing.T, opts Options) PasswordHasher {
+	t.Helper()
+	h, err := New(opts)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return h
+}
+
+func TestHashRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{"argon2id", "bcrypt", ""} {
+		t.Run(algorithm, func(t *testing.T) {
+			c := testOptions
+			c.Algorithm = algorithm
+			h := newTestHasher(t, c)
+
+			encoded, err := h.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if wantPrefix := map[string]string{"argon2id": "$argon2id$", "": "$argon2id$", "bcrypt": "$2a$"}[algorithm]; !strings.HasPrefix(encoded, wantPrefix) {
+				t.Errorf("Hash() = %q, want prefix %q", encoded, wantPrefix)
+			}
+
+			match, needsRehash, err := h.Verify("correct horse battery staple", encoded)
+			if err != nil || !match || needsRehash {
+				t.Errorf("Verify(correct) = (%v, %v, %v), want (true, false, nil)", match, needsRehash, err)
+			}
+			match, needsRehash, err = h.Verify("Correct horse battery staple", encoded)
+			if err != nil || match || needsRehash {
+				t.Errorf("Verify(wrong) = (%v, %v, %v), want (false, false, nil)", match, needsRehash, err)
+			}
+
+			// Salt ngẫu nhiên: cùng mật khẩu cho chuỗi băm khác nhau
+			again, err := h.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if again == encoded {
+				t.Error("Hash() returned the same hash twice")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	argonActive := testOptions
+
+	argonStronger := testOptions
+	argonStronger.Argon2Memory = 128
+
+	bcryptActive := testOptions
+	bcryptActive.Algorithm = "bcrypt"
+
+	bcryptStronger := bcryptActive
+	bcryptStronger.BcryptCost = 5
+
+	tests := []struct {
+		name            string
+		opts            Options
+		password        string
+		encoded         string
+		wantMatch       bool
+		wantNeedsRehash bool
+	}{
+		{"argon2id current parameters", argonActive, "password", argon2idPassword, true, false},
+		{"argon2id wrong password", argonActive, "passw0rd", argon2idPassword, false, false},
+		{"argon2id older parameters", argonStronger, "password", argon2idPassword, true, true},
+		{"argon2id older parameters wrong password", argonStronger, "passw0rd", argon2idPassword, false, false},
+		{"bcrypt hash while argon2id is active", argonActive, "password", bcryptPassword, true, true},
+		{"bcrypt wrong password", argonActive, "passw0rd", bcryptPassword, false, false},
+		{"bcrypt current cost", bcryptActive, "password", bcryptPassword, true, false},
+		{"bcrypt older cost", bcryptStronger, "password", bcryptPassword, true, true},
+		{"argon2id hash while bcrypt is active", bcryptActive, "password", argon2idPassword, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHasher(t, tt.opts)
+			match, needsRehash, err := h.Verify(tt.password, tt.encoded)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if match != tt.wantMatch || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("Verify() = (%v, %v), want (%v, %v)", match, needsRehash, tt.wantMatch, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyMalformedHash(t *testing.T) {
+	h := newTestHasher(t, testOptions)
+
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr error // nil: chỉ cần có lỗi
+	}{
+		{"unknown format", "plaintext", ErrUnknownHash},
+		{"empty", "", ErrUnknownHash},
+		{"argon2id missing parts", "$argon2id$v=19$m=64,t=2,p=1$c29tZXNhbHQ", nil},
+		{"argon2id unsupported version", "$argon2id$v=16$m=64,t=2,p=1$c29tZXNhbHQ$FqGkmHNGCd0BRW2kBt6fPZ2pPmyGwwChL8FGUhTOSSI", nil},
+		{"argon2id zero iterations", "$argon2id$v=19$m=64,t=0,p=1$c29tZXNhbHQ$FqGkmHNGCd0BRW2kBt6fPZ2pPmyGwwChL8FGUhTOSSI", nil},
+		{"argon2id zero parallelism", "$argon2id$v=19$m=64,t=2,p=0$c29tZXNhbHQ$FqGkmHNGCd0BRW2kBt6fPZ2pPmyGwwChL8FGUhTOSSI", nil},
+		{"argon2id memory below 8*p", "$argon2id$v=19$m=15,t=2,p=2$c29tZXNhbHQ$FqGkmHNGCd0BRW2kBt6fPZ2pPmyGwwChL8FGUhTOSSI", nil},
+		{"argon2id invalid salt", "$argon2id$v=19$m=64,t=2,p=1$!!!$FqGkmHNGCd0BRW2kBt6fPZ2pPmyGwwChL8FGUhTOSSI", nil},
+		{"argon2id empty hash", "$argon2id$v=19$m=64,t=2,p=1$c29tZXNhbHQ$", nil},
+		{"bcrypt truncated", "$2a$04$9sA3KRbk", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, needsRehash, err := h.Verify("password", tt.encoded)
+			if err == nil || match || needsRehash {
+				t.Fatalf("Verify() = (%v, %v, %v), want an error", match, needsRehash, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Options)
+	}{
+		{"unknown algorithm", func(c *Options) { c.Algorithm = "md5" }},
+		{"zero iterations", func(c *Options) { c.Argon2Iterations = 0 }},
+		{"zero parallelism", func(c *Options) { c.Argon2Parallelism = 0 }},
+		{"parallelism above 255", func(c *Options) { c.Argon2Parallelism = 256; c.Argon2Memory = 8 * 256 }},
+		{"memory below 8*parallelism", func(c *Options) { c.Argon2Parallelism = 4; c.Argon2Memory = 31 }},
+		{"negative memory", func(c *Options) { c.Argon2Memory = -1 }},
+		{"bcrypt cost too low", func(c *Options) { c.BcryptCost = 3 }},
+		{"bcrypt cost too high", func(c *Options) { c.BcryptCost = 32 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testOptions
+			tt.modify(&c)
+			if _, err := New(c); err == nil {
+				t.Errorf("New(%+v) returned no error", c)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// File: pkg/passwordpolicy/breached.go
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultBreachedList là danh sách nhỏ các mật khẩu phổ biến nhất đi kèm ứng dụng, dùng khi không cấu hình
+// PASSWORD_BREACHED_LIST. Danh sách này chỉ chặn những mật khẩu tệ nhất, không thay được một bộ dữ liệu thật
+// (range file của Have I Been Pwned) ở production.
+//
+//go:embed breached-passwords.txt
+var defaultBreachedList string
+
+// BreachChecker kiểm tra mật khẩu có nằm trong danh sách mật khẩu đã bị lộ hay không
+type BreachChecker interface {
+	Breached(password string) (bool, error)
+}
+
+// hashPassword - SHA-1 (hex, chữ hoa) của mật khẩu, cùng định dạng với Have I Been Pwned
+func hashPassword(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// HashList là danh sách băm SHA-1 nằm trong bộ nhớ
+type HashList struct {
+	hashes map[string]struct{}
+}
+
+// LoadBreachedList mở danh sách mật khẩu đã bị lộ:
+//   - path rỗng: danh sách đi kèm ứng dụng
+//   - path là thư mục: các file range k-anonymity theo định dạng Have I Been Pwned
+//     (<thư mục>/<5 ký tự hex đầu của SHA-1>.txt, mỗi dòng "<35 ký tự còn lại>:<số lần>"), chỉ đọc file của prefix cần tra
+//   - path là file: mỗi dòng là một mật khẩu hoặc SHA-1 dạng hex (có thể kèm ":<số lần>"), dòng bắt đầu bằng # bị bỏ qua
+func LoadBreachedList(path string) (BreachChecker, error) {
+	if path == "" {
+		return parseHashList(strings.NewReader(defaultBreachedList))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &RangeDir{dir: path}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseHashList(file)
+}
+
+func parseHashList(r io.Reader) (*HashList, error) {
+	list := &HashList{hashes: map[string]struct{}{}}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1Hex(hash) {
+			list.hashes[strings.ToUpper(hash)] = struct{}{}
+			continue
+		}
+		list.hashes[hashPassword(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read breached password list: %v", err)
+	}
+	return list, nil
+}
+
+func (l *HashList) Breached(password string) (bool, error) {
+	_, found := l.hashes[hashPassword(password)]
+	return found, nil
+}
+
+// RangeDir tra cứu trong thư mục range file k-anonymity (tải sẵn từ Have I Been Pwned), không gọi mạng
+type RangeDir struct {
+	dir string
+}
+
+func (d *RangeDir) Breached(password string) (bool, error) {
+	hash := hashPassword(password)
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(d.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		candidate, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(candidate, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func isSHA1Hex(value string) bool {
+	if len(value) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
@@ -0,0 +1,95 @@
+package passwordpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultBreachedList(t *testing.T) {
+	checker, err := LoadBreachedList("")
+	if err != nil {
+		t.Fatalf("LoadBreachedList() error = %v", err)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"123456", true},
+		{"password", true},
+		{"Tr0ub4dor&3x-unlisted", false},
+	}
+	for _, tt := range tests {
+		got, err := checker.Breached(tt.password)
+		if err != nil || got != tt.want {
+			t.Errorf("Breached(%q) = (%v, %v), want (%v, nil)", tt.password, got, err, tt.want)
+		}
+	}
+}
+
+func TestHashListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# comment\n\nplaintext-password\n" +
+		hashPassword("hashed-password") + ":42\n" +
+		"5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8\n" // "password", chữ thường
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	checker, err := LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedList() error = %v", err)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"plaintext-password", true},
+		{"hashed-password", true},
+		{"password", true},
+		{"# comment", false},
+		{"123456", false},
+	}
+	for _, tt := range tests {
+		got, err := checker.Breached(tt.password)
+		if err != nil || got != tt.want {
+			t.Errorf("Breached(%q) = (%v, %v), want (%v, nil)", tt.password, got, err, tt.want)
+		}
+	}
+}
+
+func TestRangeDir(t *testing.T) {
+	dir := t.TempDir()
+	hash := hashPassword("range-password")
+	content := "0000000000000000000000000000000000A:1\r\n" + hash[5:] + ":1337\r\n"
+	if err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	checker, err := LoadBreachedList(dir)
+	if err != nil {
+		t.Fatalf("LoadBreachedList() error = %v", err)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"range-password", true},
+		{"other-password", false}, // không có file cho prefix: coi như không bị lộ
+	}
+	for _, tt := range tests {
+		got, err := checker.Breached(tt.password)
+		if err != nil || got != tt.want {
+			t.Errorf("Breached(%q) = (%v, %v), want (%v, nil)", tt.password, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadBreachedListMissingPath(t *testing.T) {
+	if _, err := LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadBreachedList() with a missing file returned no error")
+	}
+}
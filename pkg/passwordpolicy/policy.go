@@ -0,0 +1,196 @@
+// File: pkg/passwordpolicy/policy.go
+package passwordpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BcryptMaxBytes - bcrypt chỉ dùng 72 byte đầu của mật khẩu, phần còn lại bị bỏ qua
+const BcryptMaxBytes = 72
+
+// Mã lỗi của từng vi phạm, client dùng để hiển thị thông báo riêng
+const (
+	CodeTooShort         = "too_short"
+	CodeTooLong          = "too_long"
+	CodeTooSimple        = "too_simple"
+	CodeContainsPersonal = "contains_personal_info"
+	CodeBreached         = "breached"
+)
+
+// Input là mật khẩu cần kiểm tra cùng thông tin của người dùng
+type Input struct {
+	Password string
+	Email    string
+	Name     string
+}
+
+// Violation là một vi phạm chính sách mật khẩu của một trường trong request
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ViolationError là lỗi trả về khi mật khẩu không đạt chính sách, chứa toàn bộ vi phạm
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// Rule là một quy tắc của chính sách; trả về nil nếu mật khẩu hợp lệ
+type Rule interface {
+	Check(input Input) *Violation
+}
+
+// RuleFunc cho phép dùng một hàm làm Rule
+type RuleFunc func(input Input) *Violation
+
+func (f RuleFunc) Check(input Input) *Violation {
+	return f(input)
+}
+
+// Policy là tập quy tắc kiểm tra mật khẩu, có thể thêm quy tắc riêng qua Rule
+type Policy struct {
+	rules []Rule
+}
+
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// Options là tham số của chính sách mặc định
+type Options struct {
+	MinLength           int
+	MinCharacterClasses int
+	CheckBreached       bool
+	BreachedList        string // file hoặc thư mục range file (xem LoadBreachedList), rỗng thì dùng danh sách đi kèm
+}
+
+// New tạo chính sách mặc định: độ dài và số nhóm ký tự tối thiểu theo opts,
+// giới hạn 72 byte của bcrypt, không chứa email / tên và không nằm trong danh sách mật khẩu đã bị lộ
+func New(opts Options) (*Policy, error) {
+	rules := []Rule{
+		MinLength(opts.MinLength),
+		MaxBytes(BcryptMaxBytes),
+		CharacterClasses(opts.MinCharacterClasses),
+		NoPersonalInfo(),
+	}
+
+	if opts.CheckBreached {
+		checker, err := LoadBreachedList(opts.BreachedList)
+		if err != nil {
+			return nil, fmt.Errorf("could not load breached password list: %v", err)
+		}
+		rules = append(rules, NotBreached(checker))
+	}
+	return NewPolicy(rules...), nil
+}
+
+// Validate kiểm tra mật khẩu theo mọi quy tắc; field là tên trường trong request (password, new_password...)
+func (p *Policy) Validate(field string, input Input) error {
+	var violations []Violation
+	for _, rule := range p.rules {
+		if v := rule.Check(input); v != nil {
+			v.Field = field
+			violations = append(violations, *v)
+		}
+	}
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+// MinLength - mật khẩu phải có ít nhất n ký tự
+func MinLength(n int) Rule {
+	return RuleFunc(func(input Input) *Violation {
+		if utf8.RuneCountInString(input.Password) < n {
+			return &Violation{Code: CodeTooShort, Message: fmt.Sprintf("must be at least %d characters long", n)}
+		}
+		return nil
+	})
+}
+
+// MaxBytes - mật khẩu không được dài quá n byte (xem BcryptMaxBytes)
+func MaxBytes(n int) Rule {
+	return RuleFunc(func(input Input) *Violation {
+		if len(input.Password) > n {
+			return &Violation{Code: CodeTooLong, Message: fmt.Sprintf("must be at most %d bytes long", n)}
+		}
+		return nil
+	})
+}
+
+// CharacterClasses - mật khẩu phải chứa ít nhất n trong 4 nhóm: chữ thường, chữ hoa, chữ số, ký tự đặc biệt
+func CharacterClasses(n int) Rule {
+	return RuleFunc(func(input Input) *Violation {
+		var lower, upper, digit, symbol bool
+		for _, r := range input.Password {
+			switch {
+			case unicode.IsLower(r):
+				lower = true
+			case unicode.IsUpper(r):
+				upper = true
+			case unicode.IsDigit(r):
+				digit = true
+			default:
+				symbol = true
+			}
+		}
+
+		classes := 0
+		for _, present := range []bool{lower, upper, digit, symbol} {
+			if present {
+				classes++
+			}
+		}
+		if classes < n {
+			return &Violation{Code: CodeTooSimple, Message: fmt.Sprintf("must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", n)}
+		}
+		return nil
+	})
+}
+
+// NoPersonalInfo - mật khẩu không được chứa email, phần trước @ của email hoặc tên của người dùng
+func NoPersonalInfo() Rule {
+	return RuleFunc(func(input Input) *Violation {
+		password := strings.ToLower(input.Password)
+		email := strings.ToLower(strings.TrimSpace(input.Email))
+		local, _, _ := strings.Cut(email, "@")
+
+		candidates := append([]string{email, local}, strings.Fields(strings.ToLower(input.Name))...)
+		for _, candidate := range candidates {
+			// Bỏ qua chuỗi quá ngắn để không chặn nhầm (ví dụ tên "An")
+			if utf8.RuneCountInString(candidate) >= 3 && strings.Contains(password, candidate) {
+				return &Violation{Code: CodeContainsPersonal, Message: "must not contain your email address or name"}
+			}
+		}
+		return nil
+	})
+}
+
+// NotBreached - mật khẩu không được nằm trong danh sách mật khẩu đã bị lộ.
+// Lỗi khi tra cứu thì bỏ qua quy tắc (không chặn người dùng vì lỗi hệ thống).
+func NotBreached(checker BreachChecker) Rule {
+	return RuleFunc(func(input Input) *Violation {
+		breached, err := checker.Breached(input.Password)
+		if err != nil {
+			fmt.Printf("warning: breached password check failed: %v\n", err)
+			return nil
+		}
+		if breached {
+			return &Violation{Code: CodeBreached, Message: "has appeared in a data breach, please choose a different password"}
+		}
+		return nil
+	})
+}
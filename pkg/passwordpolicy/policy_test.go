@@ -0,0 +1,136 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		input    Input
+		wantCode string // rỗng: mật khẩu hợp lệ
+	}{
+		{"min length ok", MinLength(8), Input{Password: "abcdefgh"}, ""},
+		{"min length too short", MinLength(8), Input{Password: "abcdefg"}, CodeTooShort},
+		{"min length counts runes", MinLength(8), Input{Password: "mậtkhẩuđ"}, ""},
+
+		{"max bytes ok", MaxBytes(BcryptMaxBytes), Input{Password: strings.Repeat("a", 72)}, ""},
+		{"max bytes too long", MaxBytes(BcryptMaxBytes), Input{Password: strings.Repeat("a", 73)}, CodeTooLong},
+		{"max bytes counts bytes", MaxBytes(BcryptMaxBytes), Input{Password: strings.Repeat("đ", 37)}, CodeTooLong},
+
+		{"classes lowercase only", CharacterClasses(2), Input{Password: "abcdefgh"}, CodeTooSimple},
+		{"classes lower and digit", CharacterClasses(2), Input{Password: "abcd1234"}, ""},
+		{"classes three of four", CharacterClasses(3), Input{Password: "Abcd1234"}, ""},
+		{"classes symbol counts", CharacterClasses(3), Input{Password: "abcd 123"}, ""},
+		{"classes not enough", CharacterClasses(4), Input{Password: "Abcd1234"}, CodeTooSimple},
+		{"classes disabled", CharacterClasses(0), Input{Password: "aaaaaaaa"}, ""},
+
+		{"personal none", NoPersonalInfo(), Input{Password: "correct horse", Email: "alice@example.com", Name: "Alice Nguyen"}, ""},
+		{"personal full email", NoPersonalInfo(), Input{Password: "x-alice@example.com-x", Email: "alice@example.com"}, CodeContainsPersonal},
+		{"personal email local part", NoPersonalInfo(), Input{Password: "ALICE2024!", Email: "alice@example.com"}, CodeContainsPersonal},
+		{"personal name part", NoPersonalInfo(), Input{Password: "iloveNguyen", Email: "a@example.com", Name: "Alice Nguyen"}, CodeContainsPersonal},
+		{"personal short name ignored", NoPersonalInfo(), Input{Password: "an-secret-pass", Email: "x@example.com", Name: "An"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.rule.Check(tt.input)
+			switch {
+			case tt.wantCode == "" && v != nil:
+				t.Errorf("Check() = %+v, want no violation", v)
+			case tt.wantCode != "" && (v == nil || v.Code != tt.wantCode):
+				t.Errorf("Check() = %+v, want code %q", v, tt.wantCode)
+			}
+		})
+	}
+}
+
+// stubChecker - BreachChecker trả về kết quả cố định
+type stubChecker struct {
+	breached bool
+	err      error
+}
+
+func (c stubChecker) Breached(password string) (bool, error) {
+	return c.breached, c.err
+}
+
+func TestNotBreached(t *testing.T) {
+	tests := []struct {
+		name     string
+		checker  BreachChecker
+		wantCode string
+	}{
+		{"not breached", stubChecker{}, ""},
+		{"breached", stubChecker{breached: true}, CodeBreached},
+		{"lookup error is ignored", stubChecker{err: errors.New("disk error")}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NotBreached(tt.checker).Check(Input{Password: "whatever"})
+			if (tt.wantCode == "" && v != nil) || (tt.wantCode != "" && (v == nil || v.Code != tt.wantCode)) {
+				t.Errorf("Check() = %+v, want code %q", v, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPolicyValidateCollectsViolations(t *testing.T) {
+	policy := NewPolicy(MinLength(12), CharacterClasses(3), NoPersonalInfo())
+
+	err := policy.Validate("new_password", Input{Password: "alice", Email: "alice@example.com"})
+	var violationErr *ViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatalf("Validate() error = %v, want *ViolationError", err)
+	}
+
+	var codes []string
+	for _, v := range violationErr.Violations {
+		if v.Field != "new_password" {
+			t.Errorf("violation field = %q, want new_password", v.Field)
+		}
+		codes = append(codes, v.Code)
+	}
+	want := []string{CodeTooShort, CodeTooSimple, CodeContainsPersonal}
+	if strings.Join(codes, ",") != strings.Join(want, ",") {
+		t.Errorf("violation codes = %v, want %v", codes, want)
+	}
+
+	if err := policy.Validate("password", Input{Password: "Tr0ub4dor&3x", Email: "alice@example.com"}); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		password string
+		wantErr  bool
+	}{
+		{"defaults accept a strong password", Options{MinLength: 8, MinCharacterClasses: 2, CheckBreached: true}, "Tr0ub4dor&3x", false},
+		{"built-in breached list", Options{MinLength: 6, CheckBreached: true}, "password", true},
+		{"breached check disabled", Options{MinLength: 6}, "password", false},
+		{"min length from options", Options{MinLength: 16}, "Tr0ub4dor&3x", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := New(tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if err := policy.Validate("password", Input{Password: tt.password}); (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, want error %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := New(Options{CheckBreached: true, BreachedList: "/nonexistent/breached.txt"}); err == nil {
+		t.Error("New() with a missing breached list returned no error")
+	}
+}
@@ -0,0 +1,85 @@
+// File: pkg/signer/signer.go
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Payload là nội dung được ký trong token
+type Payload struct {
+	Purpose   string            `json:"p"`           // mục đích sử dụng, token của mục đích này không dùng được cho mục đích khác
+	Subject   string            `json:"sub"`         // thường là user ID
+	Data      map[string]string `json:"d,omitempty"` // dữ liệu kèm theo
+	ExpiresAt int64             `json:"exp"`
+}
+
+// Signer ký và xác thực token ngắn gọn dạng <payload>.<chữ ký HMAC-SHA256>,
+// dùng cho các link gửi qua email hoặc link tải xuống có thời hạn
+type Signer struct {
+	secret []byte
+}
+
+func New(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Sign tạo token cho purpose/subject, hết hạn sau ttl
+func (s *Signer) Sign(purpose, subject string, data map[string]string, ttl time.Duration) (string, error) {
+	payload, err := json.Marshal(Payload{
+		Purpose:   purpose,
+		Subject:   subject,
+		Data:      data,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.mac(encoded), nil
+}
+
+// Verify kiểm tra chữ ký, mục đích và thời hạn của token
+func (s *Signer) Verify(purpose, token string) (*Payload, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.mac(encoded))) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if payload.Purpose != purpose {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() > payload.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+	return &payload, nil
+}
+
+func (s *Signer) mac(data string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
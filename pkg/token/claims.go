@@ -0,0 +1,87 @@
+// File: pkg/token/claims.go
+package token
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims là claims chuẩn của access token do hệ thống phát hành.
+// RegisteredClaims cung cấp sub, iss, aud, exp, iat, jti.
+type Claims struct {
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // phiên đăng nhập (refresh token family) phát hành token
+	jwt.RegisteredClaims
+
+	// Tổ chức đang hoạt động của phiên và vai trò của người dùng trong tổ chức đó
+	OrgID   string `json:"org_id,omitempty"`
+	OrgRole string `json:"org_role,omitempty"`
+
+	// Thời điểm người dùng xác thực gần nhất trong phiên và các phương thức đã dùng (RFC 8176: pwd, otp, mfa, fed)
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+
+	// Quản trị viên đang đăng nhập dưới danh nghĩa người dùng (sub), chỉ có trong token impersonation
+	Actor *Actor `json:"act,omitempty"`
+
+	// Chỉ có khi request xác thực bằng API key (không nằm trong JWT)
+	APIKeyID string   `json:"-"`
+	Scopes   []string `json:"-"`
+}
+
+// Actor là người thực sự sử dụng token (claim act, RFC 8693)
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// UserID trả về ID người dùng (claim sub)
+func (c *Claims) UserID() string {
+	return c.Subject
+}
+
+// ActorID trả về ID quản trị viên đang đăng nhập dưới danh nghĩa người dùng, rỗng nếu không phải token impersonation
+func (c *Claims) ActorID() string {
+	if c.Actor == nil {
+		return ""
+	}
+	return c.Actor.Subject
+}
+
+// IsImpersonated cho biết token được cấp cho quản trị viên đăng nhập dưới danh nghĩa người dùng
+func (c *Claims) IsImpersonated() bool {
+	return c.ActorID() != ""
+}
+
+// AuthenticatedAt trả về thời điểm xác thực gần nhất (claim auth_time), time.Time rỗng nếu không có
+// (API key, token impersonation)
+func (c *Claims) AuthenticatedAt() time.Time {
+	if c.AuthTime == nil {
+		return time.Time{}
+	}
+	return c.AuthTime.Time
+}
+
+// AuthenticatedWithin cho biết người dùng đã xác thực trong khoảng maxAge vừa qua hay chưa
+func (c *Claims) AuthenticatedWithin(maxAge time.Duration) bool {
+	authTime := c.AuthenticatedAt()
+	return !authTime.IsZero() && time.Since(authTime) <= maxAge
+}
+
+// IsAPIKey cho biết request được xác thực bằng API key thay vì phiên đăng nhập
+func (c *Claims) IsAPIKey() bool {
+	return c.APIKeyID != ""
+}
+
+// HasScope kiểm tra scope của API key; phiên đăng nhập bằng JWT có toàn quyền
+func (c *Claims) HasScope(scope string) bool {
+	if !c.IsAPIKey() {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+// File: pkg/token/id_token.go
+package token
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IDTokenClaims là claims của ID token OpenID Connect cấp cho client bên thứ ba.
+// Issuer là URL của authorization server, Audience là client_id.
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	AuthTime      int64  `json:"auth_time,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// SignIDToken ký ID token bằng khóa đang hoạt động (cùng JWKS với access token)
+func (m *Manager) SignIDToken(claims *IDTokenClaims) (string, error) {
+	return m.keys.Sign(claims)
+}
@@ -0,0 +1,458 @@
+// File: pkg/token/keyset.go
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Các thuật toán ký được hỗ trợ
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key là một khóa ký/xác thực JWT, định danh bằng kid
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer    // nil nếu chỉ dùng để xác thực (khóa đã nghỉ hưu)
+	Public    crypto.PublicKey // khóa công khai, được công bố qua JWKS
+	CreatedAt time.Time
+	Generated bool // khóa do KeySet tự sinh, được tự xóa khi hết hạn xác thực
+}
+
+// KeySetConfig cấu hình nguồn khóa và chính sách xoay vòng
+type KeySetConfig struct {
+	Dir              string        // thư mục chứa file PEM, trống => sinh khóa tạm trong bộ nhớ
+	Algorithm        string        // thuật toán dùng khi cần sinh khóa mới
+	ActiveKID        string        // ghim khóa ký theo kid (tùy chọn)
+	RotationInterval time.Duration // 0 => không tự xoay vòng
+	ReloadInterval   time.Duration // chu kỳ đọc lại thư mục khóa
+	MaxTokenTTL      time.Duration // thời gian sống dài nhất của token được ký
+}
+
+// KeySet quản lý nhiều khóa xác thực cùng lúc và một khóa ký đang hoạt động.
+// Mỗi file <kid>.pem trong thư mục là một khóa; khóa private mới nhất (theo thời gian tạo)
+// được dùng để ký, mọi khóa còn lại vẫn được dùng để xác thực cho tới khi bị xóa.
+// Khóa do KeySet sinh ra có thêm file <kid>.json ghi thời điểm tạo, không phụ thuộc mtime của file PEM.
+type KeySet struct {
+	cfg KeySetConfig
+
+	mu      sync.RWMutex
+	keys    map[string]*Key
+	signing *Key
+}
+
+// NewKeySet khởi tạo KeySet từ cấu hình
+func NewKeySet(cfg KeySetConfig) (*KeySet, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgRS256
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = time.Minute
+	}
+
+	ks := &KeySet{cfg: cfg, keys: map[string]*Key{}}
+
+	// Không cấu hình thư mục => sinh khóa tạm (chỉ phù hợp môi trường dev, một instance)
+	if cfg.Dir == "" {
+		log.Printf("⚠️  JWT_KEYS_DIR is not set, using an ephemeral %s signing key", cfg.Algorithm)
+		key, err := generateKey(cfg.Algorithm, newKID(time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[key.ID] = key
+		ks.signing = key
+		return ks, nil
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create keys dir: %v", err)
+	}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+
+	// Thư mục trống => sinh khóa đầu tiên và lưu lại
+	if ks.SigningKey() == nil {
+		if err := ks.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// Reload đọc lại toàn bộ khóa trong thư mục và chọn khóa ký
+func (ks *KeySet) Reload() error {
+	if ks.cfg.Dir == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(ks.cfg.Dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*Key{}
+	for _, file := range files {
+		key, err := loadKeyFile(file)
+		if err != nil {
+			log.Printf("warning: skip JWT key %s: %v", file, err)
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	signing := ks.selectSigningKey(keys)
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.signing = signing
+	ks.mu.Unlock()
+	return nil
+}
+
+// selectSigningKey chọn khóa ký: khóa được ghim qua ActiveKID, hoặc khóa private mới nhất đã
+// được công bố đủ lâu (để mọi instance kịp nạp khóa công khai trước khi token đầu tiên xuất hiện)
+func (ks *KeySet) selectSigningKey(keys map[string]*Key) *Key {
+	if ks.cfg.ActiveKID != "" {
+		if key, ok := keys[ks.cfg.ActiveKID]; ok && key.Private != nil {
+			return key
+		}
+		log.Printf("warning: JWT_ACTIVE_KID %q not found, falling back to newest key", ks.cfg.ActiveKID)
+	}
+
+	candidates := make([]*Key, 0, len(keys))
+	for _, key := range keys {
+		if key.Private != nil {
+			candidates = append(candidates, key)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+	})
+
+	activationDelay := 2 * ks.cfg.ReloadInterval
+	for _, key := range candidates {
+		if time.Since(key.CreatedAt) >= activationDelay {
+			return key
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// Rotate sinh một khóa mới vào thư mục; khóa cũ vẫn được dùng để xác thực
+func (ks *KeySet) Rotate() error {
+	if ks.cfg.Dir == "" {
+		return errors.New("key rotation requires JWT_KEYS_DIR")
+	}
+
+	key, err := generateKey(ks.cfg.Algorithm, newKID(time.Now()))
+	if err != nil {
+		return err
+	}
+	if err := writeKeyFile(ks.cfg.Dir, key); err != nil {
+		return err
+	}
+	log.Printf("🔑 Generated JWT signing key %s (%s)", key.ID, key.Algorithm)
+	return ks.Reload()
+}
+
+// Start chạy nền: đọc lại thư mục khóa định kỳ và tự xoay vòng khóa nếu được cấu hình
+func (ks *KeySet) Start() {
+	if ks.cfg.Dir == "" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(ks.cfg.ReloadInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if ks.cfg.RotationInterval > 0 {
+				ks.rotateIfDue()
+			}
+			if err := ks.Reload(); err != nil {
+				log.Printf("warning: failed to reload JWT keys: %v", err)
+			}
+		}
+	}()
+}
+
+// rotateIfDue sinh khóa mới khi khóa mới nhất đã quá RotationInterval,
+// đồng thời xóa các khóa tự sinh đã quá hạn xác thực (không còn token nào hợp lệ được ký bởi chúng).
+// Khóa do người vận hành đặt vào thư mục không bao giờ bị xóa tự động.
+func (ks *KeySet) rotateIfDue() {
+	ks.mu.RLock()
+	var newest time.Time
+	var expired []string
+	retention := ks.cfg.RotationInterval + ks.cfg.MaxTokenTTL + 2*ks.cfg.ReloadInterval
+	for _, key := range ks.keys {
+		if key.CreatedAt.After(newest) {
+			newest = key.CreatedAt
+		}
+		if key.Generated && time.Since(key.CreatedAt) > retention {
+			expired = append(expired, key.ID)
+		}
+	}
+	ks.mu.RUnlock()
+
+	if time.Since(newest) >= ks.cfg.RotationInterval {
+		if err := ks.Rotate(); err != nil {
+			log.Printf("warning: failed to rotate JWT key: %v", err)
+			return
+		}
+	}
+
+	signing := ks.SigningKey()
+	for _, kid := range expired {
+		if signing != nil && kid == signing.ID {
+			continue
+		}
+		if err := os.Remove(filepath.Join(ks.cfg.Dir, kid+".pem")); err != nil && !os.IsNotExist(err) {
+			log.Printf("warning: failed to remove retired JWT key %s: %v", kid, err)
+			continue
+		}
+		if err := os.Remove(filepath.Join(ks.cfg.Dir, kid+".json")); err != nil && !os.IsNotExist(err) {
+			log.Printf("warning: failed to remove metadata of retired JWT key %s: %v", kid, err)
+		}
+	}
+}
+
+// SigningKey trả về khóa đang dùng để ký
+func (ks *KeySet) SigningKey() *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.signing
+}
+
+// Keys trả về danh sách khóa xác thực, sắp xếp theo kid
+func (ks *KeySet) Keys() []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// Sign ký claims bằng khóa đang hoạt động, gắn header kid
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := ks.SigningKey()
+	if key == nil {
+		return "", errors.New("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// VerificationKey tìm khóa công khai theo kid và kiểm tra thuật toán khớp với khóa
+func (ks *KeySet) VerificationKey(kid, alg string) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if key.Algorithm != alg {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", alg, kid)
+	}
+	return key.Public, nil
+}
+
+// Keyfunc dùng cho jwt.Parse
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	return ks.VerificationKey(kid, token.Method.Alg())
+}
+
+// Algorithms trả về các thuật toán hợp lệ khi parse token
+func Algorithms() []string {
+	return []string{AlgRS256, AlgES256, AlgEdDSA}
+}
+
+// ======================= KEY FILES =======================
+
+// kidLayout - định dạng thời gian dùng làm kid của khóa tự sinh
+const kidLayout = "20060102T150405Z"
+
+// newKID tạo kid theo thời gian để dễ sắp xếp và nhận biết tuổi của khóa
+func newKID(t time.Time) string {
+	return t.UTC().Format(kidLayout)
+}
+
+func generateKey(alg, kid string) (*Key, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch alg {
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not generate %s key: %v", alg, err)
+	}
+
+	return &Key{
+		ID:        kid,
+		Algorithm: alg,
+		Private:   signer,
+		Public:    signer.Public(),
+		CreatedAt: time.Now(),
+		Generated: true,
+	}, nil
+}
+
+// keyMetadata là nội dung file <kid>.json đi kèm khóa tự sinh
+type keyMetadata struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// writeKeyFile ghi khóa tự sinh vào thư mục: file metadata trước, file PEM sau,
+// để instance khác không bao giờ thấy khóa tự sinh mà thiếu thời điểm tạo
+func writeKeyFile(dir string, key *Key) error {
+	meta, err := json.Marshal(keyMetadata{CreatedAt: key.CreatedAt.UTC()})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, key.ID+".json"), meta); err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, key.ID+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// writeFileAtomic ghi ra file tạm rồi rename để instance khác không đọc phải file ghi dở
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// keyCreatedAt xác định thời điểm tạo khóa: theo file metadata nếu khóa do KeySet sinh ra,
+// theo kid nếu khóa được sinh trước khi có metadata, cuối cùng mới dùng mtime của file PEM
+func keyCreatedAt(path, kid string) (createdAt time.Time, generated bool, err error) {
+	data, err := os.ReadFile(strings.TrimSuffix(path, ".pem") + ".json")
+	if err == nil {
+		var meta keyMetadata
+		if err := json.Unmarshal(data, &meta); err != nil || meta.CreatedAt.IsZero() {
+			return time.Time{}, false, errors.New("invalid key metadata")
+		}
+		return meta.CreatedAt, true, nil
+	}
+	if !os.IsNotExist(err) {
+		return time.Time{}, false, err
+	}
+
+	if t, err := time.Parse(kidLayout, kid); err == nil {
+		return t, false, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return info.ModTime(), false, nil
+}
+
+// loadKeyFile đọc một file PEM: khóa private (PKCS#8, PKCS#1, SEC1) hoặc khóa public (chỉ xác thực)
+func loadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+	createdAt, generated, err := keyCreatedAt(path, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		ID:        kid,
+		CreatedAt: createdAt,
+		Generated: generated,
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.Private = signer
+		key.Public = signer.Public()
+	} else {
+		key.Public = parsed
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Algorithm = AlgRS256
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 EC keys are supported")
+		}
+		key.Algorithm = AlgES256
+	case ed25519.PublicKey:
+		key.Algorithm = AlgEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.Public)
+	}
+	return key, nil
+}
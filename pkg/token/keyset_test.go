@@ -0,0 +1,96 @@
+package token
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeGeneratedKey ghi một khóa tự sinh có thời điểm tạo cho trước vào thư mục
+func writeGeneratedKey(t *testing.T, dir string, createdAt time.Time) *Key {
+	t.Helper()
+	key, err := generateKey(AlgES256, newKID(createdAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.CreatedAt = createdAt
+	if err := writeKeyFile(dir, key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writeOperatorKey ghi một khóa do người vận hành tự đặt vào thư mục (không có file metadata)
+func writeOperatorKey(t *testing.T, dir, kid string, modTime time.Time) {
+	t.Helper()
+	key, err := generateKey(AlgES256, kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, kid+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadKeyFileCreatedAtIgnoresModTime(t *testing.T) {
+	dir := t.TempDir()
+	createdAt := time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Second)
+	key := writeGeneratedKey(t, dir, createdAt)
+
+	// Sao chép / khôi phục thư mục khóa làm thay đổi mtime
+	path := filepath.Join(dir, key.ID+".pem")
+	if err := os.Chtimes(path, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadKeyFile(path)
+	if err != nil {
+		t.Fatalf("loadKeyFile() error = %v", err)
+	}
+	if !loaded.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreatedAt = %v, want %v", loaded.CreatedAt, createdAt)
+	}
+	if !loaded.Generated {
+		t.Error("Generated = false, want true")
+	}
+}
+
+func TestRotateIfDueRemovesOnlyGeneratedKeys(t *testing.T) {
+	dir := t.TempDir()
+	retired := writeGeneratedKey(t, dir, time.Now().Add(-24*time.Hour))
+	// Khóa của người vận hành cũng đã quá hạn nhưng mới hơn, nên đang là khóa ký
+	writeOperatorKey(t, dir, "operator", time.Now().Add(-12*time.Hour))
+
+	ks, err := NewKeySet(KeySetConfig{
+		Dir:              dir,
+		Algorithm:        AlgES256,
+		RotationInterval: time.Hour,
+		ReloadInterval:   time.Minute,
+		MaxTokenTTL:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	ks.rotateIfDue()
+
+	for _, name := range []string{retired.ID + ".pem", retired.ID + ".json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed (err = %v)", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "operator.pem")); err != nil {
+		t.Errorf("operator key was removed: %v", err)
+	}
+}
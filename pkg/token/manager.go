@@ -0,0 +1,59 @@
+// File: pkg/token/manager.go
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Manager là nơi duy nhất phát hành và xác thực access token
+type Manager struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+}
+
+// NewManager khởi tạo Manager với issuer/audience dùng cho cả phát hành lẫn xác thực
+func NewManager(keys *KeySet, issuer, audience string) *Manager {
+	return &Manager{keys: keys, issuer: issuer, audience: audience}
+}
+
+// Issue ký access token từ claims của người dùng (sub, role, sid, ...) với thời gian sống ttl.
+// Các claim chuẩn iss, aud, iat, exp, jti do Manager điền.
+func (m *Manager) Issue(claims *Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.Issuer = m.issuer
+	claims.Audience = jwt.ClaimStrings{m.audience}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	claims.ID = uuid.New().String()
+
+	signed, err := m.keys.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("error signing token: %v", err)
+	}
+	return signed, nil
+}
+
+// Parse xác thực chữ ký, thời hạn, issuer và audience rồi trả về claims đã định kiểu
+func (m *Manager) Parse(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	parsed, err := jwt.ParseWithClaims(tokenStr, claims, m.keys.Keyfunc,
+		jwt.WithValidMethods(Algorithms()),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(m.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.Valid || claims.Subject == "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
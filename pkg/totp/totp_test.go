@@ -0,0 +1,138 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret - secret "12345678901234567890" (ASCII) của phụ lục B RFC 6238, mã hóa base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	// Phụ lục B RFC 6238 (SHA1) cho mã 8 chữ số; với Digits = 6 là 6 chữ số cuối
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	got, err := Code(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1)
+	if err != nil {
+		t.Fatalf("Code() error = %v", err)
+	}
+	if got != "287082" {
+		t.Errorf("Code() = %s, want 287082", got)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code() with an invalid secret returned no error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	codeAt := func(offset int64) string {
+		code, err := Code(rfc6238Secret, step+offset)
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", codeAt(0), step, true},
+		{"previous step within skew", codeAt(-1), step - 1, true},
+		{"next step within skew", codeAt(1), step + 1, true},
+		{"two steps behind", codeAt(-2), 0, false},
+		{"two steps ahead", codeAt(2), 0, false},
+		{"spaces are ignored", codeAt(0)[:3] + " " + codeAt(0)[3:], step, true},
+		{"too short", codeAt(0)[:5], 0, false},
+		{"too long", codeAt(0) + "0", 0, false},
+		{"empty", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(rfc6238Secret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate(%q) = (%d, %v), want (%d, %v)", tt.code, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret has %d bytes, want 20", len(key))
+	}
+
+	other, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	if other == secret {
+		t.Error("GenerateSecret() returned the same secret twice")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	raw := ProvisioningURI("Base App", "user@example.com", rfc6238Secret)
+	uri, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("ProvisioningURI() = %q is not a URL: %v", raw, err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("ProvisioningURI() = %q, want otpauth://totp/...", raw)
+	}
+	if uri.Path != "/Base App:user@example.com" {
+		t.Errorf("label = %q, want %q", uri.Path, "/Base App:user@example.com")
+	}
+
+	query := uri.Query()
+	for key, want := range map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "Base App",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// File: pkg/useragent/useragent.go
+package useragent
+
+import (
+	"strings"
+)
+
+// Device là kết quả phân tích User-Agent ở mức đủ để người dùng nhận ra thiết bị của mình
+type Device struct {
+	Browser string
+	OS      string
+	Mobile  bool
+}
+
+// Name trả về tên hiển thị, ví dụ "Chrome on macOS"
+func (d Device) Name() string {
+	switch {
+	case d.Browser != "" && d.OS != "":
+		return d.Browser + " on " + d.OS
+	case d.Browser != "":
+		return d.Browser
+	case d.OS != "":
+		return d.OS
+	}
+	return "Unknown device"
+}
+
+// Thứ tự quan trọng: nhiều trình duyệt chứa chuỗi của trình duyệt khác (Edge chứa "Chrome", Chrome chứa "Safari")
+var browsers = []struct {
+	token string
+	name  string
+}{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+	{"okhttp/", "OkHttp"},
+	{"Go-http-client/", "Go HTTP client"},
+	{"python-requests/", "Python Requests"},
+}
+
+var systems = []struct {
+	token string
+	name  string
+}{
+	{"Windows NT", "Windows"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// Parse phân tích chuỗi User-Agent
+func Parse(ua string) Device {
+	var device Device
+
+	for _, b := range browsers {
+		if strings.Contains(ua, b.token) {
+			device.Browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(ua, s.token) {
+			device.OS = s.name
+			break
+		}
+	}
+	device.Mobile = strings.Contains(ua, "Mobile") || device.OS == "iOS" || device.OS == "Android"
+
+	return device
+}
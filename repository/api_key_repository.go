@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"base-app/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository là interface thao tác với personal access token (API key)
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	FindByHash(keyHash string) (*model.APIKey, error)
+	ListByUser(userID string) ([]model.APIKey, error)
+	CountActive(userID string) (int64, error)
+	Revoke(userID, keyID string) error
+	TouchLastUsed(keyID string, at time.Time, interval time.Duration) error
+	RevokeAllByUser(userID string) error
+	DeleteByUser(userID string) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByHash(keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUser(userID string) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// CountActive đếm số key chưa thu hồi và chưa hết hạn
+func (r *apiKeyRepository) CountActive(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke thu hồi key; chỉ thu hồi được key thuộc về chính người dùng
+func (r *apiKeyRepository) Revoke(userID, keyID string) error {
+	result := r.db.Model(&model.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
+
+// RevokeAllByUser thu hồi mọi key còn hiệu lực của người dùng
+func (r *apiKeyRepository) RevokeAllByUser(userID string) error {
+	return r.db.Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed cập nhật last_used_at, tối đa một lần mỗi interval để không ghi DB ở mọi request
+func (r *apiKeyRepository) TouchLastUsed(keyID string, at time.Time, interval time.Duration) error {
+	return r.db.Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", keyID, at.Add(-interval)).
+		Update("last_used_at", at).Error
+}
+
+func (r *apiKeyRepository) DeleteByUser(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.APIKey{}).Error
+}
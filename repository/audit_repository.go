@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"base-app/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditFilter là điều kiện lọc audit log
+type AuditFilter struct {
+	ActorID  string
+	TargetID string
+	UserID   string // sự kiện mà người dùng là actor hoặc target
+	Action   string
+	Result   string
+	IP       string
+	Since    *time.Time
+	Until    *time.Time
+	Cursor   string // NextCursor của trang trước
+	Limit    int
+
+	ImpersonatorID string // sự kiện diễn ra khi quản trị viên đăng nhập dưới danh nghĩa người dùng
+}
+
+// AuditPage là một trang kết quả, NextCursor rỗng khi đã hết dữ liệu
+type AuditPage struct {
+	Events     []model.AuditEvent `json:"events"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// AuditRepository ghi và đọc audit log; không có thao tác sửa / xóa
+type AuditRepository interface {
+	Create(event *model.AuditEvent) error
+	List(filter AuditFilter) (*AuditPage, error)
+	ListByUser(userID string) ([]model.AuditEvent, error)
+	ListByUserActions(userID string, actions []string) ([]model.AuditEvent, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(event *model.AuditEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	return r.db.Create(event).Error
+}
+
+// List trả về sự kiện mới nhất trước, phân trang theo cursor (created_at, id)
+func (r *auditRepository) List(filter AuditFilter) (*AuditPage, error) {
+	query := r.db.Model(&model.AuditEvent{})
+
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("actor_id = ? OR target_id = ?", filter.UserID, filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.ImpersonatorID != "" {
+		query = query.Where("impersonator_id = ?", filter.ImpersonatorID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	// Lấy thừa một bản ghi để biết còn trang sau hay không
+	var events []model.AuditEvent
+	if err := query.Order("created_at DESC, id DESC").Limit(filter.Limit + 1).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	page := &AuditPage{Events: events}
+	if len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		last := page.Events[len(page.Events)-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// ListByUser trả về toàn bộ sự kiện liên quan tới người dùng (dùng cho export dữ liệu)
+func (r *auditRepository) ListByUser(userID string) ([]model.AuditEvent, error) {
+	var events []model.AuditEvent
+	err := r.db.Where("actor_id = ? OR target_id = ?", userID, userID).Order("created_at DESC").Find(&events).Error
+	return events, err
+}
+
+// ListByUserActions trả về sự kiện liên quan tới người dùng thuộc các action cho trước, mới nhất trước
+func (r *auditRepository) ListByUserActions(userID string, actions []string) ([]model.AuditEvent, error) {
+	var events []model.AuditEvent
+	err := r.db.Where("(actor_id = ? OR target_id = ?) AND action IN ?", userID, userID, actions).
+		Order("created_at DESC").
+		Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"base-app/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrDeviceNotFound - thiết bị không tồn tại hoặc không thuộc về người dùng
+var ErrDeviceNotFound = errors.New("device not found")
+
+// DeviceRepository là interface thao tác với thiết bị đã biết của người dùng
+type DeviceRepository interface {
+	Create(device *model.KnownDevice) error
+	FindByFingerprint(userID, fingerprint string) (*model.KnownDevice, error)
+	ListByUser(userID string) ([]model.KnownDevice, error)
+	CountByUser(userID string) (int64, error)
+	Touch(deviceID, ip, network string, at time.Time) error
+	Delete(userID, deviceID string) error
+	DeleteByUser(userID string) error
+}
+
+type deviceRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceRepository(db *gorm.DB) DeviceRepository {
+	return &deviceRepository{db: db}
+}
+
+func (r *deviceRepository) Create(device *model.KnownDevice) error {
+	return r.db.Create(device).Error
+}
+
+func (r *deviceRepository) FindByFingerprint(userID, fingerprint string) (*model.KnownDevice, error) {
+	var device model.KnownDevice
+	err := r.db.Where("user_id = ? AND fingerprint = ?", userID, fingerprint).First(&device).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// ListByUser trả về thiết bị dùng gần nhất trước
+func (r *deviceRepository) ListByUser(userID string) ([]model.KnownDevice, error) {
+	var devices []model.KnownDevice
+	err := r.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error
+	return devices, err
+}
+
+func (r *deviceRepository) CountByUser(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.KnownDevice{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Touch cập nhật IP, dải IP và thời điểm đăng nhập gần nhất của thiết bị
+func (r *deviceRepository) Touch(deviceID, ip, network string, at time.Time) error {
+	return r.db.Model(&model.KnownDevice{}).Where("id = ?", deviceID).Updates(map[string]interface{}{
+		"last_ip":      ip,
+		"network":      network,
+		"last_seen_at": at,
+	}).Error
+}
+
+// Delete xóa (quên) thiết bị; chỉ xóa được thiết bị của chính người dùng
+func (r *deviceRepository) Delete(userID, deviceID string) error {
+	result := r.db.Where("id = ? AND user_id = ?", deviceID, userID).Delete(&model.KnownDevice{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (r *deviceRepository) DeleteByUser(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.KnownDevice{}).Error
+}
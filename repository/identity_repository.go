@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"base-app/model"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository là interface thao tác với các tài khoản identity provider đã liên kết
+type IdentityRepository interface {
+	Create(userID, provider, subject, email string) (*model.Identity, error)
+	FindByProviderSubject(provider, subject string) (*model.Identity, error)
+	ListByUser(userID string) ([]model.Identity, error)
+	TouchLogin(identityID, email string) error
+	Delete(userID, identityID string) error
+	DeleteByUser(userID string) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(userID, provider, subject, email string) (*model.Identity, error) {
+	now := time.Now()
+	identity := &model.Identity{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Provider:    provider,
+		Subject:     subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}
+	if err := r.db.Create(identity).Error; err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (r *identityRepository) FindByProviderSubject(provider, subject string) (*model.Identity, error) {
+	var identity model.Identity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) ListByUser(userID string) ([]model.Identity, error) {
+	var identities []model.Identity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// TouchLogin cập nhật thời điểm đăng nhập và email mới nhất do IdP cung cấp
+func (r *identityRepository) TouchLogin(identityID, email string) error {
+	return r.db.Model(&model.Identity{}).Where("id = ?", identityID).Updates(map[string]interface{}{
+		"email":         email,
+		"last_login_at": time.Now(),
+	}).Error
+}
+
+// Delete hủy liên kết; chỉ xóa được identity thuộc về chính người dùng
+func (r *identityRepository) Delete(userID, identityID string) error {
+	result := r.db.Where("id = ? AND user_id = ?", identityID, userID).Delete(&model.Identity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("identity not found")
+	}
+	return nil
+}
+
+func (r *identityRepository) DeleteByUser(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.Identity{}).Error
+}
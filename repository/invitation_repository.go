@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"base-app/model"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvitationRepository là interface thao tác với lời mời tham gia tổ chức
+type InvitationRepository interface {
+	Create(invitation *model.Invitation) error
+	FindByTokenHash(tokenHash string) (*model.Invitation, error)
+	FindByID(orgID, id string) (*model.Invitation, error)
+	FindPendingByEmail(orgID, email string) (*model.Invitation, error)
+	ListPending(orgID string) ([]model.Invitation, error)
+	MarkAccepted(id, userID string) error
+	Revoke(orgID, id string) error
+	Renew(orgID, id, tokenHash string, expiresAt time.Time) error
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// pending - lời mời còn dùng được
+func pending(db *gorm.DB) *gorm.DB {
+	return db.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", time.Now())
+}
+
+func (r *invitationRepository) Create(invitation *model.Invitation) error {
+	if invitation.ID == "" {
+		invitation.ID = uuid.New().String()
+	}
+	return r.db.Create(invitation).Error
+}
+
+// FindByTokenHash tìm lời mời theo token trong link (người được mời chưa thuộc tổ chức nên không lọc theo tổ chức)
+func (r *invitationRepository) FindByTokenHash(tokenHash string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) FindByID(orgID, id string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.Scopes(ForOrganization(orgID)).Where("id = ?", id).First(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) FindPendingByEmail(orgID, email string) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.Scopes(ForOrganization(orgID), pending).Where("email = ?", email).First(&invitation).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) ListPending(orgID string) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+	err := r.db.Scopes(ForOrganization(orgID), pending).Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// MarkAccepted đánh dấu lời mời đã dùng; chỉ thành công một lần kể cả khi có request đồng thời
+func (r *invitationRepository) MarkAccepted(id, userID string) error {
+	result := r.db.Model(&model.Invitation{}).Scopes(pending).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"accepted_at": time.Now(),
+			"accepted_by": userID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invitation is no longer valid")
+	}
+	return nil
+}
+
+func (r *invitationRepository) Revoke(orgID, id string) error {
+	result := r.db.Model(&model.Invitation{}).Scopes(ForOrganization(orgID), pending).
+		Where("id = ?", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invitation not found")
+	}
+	return nil
+}
+
+// Renew thay token và gia hạn lời mời (gửi lại); link cũ không còn dùng được
+func (r *invitationRepository) Renew(orgID, id, tokenHash string, expiresAt time.Time) error {
+	result := r.db.Model(&model.Invitation{}).Scopes(ForOrganization(orgID)).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"token_hash": tokenHash,
+			"expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invitation not found")
+	}
+	return nil
+}
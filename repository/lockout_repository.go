@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"base-app/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LockoutRepository là interface ghi và đọc lịch sử khóa đăng nhập
+type LockoutRepository interface {
+	CreateEvent(event *model.LockoutEvent) error
+	ListEvents(kind, value string, limit int) ([]model.LockoutEvent, error)
+	ListEventsByUser(userID string) ([]model.LockoutEvent, error)
+}
+
+type lockoutRepository struct {
+	db *gorm.DB
+}
+
+func NewLockoutRepository(db *gorm.DB) LockoutRepository {
+	return &lockoutRepository{db: db}
+}
+
+func (r *lockoutRepository) CreateEvent(event *model.LockoutEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	return r.db.Create(event).Error
+}
+
+// ListEvents trả về các sự kiện mới nhất, lọc theo kind / value nếu có
+func (r *lockoutRepository) ListEvents(kind, value string, limit int) ([]model.LockoutEvent, error) {
+	query := r.db.Model(&model.LockoutEvent{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if value != "" {
+		query = query.Where("value = ?", value)
+	}
+
+	var events []model.LockoutEvent
+	err := query.Order("created_at DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// ListEventsByUser trả về toàn bộ lịch sử khóa đăng nhập của một tài khoản
+func (r *lockoutRepository) ListEventsByUser(userID string) ([]model.LockoutEvent, error) {
+	var events []model.LockoutEvent
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"base-app/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MFARepository là interface thao tác với mã khôi phục (recovery code) của xác thực hai lớp
+type MFARepository interface {
+	ReplaceRecoveryCodes(userID string, codeHashes []string) error
+	UseRecoveryCode(userID, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(userID string) (int64, error)
+	DeleteRecoveryCodes(userID string) error
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+// ReplaceRecoveryCodes xóa toàn bộ mã cũ và lưu bộ mã mới trong cùng một transaction
+func (r *mfaRepository) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]model.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.RecoveryCode{
+				ID:        uuid.New().String(),
+				UserID:    userID,
+				CodeHash:  hash,
+				CreatedAt: time.Now(),
+			})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode đánh dấu mã đã dùng; trả về false nếu mã không tồn tại hoặc đã dùng trước đó.
+// Điều kiện used_at IS NULL trong câu UPDATE đảm bảo mỗi mã chỉ dùng được một lần kể cả khi gửi đồng thời.
+func (r *mfaRepository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result := r.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *mfaRepository) CountUnusedRecoveryCodes(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *mfaRepository) DeleteRecoveryCodes(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"base-app/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthRepository là interface thao tác với client và consent của authorization server
+type OAuthRepository interface {
+	CreateClient(client *model.OAuthClient) error
+	FindClientByID(clientID string) (*model.OAuthClient, error)
+	ListClients() ([]model.OAuthClient, error)
+	DeleteClient(clientID string) error
+
+	FindConsent(userID, clientID string) (*model.OAuthConsent, error)
+	SaveConsent(userID, clientID, scopes string) error
+	ListConsents(userID string) ([]model.OAuthConsent, error)
+	DeleteConsent(userID, clientID string) error
+	DeleteConsentsByUser(userID string) error
+}
+
+type oauthRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepository(db *gorm.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+func (r *oauthRepository) CreateClient(client *model.OAuthClient) error {
+	if err := r.db.Create(client).Error; err != nil {
+		return fmt.Errorf("could not create oauth client: %v", err)
+	}
+	return nil
+}
+
+func (r *oauthRepository) FindClientByID(clientID string) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	result := r.db.First(&client, "id = ?", clientID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, errors.New("client not found")
+	}
+	return &client, result.Error
+}
+
+func (r *oauthRepository) ListClients() ([]model.OAuthClient, error) {
+	var clients []model.OAuthClient
+	err := r.db.Order("created_at DESC").Find(&clients).Error
+	return clients, err
+}
+
+func (r *oauthRepository) DeleteClient(clientID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", clientID).Delete(&model.OAuthConsent{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", clientID).Delete(&model.OAuthClient{}).Error
+	})
+}
+
+func (r *oauthRepository) FindConsent(userID, clientID string) (*model.OAuthConsent, error) {
+	var consent model.OAuthConsent
+	result := r.db.Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, errors.New("consent not found")
+	}
+	return &consent, result.Error
+}
+
+// SaveConsent tạo mới hoặc cập nhật danh sách scope đã đồng ý (upsert theo user + client)
+func (r *oauthRepository) SaveConsent(userID, clientID, scopes string) error {
+	consent := model.OAuthConsent{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		ClientID:  clientID,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+	}).Create(&consent).Error
+}
+
+func (r *oauthRepository) ListConsents(userID string) ([]model.OAuthConsent, error) {
+	var consents []model.OAuthConsent
+	err := r.db.Where("user_id = ?", userID).Order("updated_at DESC").Find(&consents).Error
+	return consents, err
+}
+
+func (r *oauthRepository) DeleteConsent(userID, clientID string) error {
+	return r.db.Where("user_id = ? AND client_id = ?", userID, clientID).Delete(&model.OAuthConsent{}).Error
+}
+
+func (r *oauthRepository) DeleteConsentsByUser(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.OAuthConsent{}).Error
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"base-app/model"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ForOrganization giới hạn truy vấn trong một tổ chức. Mọi truy vấn dữ liệu thuộc tenant phải dùng scope này
+// với organization ID lấy từ token (claim org_id), không lấy từ dữ liệu client gửi lên.
+func ForOrganization(orgID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("organization_id = ?", orgID)
+	}
+}
+
+// OrganizationRepository là interface thao tác với tổ chức và thành viên
+type OrganizationRepository interface {
+	Create(org *model.Organization, ownerID string) error
+	FindByID(id string) (*model.Organization, error)
+	SlugExists(slug string) (bool, error)
+	ListByUser(userID string) ([]model.OrganizationMembership, error)
+	ListMembershipsByUser(userID string) ([]model.Membership, error)
+	FindMembership(orgID, userID string) (*model.Membership, error)
+	AddMember(orgID, userID, role string) (*model.Membership, error)
+	ListMembers(orgID string) ([]model.Member, error)
+	CountMembersWithRole(orgID, role string) (int64, error)
+	UpdateMemberRole(orgID, userID, role string) error
+	RemoveMember(orgID, userID string) error
+	DeleteMembershipsByUser(userID string) error
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// Create tạo tổ chức và thêm người tạo làm owner
+func (r *organizationRepository) Create(org *model.Organization, ownerID string) error {
+	if org.ID == "" {
+		org.ID = uuid.New().String()
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.Membership{
+			ID:             uuid.New().String(),
+			OrganizationID: org.ID,
+			UserID:         ownerID,
+			Role:           model.OrgRoleOwner,
+		}).Error
+	})
+}
+
+func (r *organizationRepository) FindByID(id string) (*model.Organization, error) {
+	var org model.Organization
+	if err := r.db.Where("id = ?", id).First(&org).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) SlugExists(slug string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Organization{}).Where("slug = ?", slug).Count(&count).Error
+	return count > 0, err
+}
+
+// ListByUser trả về các tổ chức người dùng tham gia kèm vai trò
+func (r *organizationRepository) ListByUser(userID string) ([]model.OrganizationMembership, error) {
+	var orgs []model.OrganizationMembership
+	err := r.db.Table("organizations").
+		Select("organizations.*, memberships.role").
+		Joins("JOIN memberships ON memberships.organization_id = organizations.id").
+		Where("memberships.user_id = ?", userID).
+		Order("memberships.created_at").
+		Scan(&orgs).Error
+	return orgs, err
+}
+
+func (r *organizationRepository) ListMembershipsByUser(userID string) ([]model.Membership, error) {
+	var memberships []model.Membership
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *organizationRepository) FindMembership(orgID, userID string) (*model.Membership, error) {
+	var membership model.Membership
+	if err := r.db.Scopes(ForOrganization(orgID)).Where("user_id = ?", userID).First(&membership).Error; err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *organizationRepository) AddMember(orgID, userID, role string) (*model.Membership, error) {
+	membership := &model.Membership{
+		ID:             uuid.New().String(),
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           role,
+	}
+	if err := r.db.Create(membership).Error; err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+func (r *organizationRepository) ListMembers(orgID string) ([]model.Member, error) {
+	var members []model.Member
+	err := r.db.Table("memberships").
+		Select("memberships.user_id, users.name, users.email, memberships.role, memberships.created_at AS joined_at").
+		Joins("JOIN users ON users.id = memberships.user_id").
+		Scopes(ForOrganization(orgID)).
+		Order("memberships.created_at").
+		Scan(&members).Error
+	return members, err
+}
+
+func (r *organizationRepository) CountMembersWithRole(orgID, role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Membership{}).Scopes(ForOrganization(orgID)).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+func (r *organizationRepository) UpdateMemberRole(orgID, userID, role string) error {
+	result := r.db.Model(&model.Membership{}).Scopes(ForOrganization(orgID)).Where("user_id = ?", userID).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("member not found")
+	}
+	return nil
+}
+
+func (r *organizationRepository) RemoveMember(orgID, userID string) error {
+	result := r.db.Scopes(ForOrganization(orgID)).Where("user_id = ?", userID).Delete(&model.Membership{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("member not found")
+	}
+	return nil
+}
+
+func (r *organizationRepository) DeleteMembershipsByUser(userID string) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.Membership{}).Error
+}
@@ -3,31 +3,86 @@ package repository
 import (
 	"base-app/model"
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 )
 
 // RedisRepository định nghĩa các hàm thao tác với Redis
 type RedisRepository interface {
 	// Auth
-	SetAccessToken(ctx context.Context, token, userID, role string, ttl time.Duration) error
+	SetAccessToken(ctx context.Context, token, userID, role, familyID string, ttl time.Duration) error
+	GetAccessToken(ctx context.Context, token string) (userID, role, familyID string, err error)
 	IsTokenValid(ctx context.Context, token string) bool
 	RevokeToken(ctx context.Context, token string) error
 
 	// Refresh Token
-	SetRefreshToken(ctx context.Context, refreshToken, userID string, ttl time.Duration) error
+	SetRefreshToken(ctx context.Context, refreshToken, userID, familyID string, ttl time.Duration) error
 	GetUserIDByRefreshToken(ctx context.Context, refreshToken string) (string, error)
+	ConsumeRefreshToken(ctx context.Context, refreshToken string, usedTTL time.Duration) (userID, familyID string, err error)
+	FindUsedRefreshToken(ctx context.Context, refreshToken string) (userID, familyID string, err error)
+	RevokeRefreshTokenFamily(ctx context.Context, userID, familyID string) error
+	RevokeAllUserRefreshTokens(ctx context.Context, userID string) error
+
+	// MFA
+	SetMFAPendingSecret(ctx context.Context, userID, secret string, ttl time.Duration) error
+	GetMFAPendingSecret(ctx context.Context, userID string) (string, error)
+	DeleteMFAPendingSecret(ctx context.Context, userID string) error
+	SetMFAChallenge(ctx context.Context, challenge, userID, method string, ttl time.Duration) error
+	GetMFAChallenge(ctx context.Context, challenge string) (string, string, error)
+	DeleteMFAChallenge(ctx context.Context, challenge string) error
+	MarkTOTPStepUsed(ctx context.Context, userID string, step int64, ttl time.Duration) (bool, error)
+
+	// Password Reset
+	SetPasswordResetToken(ctx context.Context, tokenHash, userID string, ttl time.Duration) error
+	GetPasswordResetToken(ctx context.Context, tokenHash string) (string, error)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (string, error)
+	MarkDeviceReportUsed(ctx context.Context, tokenHash string, ttl time.Duration) (bool, error)
+
+	// OAuth / OIDC authorization server
+	SetOAuthCode(ctx context.Context, code, data string, ttl time.Duration) error
+	ConsumeOAuthCode(ctx context.Context, code string) (string, error)
+	SetOAuthAccessToken(ctx context.Context, userID, clientID, tokenHash, data string, ttl time.Duration) error
+	GetOAuthAccessToken(ctx context.Context, tokenHash string) (string, error)
+	RevokeOAuthAccessTokens(ctx context.Context, userID, clientID string) error
+
+	// Đăng nhập qua identity provider bên ngoài
+	SetFederatedLoginState(ctx context.Context, state, data string, ttl time.Duration) error
+	ConsumeFederatedLoginState(ctx context.Context, state string) (string, error)
 
 	// Rate Limiting
 	IncrementRate(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	SlidingWindowAllow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error)
+	TokenBucketAllow(ctx context.Context, key string, limit int, window time.Duration, burst int) (*RateLimitResult, error)
+
+	// Chống dò mật khẩu (brute-force)
+	IncrementLoginFailures(ctx context.Context, kind, value string, window time.Duration) (int64, error)
+	ResetLoginFailures(ctx context.Context, kind, value string) error
+	SetLoginDelay(ctx context.Context, kind, value string, delay time.Duration) error
+	GetLoginDelay(ctx context.Context, kind, value string) (time.Duration, error)
+	SetLoginLock(ctx context.Context, kind, value string, until time.Time) error
+	GetLoginLock(ctx context.Context, kind, value string) (time.Time, error)
+	ClearLoginLock(ctx context.Context, kind, value string) (bool, error)
+	ListLoginLocks(ctx context.Context) ([]model.Lockout, error)
 
-	// Role & Permission
-	AddPermissionToRole(ctx context.Context, role, permission string) error
-	RoleHasPermission(ctx context.Context, role, permission string) (bool, error)
+	// Role & Permission (cache, nguồn gốc nằm trong Postgres)
+	SetRolePermissions(ctx context.Context, role string, permissions []string, ttl time.Duration) error
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+	DeleteRolePermissions(ctx context.Context, role string) error
 
 	// Session Management
+	CreateSession(ctx context.Context, session *model.Session, ttl time.Duration) error
+	GetSession(ctx context.Context, sessionID string) (*model.Session, error)
+	ListUserSessions(ctx context.Context, userID string) ([]model.Session, error)
+	TouchSession(ctx context.Context, sessionID, ip string, at time.Time, ttl time.Duration) error
+	SetSessionOrganization(ctx context.Context, sessionID, orgID string) error
+	SetSessionAuthentication(ctx context.Context, sessionID string, at time.Time, amr []string) error
+	AddTokenToSession(ctx context.Context, sessionID, token string, ttl time.Duration) error
 	AddTokenToUser(ctx context.Context, userID, token string) error
 	RemoveTokenFromUser(ctx context.Context, userID, token string) error
 	GetAllUserTokens(ctx context.Context, userID string) ([]string, error)
@@ -38,10 +93,14 @@ type RedisRepository interface {
 	SetUserProfileFull(ctx context.Context, user *model.User, ttl time.Duration) error
 
 	GetUserProfile(ctx context.Context, userID string) (*model.User, error)
+	DeleteUserProfile(ctx context.Context, userID string) error
 
-	// list user mail
-	AddUserEmailToList(ctx context.Context, email string) error
-	GetUserEmails(ctx context.Context, start, stop int64) ([]string, error)
+	// Export dữ liệu người dùng
+	SaveDataExport(ctx context.Context, export *model.DataExport) error
+	GetDataExport(ctx context.Context, exportID string) (*model.DataExport, error)
+	GetLatestDataExport(ctx context.Context, userID string) (*model.DataExport, error)
+	SetDataExportArchive(ctx context.Context, exportID string, archive []byte, expiresAt time.Time) error
+	GetDataExportArchive(ctx context.Context, exportID string) ([]byte, error)
 
 	// Xóa dữ liệu người dùng khỏi Redis
 	DeletedAllDataUserAccount(ctx context.Context, key string) error
@@ -59,12 +118,32 @@ func NewRedisRepository(client *redis.Client) RedisRepository {
 
 // ======================= AUTH =======================
 
-func (r *redisRepo) SetAccessToken(ctx context.Context, token, userID, role string, ttl time.Duration) error {
+// ErrAccessTokenNotFound - access token không tồn tại (hết hạn hoặc đã bị thu hồi)
+var ErrAccessTokenNotFound = errors.New("access token not found")
+
+func (r *redisRepo) SetAccessToken(ctx context.Context, token, userID, role, familyID string, ttl time.Duration) error {
 	key := "auth:token:" + token
-	value := fmt.Sprintf("%s|%s", userID, role)
+	value := fmt.Sprintf("%s|%s|%s", userID, role, familyID)
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
+// GetAccessToken trả về user, role và refresh token family gắn với access token
+func (r *redisRepo) GetAccessToken(ctx context.Context, token string) (string, string, string, error) {
+	key := "auth:token:" + token
+	value, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", "", "", ErrAccessTokenNotFound
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+	parts := strings.SplitN(value, "|", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
 func (r *redisRepo) IsTokenValid(ctx context.Context, token string) bool {
 	key := "auth:token:" + token
 	exists, _ := r.client.Exists(ctx, key).Result()
@@ -78,43 +157,761 @@ func (r *redisRepo) RevokeToken(ctx context.Context, token string) error {
 
 // ======================= REFRESH TOKEN =======================
 
-func (r *redisRepo) SetRefreshToken(ctx context.Context, refreshToken, userID string, ttl time.Duration) error {
+// ErrRefreshTokenNotFound - refresh token không tồn tại, đã hết hạn hoặc đã bị sử dụng
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+// SetRefreshToken lưu refresh token cùng user và "family" (chuỗi token sinh ra từ một lần đăng nhập)
+func (r *redisRepo) SetRefreshToken(ctx context.Context, refreshToken, userID, familyID string, ttl time.Duration) error {
 	key := "auth:refresh:" + refreshToken
-	return r.client.Set(ctx, key, userID, ttl).Err()
+	familyKey := "auth:refresh:family:" + familyID
+	userFamiliesKey := "auth:user:" + userID + ":refresh_families"
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, fmt.Sprintf("%s|%s", userID, familyID), ttl)
+		pipe.SAdd(ctx, familyKey, refreshToken)
+		pipe.Expire(ctx, familyKey, ttl)
+		pipe.SAdd(ctx, userFamiliesKey, familyID)
+		pipe.Expire(ctx, userFamiliesKey, ttl)
+		return nil
+	})
+	return err
 }
 
 func (r *redisRepo) GetUserIDByRefreshToken(ctx context.Context, refreshToken string) (string, error) {
 	key := "auth:refresh:" + refreshToken
-	return r.client.Get(ctx, key).Result()
+	value, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	userID, _ := splitPair(value)
+	return userID, nil
+}
+
+// ConsumeRefreshToken lấy và xóa refresh token trong một lệnh (GETDEL) để đảm bảo mỗi token chỉ dùng được một lần.
+// Token đã dùng được đánh dấu lại trong usedTTL để phát hiện việc sử dụng lại.
+func (r *redisRepo) ConsumeRefreshToken(ctx context.Context, refreshToken string, usedTTL time.Duration) (string, string, error) {
+	key := "auth:refresh:" + refreshToken
+	value, err := r.client.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return "", "", ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	userID, familyID := splitPair(value)
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, "auth:refresh:used:"+refreshToken, value, usedTTL)
+	pipe.SRem(ctx, "auth:refresh:family:"+familyID, refreshToken)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", "", err
+	}
+	return userID, familyID, nil
+}
+
+// FindUsedRefreshToken trả về user và family của một refresh token đã bị xoay vòng (đã dùng)
+func (r *redisRepo) FindUsedRefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	value, err := r.client.Get(ctx, "auth:refresh:used:"+refreshToken).Result()
+	if err == redis.Nil {
+		return "", "", ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	userID, familyID := splitPair(value)
+	return userID, familyID, nil
+}
+
+// RevokeRefreshTokenFamily thu hồi toàn bộ refresh token còn hiệu lực thuộc một family.
+// Family chính là một phiên đăng nhập nên access token và bản ghi session của phiên cũng bị xóa.
+func (r *redisRepo) RevokeRefreshTokenFamily(ctx context.Context, userID, familyID string) error {
+	familyKey := "auth:refresh:family:" + familyID
+	tokens, err := r.client.SMembers(ctx, familyKey).Result()
+	if err != nil {
+		return err
+	}
+
+	sessionTokensKey := "auth:session:" + familyID + ":tokens"
+	accessTokens, err := r.client.SMembers(ctx, sessionTokensKey).Result()
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	for _, token := range tokens {
+		pipe.Del(ctx, "auth:refresh:"+token)
+	}
+	pipe.Del(ctx, familyKey)
+	pipe.SRem(ctx, "auth:user:"+userID+":refresh_families", familyID)
+
+	for _, token := range accessTokens {
+		pipe.Del(ctx, "auth:token:"+token)
+		pipe.SRem(ctx, "auth:user:"+userID+":tokens", token)
+	}
+	pipe.Del(ctx, sessionTokensKey)
+	pipe.Del(ctx, "auth:session:"+familyID)
+	pipe.SRem(ctx, "auth:user:"+userID+":sessions", familyID)
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RevokeAllUserRefreshTokens thu hồi tất cả refresh token (và do đó mọi phiên đăng nhập) của người dùng
+func (r *redisRepo) RevokeAllUserRefreshTokens(ctx context.Context, userID string) error {
+	families, err := r.client.SMembers(ctx, "auth:user:"+userID+":refresh_families").Result()
+	if err != nil {
+		return err
+	}
+	sessions, err := r.client.SMembers(ctx, "auth:user:"+userID+":sessions").Result()
+	if err != nil {
+		return err
+	}
+	for _, familyID := range append(families, sessions...) {
+		if err := r.RevokeRefreshTokenFamily(ctx, userID, familyID); err != nil {
+			return err
+		}
+	}
+	return r.client.Del(ctx, "auth:user:"+userID+":refresh_families", "auth:user:"+userID+":sessions").Err()
+}
+
+// splitPair tách giá trị dạng "a|b" được lưu trong Redis
+func splitPair(value string) (string, string) {
+	parts := strings.SplitN(value, "|", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// ======================= MFA =======================
+
+// ErrMFANotFound - secret chờ xác nhận hoặc MFA challenge không tồn tại / đã hết hạn
+var ErrMFANotFound = errors.New("mfa challenge not found")
+
+// SetMFAPendingSecret lưu secret TOTP đang chờ người dùng xác nhận bằng mã đầu tiên
+func (r *redisRepo) SetMFAPendingSecret(ctx context.Context, userID, secret string, ttl time.Duration) error {
+	key := "auth:mfa:pending:" + userID
+	return r.client.Set(ctx, key, secret, ttl).Err()
+}
+
+func (r *redisRepo) GetMFAPendingSecret(ctx context.Context, userID string) (string, error) {
+	key := "auth:mfa:pending:" + userID
+	secret, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrMFANotFound
+	}
+	return secret, err
+}
+
+func (r *redisRepo) DeleteMFAPendingSecret(ctx context.Context, userID string) error {
+	key := "auth:mfa:pending:" + userID
+	return r.client.Del(ctx, key).Err()
+}
+
+// SetMFAChallenge lưu token "MFA pending" cấp sau bước thứ nhất của đăng nhập, kèm phương thức của bước đó
+// (password, oidc:<provider>)
+func (r *redisRepo) SetMFAChallenge(ctx context.Context, challenge, userID, method string, ttl time.Duration) error {
+	key := "auth:mfa:challenge:" + challenge
+	return r.client.Set(ctx, key, userID+"|"+method, ttl).Err()
+}
+
+// GetMFAChallenge trả về user và phương thức của bước thứ nhất gắn với token "MFA pending"
+func (r *redisRepo) GetMFAChallenge(ctx context.Context, challenge string) (string, string, error) {
+	key := "auth:mfa:challenge:" + challenge
+	value, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", "", ErrMFANotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	userID, method, _ := strings.Cut(value, "|")
+	return userID, method, nil
+}
+
+func (r *redisRepo) DeleteMFAChallenge(ctx context.Context, challenge string) error {
+	return r.client.Del(ctx, "auth:mfa:challenge:"+challenge, "auth:mfa:attempts:"+challenge).Err()
+}
+
+// MarkTOTPStepUsed đánh dấu bước thời gian TOTP đã dùng (SETNX), trả về false nếu mã đã được dùng trước đó
+func (r *redisRepo) MarkTOTPStepUsed(ctx context.Context, userID string, step int64, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("auth:mfa:totp_used:%s:%d", userID, step)
+	return r.client.SetNX(ctx, key, 1, ttl).Result()
+}
+
+// ======================= PASSWORD RESET =======================
+
+// MarkDeviceReportUsed đánh dấu link "không phải tôi" đã dùng (SETNX), trả về false nếu link đã được dùng trước đó
+func (r *redisRepo) MarkDeviceReportUsed(ctx context.Context, tokenHash string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, "auth:device_report:used:"+tokenHash, 1, ttl).Result()
+}
+
+// ErrPasswordResetTokenNotFound - token đặt lại mật khẩu không tồn tại, hết hạn hoặc đã dùng
+var ErrPasswordResetTokenNotFound = errors.New("password reset token not found")
+
+// SetPasswordResetToken lưu bản băm của token đặt lại mật khẩu. Mỗi user chỉ có một token còn hiệu lực:
+// token cũ bị xóa khi token mới được tạo.
+func (r *redisRepo) SetPasswordResetToken(ctx context.Context, tokenHash, userID string, ttl time.Duration) error {
+	userKey := "auth:reset:user:" + userID
+
+	previous, err := r.client.Get(ctx, userKey).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous != "" {
+			pipe.Del(ctx, "auth:reset:"+previous)
+		}
+		pipe.Set(ctx, "auth:reset:"+tokenHash, userID, ttl)
+		pipe.Set(ctx, userKey, tokenHash, ttl)
+		return nil
+	})
+	return err
+}
+
+// GetPasswordResetToken đọc user ID của token mà không xóa token
+func (r *redisRepo) GetPasswordResetToken(ctx context.Context, tokenHash string) (string, error) {
+	userID, err := r.client.Get(ctx, "auth:reset:"+tokenHash).Result()
+	if err == redis.Nil {
+		return "", ErrPasswordResetTokenNotFound
+	}
+	return userID, err
+}
+
+// ConsumePasswordResetToken lấy và xóa token (GETDEL) để token chỉ dùng được một lần
+func (r *redisRepo) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (string, error) {
+	userID, err := r.client.GetDel(ctx, "auth:reset:"+tokenHash).Result()
+	if err == redis.Nil {
+		return "", ErrPasswordResetTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	r.client.Del(ctx, "auth:reset:user:"+userID)
+	return userID, nil
+}
+
+// ======================= OAUTH =======================
+
+// ErrOAuthGrantNotFound - authorization code hoặc access token OAuth không tồn tại / đã hết hạn
+var ErrOAuthGrantNotFound = errors.New("oauth grant not found")
+
+// SetOAuthCode lưu authorization code cùng dữ liệu yêu cầu (JSON)
+func (r *redisRepo) SetOAuthCode(ctx context.Context, code, data string, ttl time.Duration) error {
+	return r.client.Set(ctx, "oauth:code:"+code, data, ttl).Err()
+}
+
+// ConsumeOAuthCode lấy và xóa authorization code (GETDEL) để code chỉ đổi được một lần
+func (r *redisRepo) ConsumeOAuthCode(ctx context.Context, code string) (string, error) {
+	data, err := r.client.GetDel(ctx, "oauth:code:"+code).Result()
+	if err == redis.Nil {
+		return "", ErrOAuthGrantNotFound
+	}
+	return data, err
+}
+
+// SetOAuthAccessToken lưu access token cấp cho client bên thứ ba (chỉ lưu bản băm)
+// và ghi vào danh sách token của người dùng dưới dạng "<client_id>:<hash>" để thu hồi theo client
+func (r *redisRepo) SetOAuthAccessToken(ctx context.Context, userID, clientID, tokenHash, data string, ttl time.Duration) error {
+	key := "oauth:user:" + userID + ":access"
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "oauth:access:"+tokenHash, data, ttl)
+		pipe.SAdd(ctx, key, clientID+":"+tokenHash)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (r *redisRepo) GetOAuthAccessToken(ctx context.Context, tokenHash string) (string, error) {
+	data, err := r.client.Get(ctx, "oauth:access:"+tokenHash).Result()
+	if err == redis.Nil {
+		return "", ErrOAuthGrantNotFound
+	}
+	return data, err
+}
+
+// RevokeOAuthAccessTokens xóa access token người dùng đã cấp cho client; clientID rỗng nghĩa là mọi client
+func (r *redisRepo) RevokeOAuthAccessTokens(ctx context.Context, userID, clientID string) error {
+	key := "oauth:user:" + userID + ":access"
+	members, err := r.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	var revoked []interface{}
+	for _, member := range members {
+		sep := strings.LastIndex(member, ":")
+		if sep < 0 {
+			continue
+		}
+		if clientID != "" && member[:sep] != clientID {
+			continue
+		}
+		keys = append(keys, "oauth:access:"+member[sep+1:])
+		revoked = append(revoked, member)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		pipe.SRem(ctx, key, revoked...)
+		return nil
+	})
+	return err
+}
+
+// ======================= FEDERATED LOGIN =======================
+
+// ErrFederatedStateNotFound - state không tồn tại, đã hết hạn hoặc đã được dùng
+var ErrFederatedStateNotFound = errors.New("federated login state not found")
+
+// SetFederatedLoginState lưu state cùng nonce / PKCE verifier (JSON) trong lúc chờ IdP redirect về
+func (r *redisRepo) SetFederatedLoginState(ctx context.Context, state, data string, ttl time.Duration) error {
+	return r.client.Set(ctx, "auth:federated:state:"+state, data, ttl).Err()
+}
+
+// ConsumeFederatedLoginState lấy và xóa state (GETDEL) để callback chỉ xử lý được một lần
+func (r *redisRepo) ConsumeFederatedLoginState(ctx context.Context, state string) (string, error) {
+	data, err := r.client.GetDel(ctx, "auth:federated:state:"+state).Result()
+	if err == redis.Nil {
+		return "", ErrFederatedStateNotFound
+	}
+	return data, err
 }
 
 // ======================= RATE LIMITING =======================
 
+// incrementRateScript tăng bộ đếm và đặt TTL trong cùng một lệnh, tránh bộ đếm không bao giờ hết hạn
+// nếu tiến trình dừng giữa INCR và EXPIRE
+var incrementRateScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// IncrementRate tăng bộ đếm fixed window, TTL được đặt ở lần tăng đầu tiên
 func (r *redisRepo) IncrementRate(ctx context.Context, key string, ttl time.Duration) (int64, error) {
-	count, err := r.client.Incr(ctx, key).Result()
+	return incrementRateScript.Run(ctx, r.client, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+// RateLimitResult là kết quả kiểm tra giới hạn tần suất của một request
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // thời gian tới khi giới hạn được khôi phục hoàn toàn / có thêm lượt
+	RetryAfter time.Duration // chỉ có khi bị từ chối
+}
+
+// slidingWindowScript lưu thời điểm các request trong sorted set, chỉ đếm các request trong cửa sổ [now - window, now]
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call("PEXPIRE", KEYS[1], window)
+
+local reset = 0
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// SlidingWindowAllow cho phép tối đa limit request trong cửa sổ trượt window
+func (r *redisRepo) SlidingWindowAllow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	now := time.Now().UnixMilli()
+	member := strconv.FormatInt(now, 10) + "-" + uuid.New().String()
+
+	values, err := slidingWindowScript.Run(ctx, r.client, []string{key}, now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RateLimitResult{
+		Allowed:   values[0] == 1,
+		Remaining: int(values[1]),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}
+	if !result.Allowed {
+		result.RetryAfter = result.Reset
+	}
+	return result, nil
+}
+
+// tokenBucketScript lưu số token còn lại và thời điểm cập nhật; token được nạp lại liên tục theo rate (token/ms)
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = capacity
+	updated = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - updated) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", now)
+redis.call("PEXPIRE", KEYS[1], reset + 1000)
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// TokenBucketAllow dùng bucket chứa tối đa burst token, nạp lại limit token mỗi window
+func (r *redisRepo) TokenBucketAllow(ctx context.Context, key string, limit int, window time.Duration, burst int) (*RateLimitResult, error) {
+	now := time.Now().UnixMilli()
+	rate := float64(limit) / float64(window.Milliseconds())
+
+	values, err := tokenBucketScript.Run(ctx, r.client, []string{key}, now, strconv.FormatFloat(rate, 'f', -1, 64), burst).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// ======================= LOGIN LOCKOUT =======================
+
+// ErrLoginLockNotFound - không có khóa đăng nhập đang hiệu lực
+var ErrLoginLockNotFound = errors.New("login lock not found")
+
+// loginLocksKey là sorted set các khóa đang hiệu lực ("<kind>|<value>", score = thời điểm hết khóa) phục vụ trang admin
+const loginLocksKey = "auth:login:locks"
+
+func loginKey(prefix, kind, value string) string {
+	return "auth:login:" + prefix + ":" + kind + ":" + value
+}
+
+// IncrementLoginFailures tăng bộ đếm đăng nhập sai trong cửa sổ thời gian
+func (r *redisRepo) IncrementLoginFailures(ctx context.Context, kind, value string, window time.Duration) (int64, error) {
+	return r.IncrementRate(ctx, loginKey("fail", kind, value), window)
+}
+
+// ResetLoginFailures xóa bộ đếm đăng nhập sai và độ trễ đang áp dụng
+func (r *redisRepo) ResetLoginFailures(ctx context.Context, kind, value string) error {
+	return r.client.Del(ctx, loginKey("fail", kind, value), loginKey("delay", kind, value)).Err()
+}
+
+// SetLoginDelay buộc lần thử tiếp theo phải chờ delay
+func (r *redisRepo) SetLoginDelay(ctx context.Context, kind, value string, delay time.Duration) error {
+	return r.client.Set(ctx, loginKey("delay", kind, value), 1, delay).Err()
+}
+
+// GetLoginDelay trả về thời gian còn phải chờ trước lần thử tiếp theo (0 nếu không phải chờ)
+func (r *redisRepo) GetLoginDelay(ctx context.Context, kind, value string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, loginKey("delay", kind, value)).Result()
 	if err != nil {
 		return 0, err
 	}
-	if count == 1 {
-		r.client.Expire(ctx, key, ttl)
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// SetLoginLock khóa đăng nhập tới thời điểm until
+func (r *redisRepo) SetLoginLock(ctx context.Context, kind, value string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, loginKey("lock", kind, value), until.Unix(), ttl)
+		pipe.Del(ctx, loginKey("fail", kind, value), loginKey("delay", kind, value))
+		pipe.ZAdd(ctx, loginLocksKey, &redis.Z{Score: float64(until.Unix()), Member: kind + "|" + value})
+		return nil
+	})
+	return err
+}
+
+// GetLoginLock trả về thời điểm hết khóa
+func (r *redisRepo) GetLoginLock(ctx context.Context, kind, value string) (time.Time, error) {
+	until, err := r.client.Get(ctx, loginKey("lock", kind, value)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, ErrLoginLockNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(until, 0), nil
+}
+
+// ClearLoginLock mở khóa và xóa bộ đếm; trả về false nếu không có khóa nào đang hiệu lực
+func (r *redisRepo) ClearLoginLock(ctx context.Context, kind, value string) (bool, error) {
+	var deleted *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.Del(ctx, loginKey("lock", kind, value))
+		pipe.Del(ctx, loginKey("fail", kind, value), loginKey("delay", kind, value))
+		pipe.ZRem(ctx, loginLocksKey, kind+"|"+value)
+		return nil
+	})
+	if err != nil {
+		return false, err
 	}
-	return count, nil
+	return deleted.Val() > 0, nil
+}
+
+// ListLoginLocks trả về các khóa đang hiệu lực, dọn các khóa đã hết hạn khỏi danh sách
+func (r *redisRepo) ListLoginLocks(ctx context.Context) ([]model.Lockout, error) {
+	now := time.Now().Unix()
+	r.client.ZRemRangeByScore(ctx, loginLocksKey, "-inf", strconv.FormatInt(now, 10))
+
+	members, err := r.client.ZRangeWithScores(ctx, loginLocksKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	locks := make([]model.Lockout, 0, len(members))
+	for _, m := range members {
+		member, _ := m.Member.(string)
+		kind, value := splitPair(member)
+		locks = append(locks, model.Lockout{
+			Kind:        kind,
+			Value:       value,
+			LockedUntil: time.Unix(int64(m.Score), 0),
+		})
+	}
+	return locks, nil
 }
 
 // ======================= ROLE - PERMISSION =======================
 
-func (r *redisRepo) AddPermissionToRole(ctx context.Context, role, permission string) error {
+// ErrRolePermissionsNotCached - quyền của vai trò chưa có trong cache, cần đọc từ Postgres
+var ErrRolePermissionsNotCached = errors.New("role permissions not cached")
+
+// rolePermissionsMarker luôn có trong set để phân biệt "vai trò không có quyền nào" với "chưa cache"
+const rolePermissionsMarker = "#"
+
+// SetRolePermissions ghi đè toàn bộ quyền của vai trò trong cache
+func (r *redisRepo) SetRolePermissions(ctx context.Context, role string, permissions []string, ttl time.Duration) error {
 	key := "user:role:" + role
-	return r.client.SAdd(ctx, key, permission).Err()
+	members := make([]interface{}, 0, len(permissions)+1)
+	members = append(members, rolePermissionsMarker)
+	for _, permission := range permissions {
+		members = append(members, permission)
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.SAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
 }
 
-func (r *redisRepo) RoleHasPermission(ctx context.Context, role, permission string) (bool, error) {
-	key := "user:role:" + role
-	return r.client.SIsMember(ctx, key, permission).Result()
+// GetRolePermissions trả về quyền của vai trò từ cache
+func (r *redisRepo) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	members, err := r.client.SMembers(ctx, "user:role:"+role).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, ErrRolePermissionsNotCached
+	}
+
+	permissions := make([]string, 0, len(members)-1)
+	for _, member := range members {
+		if member != rolePermissionsMarker {
+			permissions = append(permissions, member)
+		}
+	}
+	return permissions, nil
+}
+
+// DeleteRolePermissions xóa cache quyền của vai trò sau khi thay đổi trong Postgres
+func (r *redisRepo) DeleteRolePermissions(ctx context.Context, role string) error {
+	return r.client.Del(ctx, "user:role:"+role).Err()
 }
 
 // ======================= SESSION =======================
 
+// ErrSessionNotFound - phiên đăng nhập không tồn tại hoặc đã kết thúc
+var ErrSessionNotFound = errors.New("session not found")
+
+// touchSessionScript chỉ cập nhật phiên còn tồn tại, tránh tạo lại hash không có TTL sau khi phiên đã bị thu hồi
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], "last_seen_at", ARGV[1])
+if ARGV[2] ~= "" then
+	redis.call("HSET", KEYS[1], "ip", ARGV[2])
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+// setSessionFieldScript chỉ ghi vào phiên còn tồn tại
+var setSessionFieldScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// SetSessionOrganization lưu tổ chức đang hoạt động của phiên
+func (r *redisRepo) SetSessionOrganization(ctx context.Context, sessionID, orgID string) error {
+	return setSessionFieldScript.Run(ctx, r.client, []string{"auth:session:" + sessionID}, "org_id", orgID).Err()
+}
+
+// setSessionAuthScript ghi thời điểm và phương thức xác thực vào phiên còn tồn tại
+var setSessionAuthScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], "auth_time", ARGV[1], "amr", ARGV[2])
+return 1
+`)
+
+// SetSessionAuthentication ghi nhận lần xác thực mới của phiên (xác thực lại mà không mở phiên mới)
+func (r *redisRepo) SetSessionAuthentication(ctx context.Context, sessionID string, at time.Time, amr []string) error {
+	return setSessionAuthScript.Run(ctx, r.client, []string{"auth:session:" + sessionID}, at.Unix(), strings.Join(amr, ",")).Err()
+}
+
+// CreateSession lưu phiên đăng nhập dạng hash "auth:session:<id>" và thêm vào danh sách phiên của người dùng
+func (r *redisRepo) CreateSession(ctx context.Context, session *model.Session, ttl time.Duration) error {
+	key := "auth:session:" + session.ID
+	userSessionsKey := "auth:user:" + session.UserID + ":sessions"
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, map[string]interface{}{
+			"user_id":      session.UserID,
+			"user_agent":   session.UserAgent,
+			"device":       session.Device,
+			"ip":           session.IP,
+			"created_at":   session.CreatedAt.Unix(),
+			"last_seen_at": session.LastSeenAt.Unix(),
+			"auth_time":    session.AuthTime.Unix(),
+			"amr":          strings.Join(session.AMR, ","),
+		})
+		pipe.Expire(ctx, key, ttl)
+		pipe.SAdd(ctx, userSessionsKey, session.ID)
+		pipe.Expire(ctx, userSessionsKey, ttl)
+		return nil
+	})
+	return err
+}
+
+// GetSession đọc một phiên đăng nhập
+func (r *redisRepo) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	data, err := r.client.HGetAll(ctx, "auth:session:"+sessionID).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrSessionNotFound
+	}
+	return sessionFromHash(sessionID, data), nil
+}
+
+// ListUserSessions trả về các phiên còn hiệu lực của người dùng, dọn các ID đã hết hạn khỏi danh sách
+func (r *redisRepo) ListUserSessions(ctx context.Context, userID string) ([]model.Session, error) {
+	userSessionsKey := "auth:user:" + userID + ":sessions"
+	ids, err := r.client.SMembers(ctx, userSessionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]model.Session, 0, len(ids))
+	for _, id := range ids {
+		session, err := r.GetSession(ctx, id)
+		if err == ErrSessionNotFound {
+			r.client.SRem(ctx, userSessionsKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+// TouchSession cập nhật thời điểm hoạt động gần nhất (và IP nếu có); ttl > 0 thì gia hạn phiên
+func (r *redisRepo) TouchSession(ctx context.Context, sessionID, ip string, at time.Time, ttl time.Duration) error {
+	return touchSessionScript.Run(ctx, r.client, []string{"auth:session:" + sessionID},
+		at.Unix(), ip, ttl.Milliseconds()).Err()
+}
+
+// AddTokenToSession ghi nhận access token thuộc phiên để có thể thu hồi khi đăng xuất phiên từ xa
+func (r *redisRepo) AddTokenToSession(ctx context.Context, sessionID, token string, ttl time.Duration) error {
+	key := "auth:session:" + sessionID + ":tokens"
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, token)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+// sessionFromHash chuyển hash trong Redis thành model.Session
+func sessionFromHash(sessionID string, data map[string]string) *model.Session {
+	parseUnix := func(value string) time.Time {
+		sec, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Unix(sec, 0)
+	}
+	var amr []string
+	if data["amr"] != "" {
+		amr = strings.Split(data["amr"], ",")
+	}
+	return &model.Session{
+		ID:         sessionID,
+		UserID:     data["user_id"],
+		UserAgent:  data["user_agent"],
+		Device:     data["device"],
+		IP:         data["ip"],
+		CreatedAt:  parseUnix(data["created_at"]),
+		LastSeenAt: parseUnix(data["last_seen_at"]),
+
+		OrganizationID: data["org_id"],
+
+		AuthTime: parseUnix(data["auth_time"]),
+		AMR:      amr,
+	}
+}
+
 func (r *redisRepo) AddTokenToUser(ctx context.Context, userID, token string) error {
 	key := "auth:user:" + userID + ":tokens"
 	return r.client.SAdd(ctx, key, token).Err()
@@ -142,6 +939,97 @@ func (r *redisRepo) RevokeAllUserTokens(ctx context.Context, userID string) erro
 	return r.client.Del(ctx, key).Err()
 }
 
+// ======================= DATA EXPORT =======================
+
+// ErrDataExportNotFound - yêu cầu export không tồn tại hoặc đã hết hạn
+var ErrDataExportNotFound = errors.New("data export not found")
+
+// SaveDataExport lưu trạng thái export dạng hash "user:export:<id>" và đánh dấu là export mới nhất của người dùng;
+// cả hai khóa hết hạn cùng lúc với export
+func (r *redisRepo) SaveDataExport(ctx context.Context, export *model.DataExport) error {
+	key := "user:export:" + export.ID
+	completedAt := int64(0)
+	if export.CompletedAt != nil {
+		completedAt = export.CompletedAt.Unix()
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, map[string]interface{}{
+			"user_id":      export.UserID,
+			"status":       export.Status,
+			"error":        export.Error,
+			"created_at":   export.CreatedAt.Unix(),
+			"completed_at": completedAt,
+			"expires_at":   export.ExpiresAt.Unix(),
+		})
+		pipe.ExpireAt(ctx, key, export.ExpiresAt)
+		pipe.Set(ctx, "user:"+export.UserID+":export", export.ID, 0)
+		pipe.ExpireAt(ctx, "user:"+export.UserID+":export", export.ExpiresAt)
+		return nil
+	})
+	return err
+}
+
+func (r *redisRepo) GetDataExport(ctx context.Context, exportID string) (*model.DataExport, error) {
+	data, err := r.client.HGetAll(ctx, "user:export:"+exportID).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrDataExportNotFound
+	}
+
+	parseUnix := func(value string) time.Time {
+		sec, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Unix(sec, 0)
+	}
+	export := &model.DataExport{
+		ID:        exportID,
+		UserID:    data["user_id"],
+		Status:    data["status"],
+		Error:     data["error"],
+		CreatedAt: parseUnix(data["created_at"]),
+		ExpiresAt: parseUnix(data["expires_at"]),
+	}
+	if completedAt := parseUnix(data["completed_at"]); completedAt.Unix() > 0 {
+		export.CompletedAt = &completedAt
+	}
+	return export, nil
+}
+
+// GetLatestDataExport trả về export gần nhất (chưa hết hạn) của người dùng
+func (r *redisRepo) GetLatestDataExport(ctx context.Context, userID string) (*model.DataExport, error) {
+	exportID, err := r.client.Get(ctx, "user:"+userID+":export").Result()
+	if err == redis.Nil {
+		return nil, ErrDataExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetDataExport(ctx, exportID)
+}
+
+func (r *redisRepo) SetDataExportArchive(ctx context.Context, exportID string, archive []byte, expiresAt time.Time) error {
+	key := "user:export:" + exportID + ":archive"
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, archive, 0)
+		pipe.ExpireAt(ctx, key, expiresAt)
+		return nil
+	})
+	return err
+}
+
+func (r *redisRepo) GetDataExportArchive(ctx context.Context, exportID string) ([]byte, error) {
+	data, err := r.client.Get(ctx, "user:export:"+exportID+":archive").Bytes()
+	if err == redis.Nil {
+		return nil, ErrDataExportNotFound
+	}
+	return data, err
+}
+
 // ======================= USER PROFILE =======================
 
 // Lưu email đơn giản
@@ -187,16 +1075,9 @@ func (r *redisRepo) GetUserProfile(ctx context.Context, userID string) (*model.U
 	return user, nil
 }
 
-// AddUserEmailToList thêm email vào danh sách người dùng
-func (r *redisRepo) AddUserEmailToList(ctx context.Context, email string) error {
-	key := "user:list_email"
-	return r.client.LPush(ctx, key, email).Err()
-}
-
-// GetUserEmails lấy danh sách email người dùng từ Redis
-func (r *redisRepo) GetUserEmails(ctx context.Context, start, stop int64) ([]string, error) {
-	key := "user:list_email"
-	return r.client.LRange(ctx, key, start, stop).Result()
+// DeleteUserProfile - Xóa cache profile sau khi dữ liệu người dùng thay đổi
+func (r *redisRepo) DeleteUserProfile(ctx context.Context, userID string) error {
+	return r.client.Del(ctx, "user:profile:"+userID).Err()
 }
 
 // DeletedUserAccount - Xóa tất cả dữ liệu liên quan đến người dùng khỏi Redis
@@ -213,28 +1094,50 @@ func (r *redisRepo) DeletedAllDataUserAccount(ctx context.Context, userID string
 		return fmt.Errorf("failed to delete user email from Redis: %v", err)
 	}
 
-	// Xóa danh sách token của người dùng
-	err = r.client.Del(ctx, "auth:user:"+userID+":tokens").Err()
+	// Thu hồi access token còn hiệu lực (xóa cả token lẫn danh sách token của người dùng)
+	err = r.RevokeAllUserTokens(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user tokens from Redis: %v", err)
 	}
 
-	// Xóa các khóa liên quan đến session, nếu có
-	err = r.client.Del(ctx, "auth:user:"+userID+":sessions").Err()
+	// Xóa các khóa liên quan đến refresh token và session (nếu có)
+	err = r.RevokeAllUserRefreshTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user refresh token from Redis: %v", err)
+	}
+
+	// Thu hồi access token đã cấp cho ứng dụng bên thứ ba
+	err = r.RevokeOAuthAccessTokens(ctx, userID, "")
 	if err != nil {
-		return fmt.Errorf("failed to delete user sessions from Redis: %v", err)
+		return fmt.Errorf("failed to delete user oauth access tokens from Redis: %v", err)
 	}
 
-	// Xóa các khóa liên quan đến refresh token (nếu có)
-	err = r.client.Del(ctx, "auth:refresh:"+userID).Err()
+	// Xóa link đặt lại mật khẩu còn hiệu lực
+	resetToken, err := r.client.Get(ctx, "auth:reset:user:"+userID).Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read password reset token from Redis: %v", err)
+	}
+	keys := []string{"auth:reset:user:" + userID}
+	if resetToken != "" {
+		keys = append(keys, "auth:reset:"+resetToken)
+	}
+	err = r.client.Del(ctx, keys...).Err()
 	if err != nil {
-		return fmt.Errorf("failed to delete user refresh token from Redis: %v", err)
+		return fmt.Errorf("failed to delete password reset token from Redis: %v", err)
 	}
 
-	// Xóa tất cả các quyền và role nếu có
-	err = r.client.Del(ctx, "user:role:"+userID).Err()
+	// Xóa file export dữ liệu gần nhất
+	exportID, err := r.client.Get(ctx, "user:"+userID+":export").Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read data export from Redis: %v", err)
+	}
+	keys = []string{"user:" + userID + ":export"}
+	if exportID != "" {
+		keys = append(keys, "user:export:"+exportID, "user:export:"+exportID+":archive")
+	}
+	err = r.client.Del(ctx, keys...).Err()
 	if err != nil {
-		return fmt.Errorf("failed to delete user role from Redis: %v", err)
+		return fmt.Errorf("failed to delete data export from Redis: %v", err)
 	}
 
 	// Nếu có các dữ liệu khác cần xóa, thêm vào đây
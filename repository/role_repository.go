@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"base-app/model"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleRepository là interface thao tác với vai trò và quyền (nguồn dữ liệu gốc, Redis chỉ là cache)
+type RoleRepository interface {
+	Create(role *model.Role) error
+	FindByName(name string) (*model.Role, error)
+	List() ([]model.Role, error)
+	Delete(name string) error
+	ListPermissions(role string) ([]string, error)
+	AddPermission(role, permission string) error
+	RemovePermission(role, permission string) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// Create tạo vai trò cùng các quyền ban đầu
+func (r *roleRepository) Create(role *model.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		for _, permission := range role.Permissions {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&model.RolePermission{Role: role.Name, Permission: permission}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *roleRepository) FindByName(name string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+
+	permissions, err := r.ListPermissions(name)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
+	return &role, nil
+}
+
+func (r *roleRepository) List() ([]model.Role, error) {
+	var roles []model.Role
+	if err := r.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	var grants []model.RolePermission
+	if err := r.db.Order("permission").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	byRole := map[string][]string{}
+	for _, grant := range grants {
+		byRole[grant.Role] = append(byRole[grant.Role], grant.Permission)
+	}
+
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].Name]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return roles, nil
+}
+
+// Delete xóa vai trò và toàn bộ quyền của vai trò
+func (r *roleRepository) Delete(name string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", name).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("name = ?", name).Delete(&model.Role{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("role not found")
+		}
+		return nil
+	})
+}
+
+func (r *roleRepository) ListPermissions(role string) ([]string, error) {
+	permissions := []string{}
+	err := r.db.Model(&model.RolePermission{}).
+		Where("role = ?", role).
+		Order("permission").
+		Pluck("permission", &permissions).Error
+	return permissions, err
+}
+
+// AddPermission cấp quyền cho vai trò; cấp lại quyền đã có không báo lỗi
+func (r *roleRepository) AddPermission(role, permission string) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.RolePermission{Role: role, Permission: permission}).Error
+}
+
+func (r *roleRepository) RemovePermission(role, permission string) error {
+	result := r.db.Where("role = ? AND permission = ?", role, permission).Delete(&model.RolePermission{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("permission not granted to role")
+	}
+	return nil
+}
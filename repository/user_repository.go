@@ -2,14 +2,37 @@ package repository
 
 import (
 	"base-app/model"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// UserFilter là điều kiện lọc danh sách người dùng cho trang quản trị
+type UserFilter struct {
+	Search        string // tìm theo tên hoặc email (không phân biệt hoa thường)
+	Role          string
+	Status        string
+	EmailVerified *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Cursor        string // NextCursor của trang trước
+	Limit         int
+}
+
+// UserPage là một trang kết quả, NextCursor rỗng khi đã hết dữ liệu
+type UserPage struct {
+	Users      []model.User
+	NextCursor string
+}
+
+// ErrInvalidCursor - cursor phân trang không hợp lệ
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 // UserRepository là interface cho các thao tác với người dùng
 type UserRepository interface {
 	Create(name string, email string, hashPassword string) (*model.User, error)
@@ -17,6 +40,18 @@ type UserRepository interface {
 	FindByID(id string) (*model.User, error)
 	Update(userID string, name string, email string) (*model.User, error)
 	UpdatePassword(userID, password string) error
+	ResetPassword(userID, password string) error
+	RequirePasswordReset(userID string) error
+	UpdateMFA(userID string, enabled bool, totpSecret string) error
+	MarkEmailVerified(userID, email string) (bool, error)
+	UpdateRole(userID, role string) error
+	CountByRole(role string) (int64, error)
+	SetActiveOrganization(userID, orgID string) error
+	List(filter UserFilter) (*UserPage, error)
+	UpdateStatus(userID, status string) error
+	MarkPendingDeletion(userID string) error
+	RestorePendingDeletion(userID string) (bool, error)
+	ListPendingDeletion(requestedBefore time.Time, excludeIDs []string, limit int) ([]model.User, error)
 	Delete(userID string) error
 }
 
@@ -35,6 +70,7 @@ func (r *userRepository) Create(name string, email string, hashPassword string)
 		Email:     email,
 		Password:  hashPassword,
 		Role:      model.RoleUser,
+		Status:    model.UserStatusActive,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -70,6 +106,12 @@ func (r *userRepository) Update(userID string, name string, email string) (*mode
 		return nil, err
 	}
 
+	// Đổi email => phải xác thực lại địa chỉ mới
+	if user.Email != email {
+		user.EmailVerified = false
+		user.EmailVerifiedAt = nil
+	}
+
 	user.Name = name
 	user.Email = email
 	user.UpdatedAt = time.Now()
@@ -85,6 +127,205 @@ func (r *userRepository) UpdatePassword(userID string, newPassword string) error
 	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("password", newPassword).Error
 }
 
+// ResetPassword lưu mật khẩu đặt lại qua link trong email và gỡ yêu cầu bắt buộc đổi mật khẩu (nếu có).
+// Đổi mật khẩu trong phiên đăng nhập (UpdatePassword) không gỡ yêu cầu này.
+func (r *userRepository) ResetPassword(userID string, newPassword string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":                newPassword,
+		"password_reset_required": false,
+	}).Error
+}
+
+// RequirePasswordReset chặn đăng nhập bằng mật khẩu cho tới khi người dùng đặt mật khẩu mới
+func (r *userRepository) RequirePasswordReset(userID string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("password_reset_required", true).Error
+}
+
+func (r *userRepository) UpdateMFA(userID string, enabled bool, totpSecret string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"mfa_enabled": enabled,
+		"totp_secret": totpSecret,
+		"updated_at":  time.Now(),
+	}).Error
+}
+
+// MarkEmailVerified đánh dấu email đã xác thực; chỉ cập nhật nếu email hiện tại vẫn là email được gửi link
+func (r *userRepository) MarkEmailVerified(userID, email string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Updates(map[string]interface{}{
+			"email_verified":    true,
+			"email_verified_at": now,
+			"updated_at":        now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *userRepository) UpdateRole(userID, role string) error {
+	result := r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"role":       role,
+		"updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// CountByRole đếm số người dùng đang được gán vai trò
+func (r *userRepository) CountByRole(role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) SetActiveOrganization(userID, orgID string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Update("active_organization_id", orgID).Error
+}
+
+// List trả về người dùng mới nhất trước, phân trang theo cursor (created_at, id) nên không bị lệch
+// khi có người dùng mới được tạo giữa hai lần gọi
+func (r *userRepository) List(filter UserFilter) (*UserPage, error) {
+	query := r.db.Model(&model.User{})
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(search)) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EmailVerified != nil {
+		query = query.Where("email_verified = ?", *filter.EmailVerified)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	// Lấy thừa một bản ghi để biết còn trang sau hay không
+	var users []model.User
+	if err := query.Order("created_at DESC, id DESC").Limit(filter.Limit + 1).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	page := &UserPage{Users: users}
+	if len(users) > filter.Limit {
+		page.Users = users[:filter.Limit]
+		last := page.Users[len(page.Users)-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+func (r *userRepository) UpdateStatus(userID, status string) error {
+	updates := map[string]interface{}{
+		"status":      status,
+		"disabled_at": nil,
+		"updated_at":  time.Now(),
+	}
+	if status == model.UserStatusDisabled {
+		updates["disabled_at"] = time.Now()
+	}
+
+	result := r.db.Model(&model.User{}).Where("id = ?", userID).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// MarkPendingDeletion - Đánh dấu tài khoản chờ xóa vĩnh viễn
+func (r *userRepository) MarkPendingDeletion(userID string) error {
+	now := time.Now()
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND status <> ?", userID, model.UserStatusPendingDeletion).
+		Updates(map[string]interface{}{
+			"status":                model.UserStatusPendingDeletion,
+			"deletion_requested_at": now,
+			"updated_at":            now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// RestorePendingDeletion - Hủy yêu cầu xóa tài khoản; trả về false nếu tài khoản không ở trạng thái chờ xóa
+func (r *userRepository) RestorePendingDeletion(userID string) (bool, error) {
+	result := r.db.Model(&model.User{}).
+		Where("id = ? AND status = ?", userID, model.UserStatusPendingDeletion).
+		Updates(map[string]interface{}{
+			"status":                model.UserStatusActive,
+			"deletion_requested_at": nil,
+			"updated_at":            time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListPendingDeletion - Tài khoản chờ xóa đã yêu cầu trước thời điểm cho trước (đã hết thời gian ân hạn),
+// bỏ qua các tài khoản trong excludeIDs (đã xóa lỗi trong lần chạy hiện tại)
+func (r *userRepository) ListPendingDeletion(requestedBefore time.Time, excludeIDs []string, limit int) ([]model.User, error) {
+	var users []model.User
+	query := r.db.Where("status = ? AND deletion_requested_at < ?", model.UserStatusPendingDeletion, requestedBefore)
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+	err := query.Order("deletion_requested_at").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// likeEscaper thoát các ký tự đặc biệt của LIKE trong chuỗi tìm kiếm
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// encodeCursor - cursor phân trang theo (created_at, id), dạng "<created_at>|<id>" mã hóa base64url, client coi như chuỗi mờ
+func encodeCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return t, id, nil
+}
+
 func (r *userRepository) Delete(userID string) error {
 	return r.db.Where("id = ?", userID).Delete(&model.User{}).Error
 }
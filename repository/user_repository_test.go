@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		id        string
+	}{
+		{"utc with nanoseconds", time.Date(2024, 3, 1, 10, 20, 30, 123456789, time.UTC), "8c6f3c1e-5b0a-4d7e-9a61-1f2b3c4d5e6f"},
+		{"non-utc location", time.Date(2024, 3, 1, 17, 20, 30, 0, time.FixedZone("ICT", 7*3600)), "user-1"},
+		{"id containing separator", time.Date(2023, 12, 31, 23, 59, 59, 1, time.UTC), "a|b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := encodeCursor(tt.createdAt, tt.id)
+			createdAt, id, err := decodeCursor(cursor)
+			if err != nil {
+				t.Fatalf("decodeCursor(%q) error = %v", cursor, err)
+			}
+			if !createdAt.Equal(tt.createdAt) || id != tt.id {
+				t.Errorf("decodeCursor() = (%v, %q), want (%v, %q)", createdAt, id, tt.createdAt, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("2024-03-01T10:20:30Z|user-1x"))},
+		{"missing separator", encode("2024-03-01T10:20:30Z")},
+		{"missing id", encode("2024-03-01T10:20:30Z|")},
+		{"invalid time", encode("yesterday|user-1")},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestLikeEscaper(t *testing.T) {
+	tests := map[string]string{
+		"alice":      "alice",
+		"100%":       `100\%`,
+		"first_name": `first\_name`,
+		`back\slash`: `back\\slash`,
+		`%_\`:        `\%\_\\`,
+	}
+	for input, want := range tests {
+		if got := likeEscaper.Replace(input); got != want {
+			t.Errorf("likeEscaper.Replace(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
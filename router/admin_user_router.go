@@ -0,0 +1,26 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+	"base-app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAdminUserRoutes - các route quản trị người dùng (xem cần users:read, thay đổi cần users:write,
+// đăng nhập dưới danh nghĩa người dùng cần users:impersonate). Xóa vĩnh viễn và impersonation cần phiên đăng nhập thật
+// của quản trị viên và xác thực gần đây (step-up, xem RequireRecentAuth).
+// Middleware gắn theo từng route vì /api/v1/admin/users còn có route gán vai trò của SetupRoleRoutes.
+func SetupAdminUserRoutes(app *fiber.App, adminUserController *controller.AdminUserController, authRequired fiber.Handler, recentAuth fiber.Handler, authz *middleware.Authorizer) {
+	readUsers := authz.RequirePermission(model.PermissionUsersRead)
+	writeUsers := authz.RequirePermission(model.PermissionUsersWrite)
+	users := app.Group("/api/v1/admin/users")
+
+	users.Get("/", authRequired, readUsers, adminUserController.List)
+	users.Get("/:id", authRequired, readUsers, adminUserController.Get)
+	users.Post("/:id/disable", authRequired, writeUsers, adminUserController.Disable)
+	users.Post("/:id/enable", authRequired, writeUsers, adminUserController.Enable)
+	users.Delete("/:id", authRequired, middleware.RequireSession(), recentAuth, writeUsers, adminUserController.Delete)
+	users.Post("/:id/impersonate", authRequired, middleware.RequireSession(), recentAuth, authz.RequirePermission(model.PermissionUsersImpersonate), adminUserController.Impersonate)
+}
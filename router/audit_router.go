@@ -0,0 +1,17 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+	"base-app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAuditRoutes - truy vấn audit log (quản trị, cần audit:read) và hoạt động bảo mật của chính người dùng
+func SetupAuditRoutes(app *fiber.App, auditController *controller.AuditController, authRequired fiber.Handler, authz *middleware.Authorizer) {
+	api := app.Group("/api/v1")
+
+	api.Get("/admin/audit-events", authRequired, authz.RequirePermission(model.PermissionAuditRead), auditController.List)
+	api.Get("/user/security-activity", authRequired, auditController.MySecurityActivity)
+}
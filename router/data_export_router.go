@@ -0,0 +1,19 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupDataExportRoutes - các route export dữ liệu người dùng
+func SetupDataExportRoutes(app *fiber.App, dataExportController *controller.DataExportController, authRequired fiber.Handler) {
+	api := app.Group("/api/v1")
+
+	api.Post("/user/export", authRequired, middleware.RequireSession(), dataExportController.Request)
+	api.Get("/user/export/:id", authRequired, middleware.RequireSession(), dataExportController.Get)
+
+	// Link tải trong email: xác thực bằng token đã ký, không cần JWT
+	api.Get("/exports/download", dataExportController.Download)
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupDeviceRoutes - các route thiết bị đã biết và link "không phải tôi" trong email thiết bị mới
+func SetupDeviceRoutes(app *fiber.App, deviceController *controller.DeviceController, authRequired fiber.Handler) {
+	api := app.Group("/api/v1")
+
+	api.Get("/user/devices", authRequired, deviceController.List)
+	api.Delete("/user/devices/:id", authRequired, middleware.RequireSession(), deviceController.Forget)
+
+	// Link trong email: xác thực bằng token đã ký, không cần JWT
+	api.Post("/devices/report", deviceController.ReportNotMe)
+}
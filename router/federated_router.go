@@ -0,0 +1,23 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupFederatedRoutes - các route đăng nhập qua identity provider bên ngoài (OIDC)
+func SetupFederatedRoutes(app *fiber.App, federatedController *controller.FederatedController, authRequired fiber.Handler) {
+	api := app.Group("/api/v1")
+
+	oidc := api.Group("/auth/oidc")
+	oidc.Get("/providers", federatedController.ListProviders)
+	oidc.Get("/:provider/login", federatedController.Login)
+	oidc.Get("/:provider/callback", middleware.DeviceCookie(), federatedController.Callback)
+
+	// Liên kết tài khoản IdP vào tài khoản đang đăng nhập
+	api.Get("/user/identities", authRequired, federatedController.ListIdentities)
+	api.Post("/user/identities/:provider", authRequired, middleware.RequireSession(), federatedController.LinkIdentity)
+	api.Delete("/user/identities/:id", authRequired, middleware.RequireSession(), federatedController.UnlinkIdentity)
+}
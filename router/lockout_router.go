@@ -0,0 +1,18 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+	"base-app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupLockoutRoutes - các route quản trị khóa đăng nhập (cần quyền lockouts:manage)
+func SetupLockoutRoutes(app *fiber.App, lockoutController *controller.LockoutController, authRequired fiber.Handler, authz *middleware.Authorizer) {
+	lockouts := app.Group("/api/v1/admin/lockouts", authRequired, authz.RequirePermission(model.PermissionLockoutsManage))
+
+	lockouts.Get("/", lockoutController.List)
+	lockouts.Get("/events", lockoutController.ListEvents)
+	lockouts.Delete("/:kind/:value", lockoutController.Clear)
+}
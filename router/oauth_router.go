@@ -0,0 +1,37 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+	"base-app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupOAuthRoutes - các route của authorization server OAuth 2.0 / OpenID Connect
+func SetupOAuthRoutes(app *fiber.App, oauthController *controller.OAuthController, authRequired fiber.Handler, authz *middleware.Authorizer, limiter *middleware.RateLimiter) {
+	// Endpoint giao thức, dùng bởi client bên thứ ba
+	app.Get("/.well-known/openid-configuration", oauthController.Discovery)
+
+	oauth := app.Group("/oauth")
+	oauth.Get("/authorize", oauthController.Authorize)
+	oauth.Post("/token", limiter.Policy("oauth_token"), oauthController.Token)
+	oauth.Get("/userinfo", oauthController.UserInfo)
+	oauth.Post("/userinfo", oauthController.UserInfo)
+
+	api := app.Group("/api/v1")
+
+	// Màn hình consent của frontend (người dùng đã đăng nhập)
+	api.Get("/oauth/authorize", authRequired, oauthController.GetConsent)
+	api.Post("/oauth/authorize", authRequired, middleware.RequireSession(), oauthController.DecideConsent)
+
+	// Quản lý client - cần quyền oauth_clients:manage
+	clients := api.Group("/oauth/clients", authRequired, authz.RequirePermission(model.PermissionOAuthClientsManage))
+	clients.Post("/", oauthController.RegisterClient)
+	clients.Get("/", oauthController.ListClients)
+	clients.Delete("/:id", oauthController.DeleteClient)
+
+	// Ứng dụng người dùng đã cấp quyền
+	api.Get("/user/oauth/consents", authRequired, oauthController.ListConsents)
+	api.Delete("/user/oauth/consents/:client_id", authRequired, oauthController.RevokeConsent)
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupOrganizationRoutes - các route tổ chức (multi-tenant) và thành viên
+func SetupOrganizationRoutes(app *fiber.App, organizationController *controller.OrganizationController, invitationController *controller.InvitationController, authRequired fiber.Handler) {
+	api := app.Group("/api/v1")
+
+	// Tổ chức của người dùng
+	api.Get("/user/organizations", authRequired, organizationController.List)
+	api.Post("/user/organizations", authRequired, middleware.RequireSession(), organizationController.Create)
+	api.Post("/user/organizations/:id/switch", authRequired, middleware.RequireSession(), organizationController.Switch)
+
+	// Tổ chức đang hoạt động (claim org_id)
+	org := api.Group("/organization", authRequired, middleware.RequireOrganization())
+	org.Get("/", organizationController.Current)
+	org.Get("/members", organizationController.ListMembers)
+	org.Put("/members/:user_id", organizationController.UpdateMemberRole)
+	org.Delete("/members/:user_id", organizationController.RemoveMember)
+
+	// Lời mời tham gia tổ chức đang hoạt động
+	org.Get("/invitations", invitationController.ListPending)
+	org.Post("/invitations", invitationController.Create)
+	org.Delete("/invitations/:id", invitationController.Revoke)
+	org.Post("/invitations/:id/resend", invitationController.Resend)
+
+	// Người được mời: xem lời mời (công khai) và chấp nhận khi đã đăng nhập;
+	// người chưa có tài khoản gửi invitation_token kèm /auth/register
+	api.Post("/invitations/preview", invitationController.Preview)
+	api.Post("/invitations/accept", authRequired, middleware.RequireSession(), invitationController.Accept)
+}
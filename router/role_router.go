@@ -0,0 +1,25 @@
+package router
+
+import (
+	"base-app/controller"
+	"base-app/middleware"
+	"base-app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupRoleRoutes - các route quản trị vai trò, quyền và gán vai trò cho người dùng
+func SetupRoleRoutes(app *fiber.App, roleController *controller.RoleController, authRequired fiber.Handler, authz *middleware.Authorizer) {
+	manageRoles := authz.RequirePermission(model.PermissionRolesManage)
+	admin := app.Group("/api/v1/admin")
+
+	roles := admin.Group("/roles", authRequired, manageRoles)
+	roles.Get("/", roleController.List)
+	roles.Post("/", roleController.Create)
+	roles.Get("/:name", roleController.Get)
+	roles.Delete("/:name", roleController.Delete)
+	roles.Post("/:name/permissions", roleController.GrantPermission)
+	roles.Delete("/:name/permissions/:permission", roleController.RevokePermission)
+
+	admin.Put("/users/:id/role", authRequired, manageRoles, roleController.AssignRole)
+}
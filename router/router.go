@@ -1,37 +1,59 @@
 package router
 
 import (
-	"base-app/config"
 	"base-app/controller"
+	"base-app/middleware"
 
 	"github.com/gofiber/fiber/v2"
-	jwt "github.com/gofiber/jwt/v3"
 )
 
-func SetupRoutes(app *fiber.App, userController *controller.UserController) {
+func SetupRoutes(app *fiber.App, userController *controller.UserController, mfaController *controller.MFAController, verificationController *controller.VerificationController, passwordResetController *controller.PasswordResetController, jwksController *controller.JWKSController, apiKeyController *controller.APIKeyController, sessionController *controller.SessionController, authRequired fiber.Handler, recentAuth fiber.Handler, limiter *middleware.RateLimiter) {
+	// Khóa công khai cho các service khác xác thực token
+	app.Get("/.well-known/jwks.json", jwksController.GetJWKS)
+
 	// Group API
 	api := app.Group("/api/v1")
 
 	// Auth routes
 	auth := api.Group("/auth")
-	auth.Post("/register", userController.Register)
-	auth.Post("/login", userController.Login)
-
-	// User routes - require JWT
+	auth.Post("/register", limiter.Policy("register"), userController.Register)
+	auth.Post("/login", limiter.Policy("login"), middleware.DeviceCookie(), userController.Login)
+	auth.Post("/refresh", limiter.Policy("refresh"), userController.RefreshToken)
+	auth.Post("/mfa/verify", limiter.Policy("mfa_verify"), middleware.DeviceCookie(), userController.VerifyMFA)
+	auth.Post("/verify-email", verificationController.VerifyEmail)
+	auth.Post("/resend-verification", limiter.Policy("resend_verification"), verificationController.ResendVerification)
+	auth.Post("/forgot-password", limiter.Policy("forgot_password"), passwordResetController.ForgotPassword)
+	auth.Post("/reset-password", passwordResetController.ResetPassword)
+	auth.Post("/logout", authRequired, userController.Logout)
+	auth.Post("/logout-all", authRequired, middleware.RequireSession(), userController.LogoutAll)
+	auth.Post("/reauthenticate", authRequired, middleware.RequireSession(), userController.Reauthenticate)
+
+	// User routes - require JWT, giới hạn tần suất theo người dùng / API key
 	user := api.Group("/user")
-
-	// JWT middleware
-	user.Use(jwt.New(jwt.Config{
-		SigningKey:   []byte(config.LoadConfig().JWTSecret),
-		ErrorHandler: jwtErrorHandler,
-	}))
+	user.Use(authRequired, limiter.Policy("user"))
 
 	user.Get("/profile", userController.GetProfile)
-}
-
-// jwtErrorHandler - xử lý lỗi nếu token không hợp lệ
-func jwtErrorHandler(c *fiber.Ctx, err error) error {
-	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		"error": "Unauthorized or invalid token",
-	})
+	user.Put("/profile", middleware.ForbidImpersonation(), userController.UpdateProfile)
+	// Các thao tác nhạy cảm chỉ dùng được với phiên đăng nhập, không dùng được API key
+	sessionOnly := middleware.RequireSession()
+
+	// Đổi mật khẩu, xóa tài khoản còn cần xác thực gần đây (step-up, xem RequireRecentAuth); đổi email kiểm tra trong service
+	user.Put("/password", sessionOnly, recentAuth, userController.ChangePassword)
+	user.Delete("/account", sessionOnly, recentAuth, userController.DeleteAccount)
+
+	// Xác thực hai lớp (TOTP + mã khôi phục)
+	user.Post("/mfa/totp/enroll", sessionOnly, mfaController.EnrollTOTP)
+	user.Post("/mfa/totp/confirm", sessionOnly, mfaController.ConfirmTOTP)
+	user.Post("/mfa/totp/disable", sessionOnly, mfaController.DisableTOTP)
+	user.Get("/mfa/recovery-codes", mfaController.GetRecoveryCodesStatus)
+	user.Post("/mfa/recovery-codes", sessionOnly, mfaController.RegenerateRecoveryCodes)
+
+	// Phiên đăng nhập trên các thiết bị
+	user.Get("/sessions", sessionController.List)
+	user.Delete("/sessions/:id", sessionOnly, sessionController.Revoke)
+
+	// API key (personal access token) cho script / CI
+	user.Get("/api-keys", sessionOnly, apiKeyController.List)
+	user.Post("/api-keys", sessionOnly, apiKeyController.Create)
+	user.Delete("/api-keys/:id", sessionOnly, apiKeyController.Revoke)
 }
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/repository"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// accountPurgeBatchSize - số tài khoản xóa vĩnh viễn trong mỗi lần truy vấn
+const accountPurgeBatchSize = 100
+
+// AccountDeletionService xóa vĩnh viễn tài khoản: admin xóa ngay, hoặc chạy nền xóa
+// các tài khoản người dùng tự xóa đã hết thời gian ân hạn
+type AccountDeletionService struct {
+	repo    repository.UserRepository
+	users   *UserService
+	apiKeys repository.APIKeyRepository
+	oauth   repository.OAuthRepository
+	devices repository.DeviceRepository
+	audit   *AuditService
+	cfg     config.Config
+}
+
+func NewAccountDeletionService(repo repository.UserRepository, users *UserService, apiKeys repository.APIKeyRepository, oauth repository.OAuthRepository, devices repository.DeviceRepository, audit *AuditService, cfg config.Config) *AccountDeletionService {
+	return &AccountDeletionService{
+		repo:    repo,
+		users:   users,
+		apiKeys: apiKeys,
+		oauth:   oauth,
+		devices: devices,
+		audit:   audit,
+		cfg:     cfg,
+	}
+}
+
+// Delete - Xóa vĩnh viễn tài khoản cùng dữ liệu liên quan trong Postgres và Redis
+func (s *AccountDeletionService) Delete(ctx context.Context, userID string) error {
+	if err := s.users.ForceDeletedUserAccount(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.apiKeys.DeleteByUser(userID); err != nil {
+		fmt.Printf("warning: failed to delete api keys: %v\n", err)
+	}
+	if err := s.oauth.DeleteConsentsByUser(userID); err != nil {
+		fmt.Printf("warning: failed to delete oauth consents: %v\n", err)
+	}
+	if err := s.devices.DeleteByUser(userID); err != nil {
+		fmt.Printf("warning: failed to delete known devices: %v\n", err)
+	}
+	return nil
+}
+
+// PurgeExpired - Xóa vĩnh viễn các tài khoản chờ xóa đã hết thời gian ân hạn, trả về số tài khoản đã xóa.
+// Một tài khoản xóa lỗi không chặn các tài khoản sau; tài khoản đó được thử lại ở lần chạy sau.
+func (s *AccountDeletionService) PurgeExpired(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.cfg.AccountDeletionGracePeriod)
+	purged := 0
+	var failed []string
+
+	for {
+		users, err := s.repo.ListPendingDeletion(cutoff, failed, accountPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, user := range users {
+			// Audit log chỉ thêm, không xóa được: không lưu email của tài khoản đã xóa vĩnh viễn
+			err := s.Delete(ctx, user.ID)
+			s.audit.Record(ctx, model.AuditActionAccountPurge, "", user.ID, err, model.AuditMetadata{
+				"deletion_requested_at": user.DeletionRequestedAt,
+			})
+			if err != nil {
+				log.Printf("warning: failed to purge user %s: %v", user.ID, err)
+				failed = append(failed, user.ID)
+				continue
+			}
+			purged++
+		}
+
+		if len(users) < accountPurgeBatchSize {
+			break
+		}
+	}
+
+	if len(failed) > 0 {
+		return purged, fmt.Errorf("failed to purge %d account(s)", len(failed))
+	}
+	return purged, nil
+}
+
+// Start chạy nền việc xóa vĩnh viễn tài khoản theo chu kỳ AccountPurgeInterval
+func (s *AccountDeletionService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.cfg.AccountPurgeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purged, err := s.PurgeExpired(context.Background())
+			if err != nil {
+				log.Printf("warning: failed to purge deleted accounts: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("🗑️  Purged %d deleted account(s)", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"base-app/model"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrCannotDisableSelf = errors.New("you cannot disable your own account")
+	ErrCannotDeleteSelf  = errors.New("you cannot delete your own account from the admin API")
+
+	ErrCannotImpersonateSelf       = errors.New("you cannot impersonate yourself")
+	ErrCannotImpersonatePrivileged = errors.New("users who can impersonate others cannot be impersonated")
+	ErrCannotManagePrivileged      = errors.New("users who can manage other users cannot be disabled or deleted, change their role first")
+)
+
+// AdminUser là thông tin người dùng trả về cho trang quản trị (không có mật khẩu / secret)
+type AdminUser struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DisabledAt      *time.Time `json:"disabled_at"`
+
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+}
+
+// AdminUserDetail là chi tiết một người dùng kèm các tổ chức người đó tham gia
+type AdminUserDetail struct {
+	AdminUser
+	Organizations []model.OrganizationMembership `json:"organizations"`
+}
+
+// AdminUserPage là một trang kết quả; next_cursor rỗng khi đã hết dữ liệu
+type AdminUserPage struct {
+	Users      []AdminUser `json:"users"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// ImpersonationResult là token đăng nhập dưới danh nghĩa người dùng cùng người dùng bị impersonate
+type ImpersonationResult struct {
+	*AuthTokens
+	User AdminUser `json:"user"`
+}
+
+type AdminUserService struct {
+	repo     repository.UserRepository
+	redis    repository.RedisRepository
+	orgs     repository.OrganizationRepository
+	users    *UserService
+	roles    *RoleService
+	deletion *AccountDeletionService
+	audit    *AuditService
+}
+
+func NewAdminUserService(repo repository.UserRepository, redisRepo repository.RedisRepository, orgs repository.OrganizationRepository, users *UserService, roles *RoleService, deletion *AccountDeletionService, audit *AuditService) *AdminUserService {
+	return &AdminUserService{repo: repo, redis: redisRepo, orgs: orgs, users: users, roles: roles, deletion: deletion, audit: audit}
+}
+
+// List - Tìm kiếm và lọc người dùng, phân trang theo cursor
+func (s *AdminUserService) List(filter repository.UserFilter) (*AdminUserPage, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	page, err := s.repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]AdminUser, 0, len(page.Users))
+	for i := range page.Users {
+		users = append(users, newAdminUser(&page.Users[i]))
+	}
+	return &AdminUserPage{Users: users, NextCursor: page.NextCursor}, nil
+}
+
+// Get - Chi tiết một người dùng
+func (s *AdminUserService) Get(userID string) (*AdminUserDetail, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	organizations, err := s.orgs.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load organizations: %v", err)
+	}
+	return &AdminUserDetail{AdminUser: newAdminUser(user), Organizations: organizations}, nil
+}
+
+// Disable - Vô hiệu hóa tài khoản và đăng xuất người dùng khỏi mọi phiên
+func (s *AdminUserService) Disable(ctx context.Context, actorID, userID string) (*AdminUser, error) {
+	if actorID == userID {
+		return nil, ErrCannotDisableSelf
+	}
+	if err := s.ensureManageable(ctx, userID); err != nil {
+		s.audit.Record(ctx, model.AuditActionUserDisable, actorID, userID, err, nil)
+		return nil, err
+	}
+
+	user, err := s.setStatus(ctx, userID, model.UserStatusDisabled)
+	s.audit.Record(ctx, model.AuditActionUserDisable, actorID, userID, err, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.redis.RevokeAllUserTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke tokens in Redis: %v\n", err)
+	}
+	if err := s.redis.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke refresh tokens in Redis: %v\n", err)
+	}
+	if err := s.redis.RevokeOAuthAccessTokens(ctx, userID, ""); err != nil {
+		fmt.Printf("warning: failed to revoke oauth access tokens in Redis: %v\n", err)
+	}
+	return user, nil
+}
+
+// Enable - Kích hoạt lại tài khoản đã bị vô hiệu hóa
+func (s *AdminUserService) Enable(ctx context.Context, actorID, userID string) (*AdminUser, error) {
+	user, err := s.setStatus(ctx, userID, model.UserStatusActive)
+	s.audit.Record(ctx, model.AuditActionUserEnable, actorID, userID, err, nil)
+	return user, err
+}
+
+// Delete - Xóa vĩnh viễn tài khoản ngay lập tức, không qua thời gian ân hạn
+func (s *AdminUserService) Delete(ctx context.Context, actorID, userID string) error {
+	if actorID == userID {
+		return ErrCannotDeleteSelf
+	}
+	if err := s.ensureManageable(ctx, userID); err != nil {
+		s.audit.Record(ctx, model.AuditActionUserDelete, actorID, userID, err, nil)
+		return err
+	}
+
+	// Không lưu email: audit log còn giữ lại sau khi tài khoản bị xóa vĩnh viễn
+	err := s.deletion.Delete(ctx, userID)
+	s.audit.Record(ctx, model.AuditActionUserDelete, actorID, userID, err, nil)
+	return err
+}
+
+// Impersonate - Cấp token ngắn hạn để quản trị viên thấy đúng những gì người dùng thấy.
+// Không impersonate được chính mình, tài khoản không hoạt động và người dùng có quyền impersonate (quản trị viên khác).
+func (s *AdminUserService) Impersonate(ctx context.Context, actorID, userID string) (result *ImpersonationResult, err error) {
+	defer func() {
+		metadata := model.AuditMetadata{}
+		if result != nil {
+			metadata["expires_in"] = result.ExpiresIn
+		}
+		s.audit.Record(ctx, model.AuditActionUserImpersonate, actorID, userID, err, metadata)
+	}()
+
+	if actorID == userID {
+		return nil, ErrCannotImpersonateSelf
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	switch user.Status {
+	case model.UserStatusDisabled:
+		return nil, ErrAccountDisabled
+	case model.UserStatusPendingDeletion:
+		return nil, ErrAccountPendingDeletion
+	}
+
+	privileged, err := s.roles.HasPermission(ctx, user.Role, model.PermissionUsersImpersonate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %v", err)
+	}
+	if privileged {
+		return nil, ErrCannotImpersonatePrivileged
+	}
+
+	tokens, err := s.users.IssueImpersonationToken(ctx, actorID, user)
+	if err != nil {
+		return nil, err
+	}
+	return &ImpersonationResult{AuthTokens: tokens, User: newAdminUser(user)}, nil
+}
+
+// ensureManageable - Không vô hiệu hóa / xóa được người dùng có quyền quản lý người dùng (quản trị viên khác):
+// một quản trị viên bị lộ tài khoản không khóa được các quản trị viên còn lại. Muốn làm vậy phải đổi role trước.
+func (s *AdminUserService) ensureManageable(ctx context.Context, userID string) error {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	privileged, err := s.roles.HasPermission(ctx, user.Role, model.PermissionUsersWrite)
+	if err != nil {
+		return fmt.Errorf("failed to check permissions: %v", err)
+	}
+	if privileged {
+		return ErrCannotManagePrivileged
+	}
+	return nil
+}
+
+// setStatus - Cập nhật trạng thái tài khoản và xóa profile đã cache
+func (s *AdminUserService) setStatus(ctx context.Context, userID, status string) (*AdminUser, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	// Tài khoản chờ xóa chỉ được khôi phục bởi chính người dùng (đăng nhập lại) hoặc bị xóa hẳn
+	if user.Status == model.UserStatusPendingDeletion {
+		return nil, ErrAccountPendingDeletion
+	}
+	if err := s.repo.UpdateStatus(userID, status); err != nil {
+		return nil, fmt.Errorf("failed to update user status: %v", err)
+	}
+	user.Status = status
+	user.DisabledAt = nil
+	if status == model.UserStatusDisabled {
+		now := time.Now()
+		user.DisabledAt = &now
+	}
+
+	if err := s.redis.DeleteUserProfile(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to clear cached profile: %v\n", err)
+	}
+
+	result := newAdminUser(user)
+	return &result, nil
+}
+
+func newAdminUser(user *model.User) AdminUser {
+	return AdminUser{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		Role:            user.Role,
+		Status:          user.Status,
+		EmailVerified:   user.EmailVerified,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		MFAEnabled:      user.MFAEnabled,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		DisabledAt:      user.DisabledAt,
+
+		DeletionRequestedAt: user.DeletionRequestedAt,
+	}
+}
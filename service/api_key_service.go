@@ -0,0 +1,190 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/token"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAPIKeyTTL       = 90 * 24 * time.Hour
+	maxAPIKeyTTL           = 365 * 24 * time.Hour
+	maxActiveAPIKeys       = 25
+	apiKeyLastUsedInterval = time.Minute // ghi last_used_at tối đa mỗi phút một lần
+	apiKeyDisplayPrefixLen = len(model.APIKeyPrefix) + 8
+)
+
+var apiKeyScopes = []string{model.APIKeyScopeRead, model.APIKeyScopeWrite, model.APIKeyScopeAdmin}
+
+// ErrInvalidAPIKey - API key không tồn tại, đã bị thu hồi hoặc đã hết hạn
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// CreateAPIKeyInput là dữ liệu tạo API key mới
+type CreateAPIKeyInput struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+type APIKeyService struct {
+	keys  repository.APIKeyRepository
+	repo  repository.UserRepository
+	roles *RoleService
+	audit *AuditService
+	cfg   config.Config
+}
+
+func NewAPIKeyService(keys repository.APIKeyRepository, repo repository.UserRepository, roles *RoleService, audit *AuditService, cfg config.Config) *APIKeyService {
+	return &APIKeyService{
+		keys:  keys,
+		repo:  repo,
+		roles: roles,
+		audit: audit,
+		cfg:   cfg,
+	}
+}
+
+// Create - Tạo API key mới, trả về key gốc (chỉ hiển thị một lần)
+func (s *APIKeyService) Create(ctx context.Context, userID string, input CreateAPIKeyInput) (key *model.APIKey, plaintext string, err error) {
+	name := strings.TrimSpace(input.Name)
+	defer func() {
+		metadata := model.AuditMetadata{"name": name, "scopes": input.Scopes}
+		if key != nil {
+			metadata["api_key_id"] = key.ID
+		}
+		s.audit.Record(ctx, model.AuditActionAPIKeyCreate, userID, userID, err, metadata)
+	}()
+
+	if name == "" {
+		return nil, "", errors.New("name is required")
+	}
+
+	if len(input.Scopes) == 0 {
+		input.Scopes = []string{model.APIKeyScopeRead}
+	}
+	for _, scope := range input.Scopes {
+		if !containsString(apiKeyScopes, scope) {
+			return nil, "", fmt.Errorf("unsupported scope %q", scope)
+		}
+	}
+
+	ttl := defaultAPIKeyTTL
+	if input.ExpiresInDays != 0 {
+		ttl = time.Duration(input.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl <= 0 || ttl > maxAPIKeyTTL {
+		return nil, "", fmt.Errorf("expires_in_days must be between 1 and %d", int(maxAPIKeyTTL.Hours()/24))
+	}
+
+	active, err := s.keys.CountActive(userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to count api keys: %v", err)
+	}
+	if active >= maxActiveAPIKeys {
+		return nil, "", fmt.Errorf("you can have at most %d active api keys", maxActiveAPIKeys)
+	}
+
+	secret, err := generateRandomToken(32)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not generate api key: %v", err)
+	}
+	plaintext = model.APIKeyPrefix + secret
+
+	key = &model.APIKey{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plaintext[:apiKeyDisplayPrefixLen],
+		KeyHash:   hashToken(plaintext),
+		Scopes:    strings.Join(mergeScopes(nil, input.Scopes), " "),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.keys.Create(key); err != nil {
+		return nil, "", fmt.Errorf("failed to create api key: %v", err)
+	}
+	return key, plaintext, nil
+}
+
+// List - Danh sách API key của người dùng (không bao gồm key gốc)
+func (s *APIKeyService) List(userID string) ([]model.APIKey, error) {
+	return s.keys.ListByUser(userID)
+}
+
+// ExportUserData - Section "api_keys" của file export (chỉ metadata, không có key)
+func (s *APIKeyService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.keys.ListByUser(userID)
+}
+
+// Revoke - Thu hồi API key, có hiệu lực ngay với request tiếp theo
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID string) error {
+	err := s.keys.Revoke(userID, keyID)
+	s.audit.Record(ctx, model.AuditActionAPIKeyRevoke, userID, userID, err, model.AuditMetadata{"api_key_id": keyID})
+	return err
+}
+
+// VerifyAPIKey - Xác thực API key và trả về claims giống access token của chủ sở hữu.
+// Key không có scope admin thì không được dùng quyền quản trị của vai trò (kể cả vai trò tự tạo).
+func (s *APIKeyService) VerifyAPIKey(ctx context.Context, plaintext string) (*token.Claims, error) {
+	if !strings.HasPrefix(plaintext, model.APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.keys.FindByHash(hashToken(plaintext))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.repo.FindByID(key.UserID)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if user.Status == model.UserStatusDisabled {
+		return nil, ErrAccountDisabled
+	}
+	if user.Status == model.UserStatusPendingDeletion {
+		return nil, ErrInvalidAPIKey
+	}
+
+	scopes := key.ScopeList()
+	role := user.Role
+	if !containsString(scopes, model.APIKeyScopeAdmin) {
+		privileged, err := s.roles.HasAnyPermission(ctx, role)
+		if err != nil {
+			// Không đọc được quyền của vai trò: coi như có quyền quản trị để không cấp nhầm
+			fmt.Printf("warning: failed to check role permissions for api key: %v\n", err)
+			privileged = true
+		}
+		if privileged {
+			role = model.RoleUser
+		}
+	}
+
+	if err := s.keys.TouchLastUsed(key.ID, now, apiKeyLastUsedInterval); err != nil {
+		fmt.Printf("warning: failed to update api key last used time: %v\n", err)
+	}
+
+	return &token.Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(key.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(key.ExpiresAt),
+		},
+		APIKeyID: key.ID,
+		Scopes:   scopes,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"base-app/model"
+	"base-app/pkg/clientinfo"
+	"base-app/repository"
+	"context"
+	"fmt"
+)
+
+// loginHistoryActions - các sự kiện audit tạo nên lịch sử đăng nhập của người dùng
+var loginHistoryActions = []string{
+	model.AuditActionLogin,
+	model.AuditActionMFAVerify,
+	model.AuditActionTokenRefresh,
+	model.AuditActionReauthenticate,
+	model.AuditActionLogout,
+	model.AuditActionLogoutAll,
+}
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record - Ghi một sự kiện vào audit log. err khác nil nghĩa là hành động thất bại (lý do được lưu trong metadata).
+// IP / User-Agent và quản trị viên đang đăng nhập dưới danh nghĩa người dùng (nếu có) lấy từ context của request. Lỗi ghi log chỉ được cảnh báo, không làm hỏng thao tác chính.
+func (s *AuditService) Record(ctx context.Context, action, actorID, targetID string, err error, metadata model.AuditMetadata) {
+	info := clientinfo.From(ctx)
+	event := &model.AuditEvent{
+		ActorID:   actorID,
+		TargetID:  targetID,
+		Action:    action,
+		Result:    model.AuditResultSuccess,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		Metadata:  metadata,
+
+		ImpersonatorID: info.ImpersonatorID,
+	}
+	if err != nil {
+		event.Result = model.AuditResultFailure
+		if event.Metadata == nil {
+			event.Metadata = model.AuditMetadata{}
+		}
+		event.Metadata["error"] = err.Error()
+	}
+
+	if err := s.repo.Create(event); err != nil {
+		fmt.Printf("warning: failed to write audit event %s: %v\n", action, err)
+	}
+}
+
+// List - Truy vấn audit log cho trang quản trị
+func (s *AuditService) List(filter repository.AuditFilter) (*repository.AuditPage, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	return s.repo.List(filter)
+}
+
+// ListForUser - Hoạt động bảo mật của chính người dùng (sự kiện người dùng là actor hoặc target)
+func (s *AuditService) ListForUser(userID, cursor string, limit int) (*repository.AuditPage, error) {
+	return s.List(repository.AuditFilter{UserID: userID, Cursor: cursor, Limit: limit})
+}
+
+// ExportUserData - Section "audit_events" của file export dữ liệu người dùng
+func (s *AuditService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// ExportLoginHistory - Section "login_history" của file export: đăng nhập (thành công và thất bại), xác thực MFA,
+// refresh token và đăng xuất, lấy từ audit log
+func (s *AuditService) ExportLoginHistory(ctx context.Context, userID string) (interface{}, error) {
+	return s.repo.ListByUserActions(userID, loginHistoryActions)
+}
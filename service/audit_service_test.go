@@ -0,0 +1,45 @@
+package service
+
+import (
+	"base-app/model"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExportLoginHistory(t *testing.T) {
+	repo := &fakeAudit{}
+	s := NewAuditService(repo)
+	ctx := context.Background()
+
+	s.Record(ctx, model.AuditActionLogin, "user-1", "user-1", errors.New("invalid credentials"), nil)
+	s.Record(ctx, model.AuditActionLogin, "user-1", "user-1", nil, model.AuditMetadata{"method": "password"})
+	s.Record(ctx, model.AuditActionPasswordChange, "user-1", "user-1", nil, nil)
+	s.Record(ctx, model.AuditActionTokenRefresh, "", "user-1", nil, nil)
+	s.Record(ctx, model.AuditActionLogin, "user-2", "user-2", nil, nil)
+	s.Record(ctx, model.AuditActionLogout, "user-1", "user-1", nil, nil)
+
+	data, err := s.ExportLoginHistory(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("ExportLoginHistory() error = %v", err)
+	}
+	events, ok := data.([]model.AuditEvent)
+	if !ok {
+		t.Fatalf("ExportLoginHistory() = %T, want []model.AuditEvent", data)
+	}
+
+	var got []string
+	for _, event := range events {
+		got = append(got, event.Action+":"+event.Result)
+	}
+	want := []string{
+		model.AuditActionLogout + ":" + model.AuditResultSuccess,
+		model.AuditActionTokenRefresh + ":" + model.AuditResultSuccess,
+		model.AuditActionLogin + ":" + model.AuditResultSuccess,
+		model.AuditActionLogin + ":" + model.AuditResultFailure,
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("login history = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"archive/zip"
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/mailer"
+	"base-app/pkg/signer"
+	"base-app/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	purposeDataExport = "data_export"
+
+	// dataExportBuildTimeout - thời gian tối đa để gom dữ liệu và tạo file ZIP
+	dataExportBuildTimeout = 5 * time.Minute
+)
+
+var (
+	ErrDataExportNotFound = errors.New("data export not found")
+	ErrDataExportNotReady = errors.New("data export is not ready")
+	ErrInvalidExportLink  = errors.New("invalid or expired download link")
+)
+
+// ExportContributor là một nguồn dữ liệu của người dùng (một subsystem).
+// Mỗi contributor được đăng ký với một tên section và trở thành file <section>.json trong archive.
+type ExportContributor interface {
+	ExportUserData(ctx context.Context, userID string) (interface{}, error)
+}
+
+// ExportContributorFunc cho phép dùng một hàm làm ExportContributor
+type ExportContributorFunc func(ctx context.Context, userID string) (interface{}, error)
+
+func (f ExportContributorFunc) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return f(ctx, userID)
+}
+
+// DataExportStatus là trạng thái export trả về cho người dùng, kèm link tải khi đã sẵn sàng
+type DataExportStatus struct {
+	*model.DataExport
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+type exportSection struct {
+	name        string
+	contributor ExportContributor
+}
+
+type DataExportService struct {
+	repo     repository.UserRepository
+	redis    repository.RedisRepository
+	mailer   mailer.Mailer
+	signer   *signer.Signer
+	cfg      config.Config
+	sections []exportSection
+}
+
+func NewDataExportService(repo repository.UserRepository, redisRepo repository.RedisRepository, mail mailer.Mailer, sign *signer.Signer, cfg config.Config) *DataExportService {
+	return &DataExportService{
+		repo:   repo,
+		redis:  redisRepo,
+		mailer: mail,
+		signer: sign,
+		cfg:    cfg,
+	}
+}
+
+// Register - Đăng ký một section của archive; gọi lúc khởi động, trước khi nhận request
+func (s *DataExportService) Register(section string, contributor ExportContributor) {
+	s.sections = append(s.sections, exportSection{name: section, contributor: contributor})
+}
+
+// Request - Tạo yêu cầu export và gom dữ liệu ở nền.
+// Nếu người dùng đang có một export chưa xong thì trả về export đó thay vì tạo thêm.
+func (s *DataExportService) Request(ctx context.Context, userID string) (*DataExportStatus, error) {
+	latest, err := s.redis.GetLatestDataExport(ctx, userID)
+	if err == nil && latest.Status == model.DataExportPending {
+		return &DataExportStatus{DataExport: latest}, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrDataExportNotFound) {
+		return nil, fmt.Errorf("failed to read data export from Redis: %v", err)
+	}
+
+	now := time.Now()
+	export := &model.DataExport{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    model.DataExportPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.DataExportTTL),
+	}
+	if err := s.redis.SaveDataExport(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to store data export in Redis: %v", err)
+	}
+
+	go s.build(export)
+	return &DataExportStatus{DataExport: export}, nil
+}
+
+// Get - Trạng thái một export của người dùng
+func (s *DataExportService) Get(ctx context.Context, userID, exportID string) (*DataExportStatus, error) {
+	export, err := s.redis.GetDataExport(ctx, exportID)
+	if err != nil || export.UserID != userID {
+		return nil, ErrDataExportNotFound
+	}
+
+	status := &DataExportStatus{DataExport: export}
+	if export.Status == model.DataExportReady {
+		link, err := s.downloadURL(export)
+		if err != nil {
+			return nil, err
+		}
+		status.DownloadURL = link
+	}
+	return status, nil
+}
+
+// Download - Đọc file ZIP bằng token trong link tải đã ký; trả về tên file và nội dung
+func (s *DataExportService) Download(ctx context.Context, token string) (string, []byte, error) {
+	payload, err := s.signer.Verify(purposeDataExport, token)
+	if err != nil {
+		return "", nil, ErrInvalidExportLink
+	}
+
+	export, err := s.redis.GetDataExport(ctx, payload.Data["export_id"])
+	if err != nil || export.UserID != payload.Subject {
+		return "", nil, ErrInvalidExportLink
+	}
+	if export.Status != model.DataExportReady {
+		return "", nil, ErrDataExportNotReady
+	}
+
+	archive, err := s.redis.GetDataExportArchive(ctx, export.ID)
+	if errors.Is(err, repository.ErrDataExportNotFound) {
+		return "", nil, ErrInvalidExportLink
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read data export from Redis: %v", err)
+	}
+
+	filename := fmt.Sprintf("data-export-%s.zip", export.CreatedAt.UTC().Format("20060102-150405"))
+	return filename, archive, nil
+}
+
+// build - Gom dữ liệu từ các contributor, tạo file ZIP và gửi link tải qua email
+func (s *DataExportService) build(export *model.DataExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataExportBuildTimeout)
+	defer cancel()
+
+	archive, err := s.archive(ctx, export)
+	if err == nil {
+		err = s.redis.SetDataExportArchive(ctx, export.ID, archive, export.ExpiresAt)
+	}
+
+	now := time.Now()
+	export.CompletedAt = &now
+	export.Status = model.DataExportReady
+	if err != nil {
+		fmt.Printf("warning: data export %s failed: %v\n", export.ID, err)
+		export.Status = model.DataExportFailed
+		export.Error = "could not build the export, please try again later"
+	}
+	if err := s.redis.SaveDataExport(ctx, export); err != nil {
+		fmt.Printf("warning: failed to store data export in Redis: %v\n", err)
+		return
+	}
+
+	if export.Status == model.DataExportReady {
+		if err := s.notify(ctx, export); err != nil {
+			fmt.Printf("warning: failed to send data export email: %v\n", err)
+		}
+	}
+}
+
+// archive - Mỗi section là một file JSON, kèm manifest.json mô tả export
+func (s *DataExportService) archive(ctx context.Context, export *model.DataExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	names := make([]string, 0, len(s.sections))
+	for _, section := range s.sections {
+		data, err := section.contributor.ExportUserData(ctx, export.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %v", section.name, err)
+		}
+		if err := writeJSONFile(zw, section.name+".json", data); err != nil {
+			return nil, err
+		}
+		names = append(names, section.name)
+	}
+
+	manifest := map[string]interface{}{
+		"export_id":    export.ID,
+		"user_id":      export.UserID,
+		"requested_at": export.CreatedAt,
+		"generated_at": time.Now(),
+		"sections":     names,
+	}
+	if err := writeJSONFile(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// notify - Gửi email báo export đã sẵn sàng kèm link tải
+func (s *DataExportService) notify(ctx context.Context, export *model.DataExport) error {
+	user, err := s.repo.FindByID(export.UserID)
+	if err != nil {
+		return err
+	}
+	link, err := s.downloadURL(export)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf("Hi %s,\n\nThe copy of your data you requested is ready. Download it from the link below:\n\n%s\n\nThis link expires at %s.\nIf you did not request this export, please change your password.\n",
+			user.Name, link, export.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+// downloadURL - Link tải đã ký, hết hạn cùng lúc với export
+func (s *DataExportService) downloadURL(export *model.DataExport) (string, error) {
+	token, err := s.signer.Sign(purposeDataExport, export.UserID, map[string]string{"export_id": export.ID}, time.Until(export.ExpiresAt))
+	if err != nil {
+		return "", fmt.Errorf("could not sign download link: %v", err)
+	}
+	return strings.TrimRight(s.cfg.AppBaseURL, "/") + "/api/v1/exports/download?token=" + url.QueryEscape(token), nil
+}
+
+func writeJSONFile(zw *zip.Writer, name string, data interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/clientinfo"
+	"base-app/pkg/mailer"
+	"base-app/pkg/signer"
+	"base-app/pkg/useragent"
+	"base-app/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const purposeDeviceReport = "device_report"
+
+var (
+	ErrDeviceNotFound      = errors.New("device not found")
+	ErrInvalidDeviceReport = errors.New("invalid or expired link")
+)
+
+type DeviceService struct {
+	repo       repository.DeviceRepository
+	users      repository.UserRepository
+	redis      repository.RedisRepository
+	apiKeys    repository.APIKeyRepository
+	identities repository.IdentityRepository
+	resets     *PasswordResetService
+	audit      *AuditService
+	mailer     mailer.Mailer
+	signer     *signer.Signer
+	cfg        config.Config
+}
+
+func NewDeviceService(repo repository.DeviceRepository, users repository.UserRepository, redisRepo repository.RedisRepository, apiKeys repository.APIKeyRepository, identities repository.IdentityRepository, resets *PasswordResetService, audit *AuditService, mail mailer.Mailer, sign *signer.Signer, cfg config.Config) *DeviceService {
+	return &DeviceService{
+		repo:       repo,
+		users:      users,
+		redis:      redisRepo,
+		apiKeys:    apiKeys,
+		identities: identities,
+		resets:     resets,
+		audit:      audit,
+		mailer:     mail,
+		signer:     sign,
+		cfg:        cfg,
+	}
+}
+
+// RecordLogin - Ghi nhận thiết bị của một lần đăng nhập; thiết bị mới được lưu lại và người dùng nhận email cảnh báo
+// kèm link "không phải tôi". Thiết bị đầu tiên của tài khoản không gửi email. Lỗi không ảnh hưởng tới đăng nhập.
+func (s *DeviceService) RecordLogin(ctx context.Context, user *model.User, sessionID string) {
+	info := clientinfo.From(ctx)
+	network := networkPrefix(info.IP)
+	now := time.Now()
+
+	fingerprint := deviceFingerprint(info, network)
+	device, err := s.repo.FindByFingerprint(user.ID, fingerprint)
+	if err == nil {
+		previousNetwork := device.Network
+		if err := s.repo.Touch(device.ID, info.IP, network, now); err != nil {
+			fmt.Printf("warning: failed to update known device: %v\n", err)
+		}
+		// Fingerprint theo cookie không chứa dải IP: cookie bị đánh cắp dùng từ mạng khác vẫn khớp thiết bị cũ,
+		// nên đổi dải IP cũng được cảnh báo như một thiết bị mới
+		if info.DeviceID != "" && previousNetwork != "" && previousNetwork != network {
+			device.LastIP, device.Network = info.IP, network
+			s.alert(ctx, user, device, sessionID, model.AuditActionNewNetwork, model.AuditMetadata{"previous_network": previousNetwork})
+		}
+		return
+	}
+	if !errors.Is(err, repository.ErrDeviceNotFound) {
+		fmt.Printf("warning: failed to look up known device: %v\n", err)
+		return
+	}
+
+	known, err := s.repo.CountByUser(user.ID)
+	if err != nil {
+		fmt.Printf("warning: failed to count known devices: %v\n", err)
+		return
+	}
+
+	device = &model.KnownDevice{
+		ID:          uuid.New().String(),
+		UserID:      user.ID,
+		Fingerprint: fingerprint,
+		Name:        useragent.Parse(info.UserAgent).Name(),
+		UserAgent:   info.UserAgent,
+		LastIP:      info.IP,
+		Network:     network,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+	if err := s.repo.Create(device); err != nil {
+		fmt.Printf("warning: failed to store known device: %v\n", err)
+		return
+	}
+
+	// Thiết bị đầu tiên của tài khoản không cần cảnh báo
+	if known == 0 {
+		s.audit.Record(ctx, model.AuditActionNewDevice, user.ID, user.ID, nil, model.AuditMetadata{
+			"device_id": device.ID,
+			"device":    device.Name,
+			"network":   device.Network,
+			"notified":  false,
+		})
+		return
+	}
+	s.alert(ctx, user, device, sessionID, model.AuditActionNewDevice, nil)
+}
+
+// alert - Ghi audit log và gửi email (nếu bật) về lần đăng nhập từ thiết bị mới hoặc dải IP mới của thiết bị đã biết
+func (s *DeviceService) alert(ctx context.Context, user *model.User, device *model.KnownDevice, sessionID, action string, metadata model.AuditMetadata) {
+	notify := s.cfg.NewDeviceNotifications
+	if metadata == nil {
+		metadata = model.AuditMetadata{}
+	}
+	metadata["device_id"] = device.ID
+	metadata["device"] = device.Name
+	metadata["network"] = device.Network
+	metadata["notified"] = notify
+	s.audit.Record(ctx, action, user.ID, user.ID, nil, metadata)
+	if !notify {
+		return
+	}
+
+	subject, intro := "New sign-in to your account", "Your account was just signed in to from a new device"
+	if action == model.AuditActionNewNetwork {
+		subject, intro = "Sign-in to your account from a new location", "Your account was just signed in to from a known device on a new network"
+	}
+	go func() {
+		if err := s.notify(context.Background(), user, device, sessionID, subject, intro); err != nil {
+			fmt.Printf("warning: failed to send new device email: %v\n", err)
+		}
+	}()
+}
+
+// List - Các thiết bị đã biết của người dùng, thiết bị dùng gần nhất đứng đầu
+func (s *DeviceService) List(userID string) ([]model.KnownDevice, error) {
+	devices, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %v", err)
+	}
+	return devices, nil
+}
+
+// Forget - Quên một thiết bị: lần đăng nhập sau từ thiết bị đó được coi là thiết bị mới
+func (s *DeviceService) Forget(ctx context.Context, userID, deviceID string) error {
+	err := s.repo.Delete(userID, deviceID)
+	if errors.Is(err, repository.ErrDeviceNotFound) {
+		err = ErrDeviceNotFound
+	}
+	s.audit.Record(ctx, model.AuditActionDeviceForget, userID, userID, err, model.AuditMetadata{"device_id": deviceID})
+	if err != nil && !errors.Is(err, ErrDeviceNotFound) {
+		return fmt.Errorf("failed to delete device: %v", err)
+	}
+	return err
+}
+
+// ReportNotMe - Người dùng bấm "không phải tôi" trong email thiết bị mới: đăng xuất mọi phiên, thu hồi API key,
+// quên thiết bị, chặn đăng nhập bằng mật khẩu cho tới khi đặt lại mật khẩu qua email và gửi link đặt lại mật khẩu.
+// Link chỉ dùng được một lần.
+func (s *DeviceService) ReportNotMe(ctx context.Context, reportToken string) (err error) {
+	payload, err := s.signer.Verify(purposeDeviceReport, reportToken)
+	if err != nil {
+		return ErrInvalidDeviceReport
+	}
+	userID := payload.Subject
+	sessionID := payload.Data["session_id"]
+	deviceID := payload.Data["device_id"]
+
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionLoginReported, userID, userID, err, model.AuditMetadata{
+			"session_id": sessionID,
+			"device_id":  deviceID,
+		})
+	}()
+
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return ErrInvalidDeviceReport
+	}
+
+	first, err := s.redis.MarkDeviceReportUsed(ctx, hashToken(reportToken), time.Until(time.Unix(payload.ExpiresAt, 0)))
+	if err != nil {
+		return fmt.Errorf("failed to check report link in Redis: %v", err)
+	}
+	if !first {
+		return ErrInvalidDeviceReport
+	}
+
+	// Kẻ chiếm tài khoản có thể đã mở thêm phiên hoặc tạo API key: thu hồi tất cả, không chỉ phiên trong email
+	if err := s.redis.RevokeAllUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke tokens in Redis: %v", err)
+	}
+	if err := s.redis.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens in Redis: %v", err)
+	}
+	if err := s.apiKeys.RevokeAllByUser(userID); err != nil {
+		return fmt.Errorf("failed to revoke api keys: %v", err)
+	}
+	// Kẻ chiếm tài khoản có thể đã liên kết IdP của mình để quay lại sau khi mật khẩu được đổi:
+	// hủy mọi liên kết, người dùng liên kết lại sau khi đặt mật khẩu mới
+	if err := s.identities.DeleteByUser(userID); err != nil {
+		return fmt.Errorf("failed to unlink identities: %v", err)
+	}
+	if err := s.repo.Delete(userID, deviceID); err != nil && !errors.Is(err, repository.ErrDeviceNotFound) {
+		fmt.Printf("warning: failed to delete reported device: %v\n", err)
+	}
+
+	if err := s.users.RequirePasswordReset(userID); err != nil {
+		return fmt.Errorf("failed to require password reset: %v", err)
+	}
+	if err := s.redis.DeleteUserProfile(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to clear cached profile: %v\n", err)
+	}
+
+	if err := s.resets.ForgotPassword(ctx, user.Email); err != nil {
+		fmt.Printf("warning: failed to send password reset email: %v\n", err)
+	}
+	return nil
+}
+
+// ExportUserData - Section "devices" của file export dữ liệu người dùng
+func (s *DeviceService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.List(userID)
+}
+
+// notify - Gửi email cảnh báo đăng nhập từ thiết bị / dải IP mới kèm link "không phải tôi"
+func (s *DeviceService) notify(ctx context.Context, user *model.User, device *model.KnownDevice, sessionID, subject, intro string) error {
+	token, err := s.signer.Sign(purposeDeviceReport, user.ID, map[string]string{
+		"session_id": sessionID,
+		"device_id":  device.ID,
+	}, s.cfg.DeviceReportTTL)
+	if err != nil {
+		return fmt.Errorf("could not sign report link: %v", err)
+	}
+	link := strings.TrimRight(s.cfg.AppBaseURL, "/") + "/security/not-me?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body: fmt.Sprintf("Hi %s,\n\n%s:\n\nDevice: %s\nIP address: %s\nTime: %s\n\nIf this was you, you can ignore this email.\nIf this wasn't you, use the link below to sign this device out and reset your password:\n\n%s\n",
+			user.Name, intro, device.Name, device.LastIP, time.Now().UTC().Format(time.RFC1123), link),
+	})
+}
+
+// deviceFingerprint - Băm device cookie cùng trình duyệt / hệ điều hành. Client không gửi cookie
+// (ứng dụng gọi API trực tiếp) thì dùng dải IP thay cho cookie.
+func deviceFingerprint(info clientinfo.Info, network string) string {
+	name := useragent.Parse(info.UserAgent).Name()
+	key := "network:" + network + "|" + name
+	if info.DeviceID != "" {
+		key = "cookie:" + info.DeviceID + "|" + name
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// networkPrefix - Dải IP chứa địa chỉ: /24 với IPv4, /64 với IPv6
+func networkPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"base-app/model"
+	"base-app/pkg/clientinfo"
+	"context"
+	"strings"
+	"testing"
+)
+
+const testUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func TestRecordLogin(t *testing.T) {
+	type login struct {
+		ip       string
+		deviceID string
+	}
+
+	tests := []struct {
+		name        string
+		logins      []login
+		wantActions []string // audit log sau lần đăng nhập cuối (các lần trước bị bỏ qua)
+		wantDevices int
+	}{
+		{
+			name:        "first device is recorded without alert",
+			logins:      []login{{"203.0.113.10", "cookie-a"}},
+			wantActions: []string{model.AuditActionNewDevice},
+			wantDevices: 1,
+		},
+		{
+			name:        "same cookie on the same network",
+			logins:      []login{{"203.0.113.10", "cookie-a"}, {"203.0.113.99", "cookie-a"}},
+			wantActions: nil,
+			wantDevices: 1,
+		},
+		{
+			name:        "same cookie on a new network",
+			logins:      []login{{"203.0.113.10", "cookie-a"}, {"198.51.100.7", "cookie-a"}},
+			wantActions: []string{model.AuditActionNewNetwork},
+			wantDevices: 1,
+		},
+		{
+			name:        "new cookie",
+			logins:      []login{{"203.0.113.10", "cookie-a"}, {"203.0.113.10", "cookie-b"}},
+			wantActions: []string{model.AuditActionNewDevice},
+			wantDevices: 2,
+		},
+		{
+			name:        "no cookie on a new network is a new device",
+			logins:      []login{{"203.0.113.10", ""}, {"198.51.100.7", ""}},
+			wantActions: []string{model.AuditActionNewDevice},
+			wantDevices: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &fakeAudit{}
+			devices := &fakeDevices{}
+			s := &DeviceService{repo: devices, audit: NewAuditService(audit)}
+			user := &model.User{ID: "user-1", Email: "user@example.com"}
+
+			for i, l := range tt.logins {
+				if i == len(tt.logins)-1 {
+					audit.events = nil
+				}
+				ctx := clientinfo.With(context.Background(), clientinfo.Info{IP: l.ip, UserAgent: testUserAgent, DeviceID: l.deviceID})
+				s.RecordLogin(ctx, user, "session-1")
+			}
+
+			if got := strings.Join(audit.actions(), ","); got != strings.Join(tt.wantActions, ",") {
+				t.Errorf("audit actions = %q, want %q", got, strings.Join(tt.wantActions, ","))
+			}
+			if len(devices.devices) != tt.wantDevices {
+				t.Errorf("known devices = %d, want %d", len(devices.devices), tt.wantDevices)
+			}
+		})
+	}
+}
+
+func TestRecordLoginNewNetworkMetadata(t *testing.T) {
+	audit := &fakeAudit{}
+	devices := &fakeDevices{}
+	s := &DeviceService{repo: devices, audit: NewAuditService(audit)}
+	user := &model.User{ID: "user-1"}
+
+	for _, ip := range []string{"203.0.113.10", "2001:db8::1"} {
+		ctx := clientinfo.With(context.Background(), clientinfo.Info{IP: ip, UserAgent: testUserAgent, DeviceID: "cookie-a"})
+		s.RecordLogin(ctx, user, "session-1")
+	}
+
+	last := audit.events[len(audit.events)-1]
+	if last.Action != model.AuditActionNewNetwork {
+		t.Fatalf("last audit action = %q, want %q", last.Action, model.AuditActionNewNetwork)
+	}
+	if last.Metadata["previous_network"] != "203.0.113.0/24" || last.Metadata["network"] != "2001:db8::/64" {
+		t.Errorf("metadata = %v, want previous_network 203.0.113.0/24 and network 2001:db8::/64", last.Metadata)
+	}
+}
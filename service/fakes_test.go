@@ -0,0 +1,453 @@
+package service
+
+import (
+	"base-app/model"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Các repository giả lập trong bộ nhớ cho test của tầng service.
+// Mỗi fake nhúng interface tương ứng: gọi tới method chưa được giả lập sẽ panic, giúp phát hiện phụ thuộc mới.
+
+type fakeRedis struct {
+	repository.RedisRepository
+
+	mu             sync.Mutex
+	loginStates    map[string]string
+	sessions       map[string]*model.Session
+	accessTokens   map[string]string
+	refreshTokens  map[string]string
+	mfaChallenges  map[string]string
+	cachedProfiles map[string]*model.User
+	usedTOTPSteps  map[string]bool
+	oauthTokens    map[string]string
+	oauthGrants    map[string]string
+	loginFailures  map[string]int64
+	loginLocks     map[string]time.Time
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		loginStates:    map[string]string{},
+		sessions:       map[string]*model.Session{},
+		accessTokens:   map[string]string{},
+		refreshTokens:  map[string]string{},
+		mfaChallenges:  map[string]string{},
+		cachedProfiles: map[string]*model.User{},
+		usedTOTPSteps:  map[string]bool{},
+		oauthTokens:    map[string]string{},
+		oauthGrants:    map[string]string{},
+		loginFailures:  map[string]int64{},
+		loginLocks:     map[string]time.Time{},
+	}
+}
+
+func (r *fakeRedis) SetFederatedLoginState(ctx context.Context, state, data string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loginStates[state] = data
+	return nil
+}
+
+func (r *fakeRedis) ConsumeFederatedLoginState(ctx context.Context, state string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.loginStates[state]
+	if !ok {
+		return "", errors.New("login state not found")
+	}
+	delete(r.loginStates, state)
+	return data, nil
+}
+
+func (r *fakeRedis) SetMFAChallenge(ctx context.Context, challenge, userID, method string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mfaChallenges[challenge] = userID + "|" + method
+	return nil
+}
+
+func (r *fakeRedis) MarkTOTPStepUsed(ctx context.Context, userID string, step int64, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := fmt.Sprintf("%s:%d", userID, step)
+	if r.usedTOTPSteps[key] {
+		return false, nil
+	}
+	r.usedTOTPSteps[key] = true
+	return true, nil
+}
+
+func (r *fakeRedis) CreateSession(ctx context.Context, session *model.Session, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *fakeRedis) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeRedis) SetSessionOrganization(ctx context.Context, sessionID, orgID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[sessionID]; ok {
+		session.OrganizationID = orgID
+	}
+	return nil
+}
+
+func (r *fakeRedis) SetAccessToken(ctx context.Context, token, userID, role, familyID string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accessTokens[token] = userID
+	return nil
+}
+
+func (r *fakeRedis) AddTokenToUser(ctx context.Context, userID, token string) error {
+	return nil
+}
+
+func (r *fakeRedis) AddTokenToSession(ctx context.Context, sessionID, token string, ttl time.Duration) error {
+	return nil
+}
+
+func (r *fakeRedis) SetRefreshToken(ctx context.Context, refreshToken, userID, familyID string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshTokens[refreshToken] = userID
+	return nil
+}
+
+func (r *fakeRedis) SetUserProfileFull(ctx context.Context, user *model.User, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cachedProfiles[user.ID] = user
+	return nil
+}
+
+func (r *fakeRedis) SetOAuthAccessToken(ctx context.Context, userID, clientID, tokenHash, data string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.oauthTokens[tokenHash] = data
+	r.oauthGrants[tokenHash] = userID + ":" + clientID
+	return nil
+}
+
+func (r *fakeRedis) GetOAuthAccessToken(ctx context.Context, tokenHash string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, ok := r.oauthTokens[tokenHash]
+	if !ok {
+		return "", errors.New("token not found")
+	}
+	return data, nil
+}
+
+func (r *fakeRedis) RevokeOAuthAccessTokens(ctx context.Context, userID, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for tokenHash, grant := range r.oauthGrants {
+		if grant == userID+":"+clientID || (clientID == "" && strings.HasPrefix(grant, userID+":")) {
+			delete(r.oauthTokens, tokenHash)
+			delete(r.oauthGrants, tokenHash)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRedis) IncrementLoginFailures(ctx context.Context, kind, value string, window time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loginFailures[kind+":"+value]++
+	return r.loginFailures[kind+":"+value], nil
+}
+
+func (r *fakeRedis) SetLoginDelay(ctx context.Context, kind, value string, delay time.Duration) error {
+	return nil
+}
+
+func (r *fakeRedis) SetLoginLock(ctx context.Context, kind, value string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loginLocks[kind+":"+value] = until
+	return nil
+}
+
+type fakeUsers struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[string]*model.User
+}
+
+func newFakeUsers(users ...*model.User) *fakeUsers {
+	r := &fakeUsers{users: map[string]*model.User{}}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUsers) Create(name, email, hashPassword string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := &model.User{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Email:     email,
+		Password:  hashPassword,
+		Role:      model.RoleUser,
+		Status:    model.UserStatusActive,
+		CreatedAt: time.Now(),
+	}
+	r.users[user.ID] = user
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUsers) FindByID(id string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUsers) FindByEmail(email string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUsers) MarkEmailVerified(userID, email string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok || user.Email != email {
+		return false, nil
+	}
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	return true, nil
+}
+
+func (r *fakeUsers) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.users)
+}
+
+type fakeIdentities struct {
+	repository.IdentityRepository
+
+	mu         sync.Mutex
+	identities []model.Identity
+}
+
+func (r *fakeIdentities) Create(userID, provider, subject, email string) (*model.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity := model.Identity{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+	r.identities = append(r.identities, identity)
+	return &identity, nil
+}
+
+func (r *fakeIdentities) FindByProviderSubject(provider, subject string) (*model.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			copied := identity
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("identity not found")
+}
+
+func (r *fakeIdentities) TouchLogin(identityID, email string) error {
+	return nil
+}
+
+type fakeOrganizations struct {
+	repository.OrganizationRepository
+}
+
+func (fakeOrganizations) FindMembership(orgID, userID string) (*model.Membership, error) {
+	return nil, errors.New("membership not found")
+}
+
+func (fakeOrganizations) ListMembershipsByUser(userID string) ([]model.Membership, error) {
+	return nil, nil
+}
+
+type fakeOAuth struct {
+	repository.OAuthRepository
+}
+
+func (fakeOAuth) DeleteConsent(userID, clientID string) error {
+	return nil
+}
+
+type fakeLockouts struct {
+	repository.LockoutRepository
+
+	mu     sync.Mutex
+	events []model.LockoutEvent
+}
+
+func (r *fakeLockouts) CreateEvent(event *model.LockoutEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+type fakeAudit struct {
+	repository.AuditRepository
+
+	mu     sync.Mutex
+	events []model.AuditEvent
+}
+
+func (r *fakeAudit) Create(event *model.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeAudit) ListByUserActions(userID string, actions []string) ([]model.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []model.AuditEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		event := r.events[i]
+		if (event.ActorID == userID || event.TargetID == userID) && containsString(actions, event.Action) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// actions - các action đã ghi, theo thứ tự
+func (r *fakeAudit) actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actions := make([]string, 0, len(r.events))
+	for _, event := range r.events {
+		actions = append(actions, event.Action)
+	}
+	return actions
+}
+
+type fakeMFA struct {
+	repository.MFARepository
+
+	mu            sync.Mutex
+	recoveryCodes map[string]bool // hash -> đã dùng
+}
+
+func (r *fakeMFA) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used, ok := r.recoveryCodes[codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recoveryCodes[codeHash] = true
+	return true, nil
+}
+
+type fakeDevices struct {
+	repository.DeviceRepository
+
+	mu      sync.Mutex
+	devices []model.KnownDevice
+}
+
+func (r *fakeDevices) FindByFingerprint(userID, fingerprint string) (*model.KnownDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, device := range r.devices {
+		if device.UserID == userID && device.Fingerprint == fingerprint {
+			copied := device
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrDeviceNotFound
+}
+
+func (r *fakeDevices) CountByUser(userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, device := range r.devices {
+		if device.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeDevices) Create(device *model.KnownDevice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.devices = append(r.devices, *device)
+	return nil
+}
+
+func (r *fakeDevices) Touch(deviceID, ip, network string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.devices {
+		if r.devices[i].ID == deviceID {
+			r.devices[i].LastIP, r.devices[i].Network, r.devices[i].LastSeenAt = ip, network, at
+		}
+	}
+	return nil
+}
+
+// fakeHasher - băm "giả" để test không tốn thời gian chạy argon2id
+type fakeHasher struct{}
+
+func (fakeHasher) Hash(password string) (string, error) {
+	return "fake$" + password, nil
+}
+
+func (fakeHasher) Verify(password, encoded string) (bool, bool, error) {
+	return encoded == "fake$"+password, false, nil
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/oidc"
+	"base-app/repository"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrIdentityEmailConflict - email từ IdP đã thuộc về một tài khoản local.
+// Không tự động liên kết để tránh chiếm tài khoản qua một IdP cho phép tự khai báo email.
+var ErrIdentityEmailConflict = errors.New("an account with this email already exists, sign in with your password and link the provider from your account settings")
+
+// ErrInvalidLoginState - state không tồn tại, hết hạn, đã dùng hoặc không thuộc trình duyệt đã bắt đầu đăng nhập
+var ErrInvalidLoginState = errors.New("invalid or expired login state")
+
+// FederatedProvider là thông tin identity provider hiển thị cho frontend
+type FederatedProvider struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+}
+
+// FederatedResult là kết quả xử lý callback từ identity provider:
+// hoặc một lần đăng nhập (Login), hoặc một identity vừa được liên kết vào tài khoản đang đăng nhập (Linked)
+type FederatedResult struct {
+	Login    *LoginResult
+	Identity *model.Identity
+	Linked   bool
+}
+
+// FederatedLogin là URL đăng nhập của IdP cùng giá trị gắn luồng đăng nhập với trình duyệt đã bắt đầu nó.
+// Binding được lưu trong cookie HttpOnly và phải được gửi lại ở callback, nên link đăng nhập / liên kết
+// gửi cho người khác không dùng được.
+type FederatedLogin struct {
+	AuthURL   string
+	Binding   string
+	ExpiresAt time.Time
+}
+
+// federatedState là dữ liệu lưu trong Redis giữa lúc chuyển hướng tới IdP và lúc IdP redirect về
+type federatedState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	LinkUserID   string `json:"link_user_id,omitempty"`
+	BindingHash  string `json:"binding_hash"`
+}
+
+type FederatedService struct {
+	providers  map[string]*oidc.Provider
+	order      []string
+	identities repository.IdentityRepository
+	repo       repository.UserRepository
+	redis      repository.RedisRepository
+	users      *UserService
+	cfg        config.Config
+}
+
+func NewFederatedService(identities repository.IdentityRepository, repo repository.UserRepository, redisRepo repository.RedisRepository, users *UserService, cfg config.Config) *FederatedService {
+	s := &FederatedService{
+		providers:  map[string]*oidc.Provider{},
+		identities: identities,
+		repo:       repo,
+		redis:      redisRepo,
+		users:      users,
+		cfg:        cfg,
+	}
+
+	for _, providerCfg := range cfg.OIDCProviders {
+		provider, err := oidc.NewProvider(providerCfg)
+		if err != nil {
+			fmt.Printf("warning: skipping identity provider %q: %v\n", providerCfg.Name, err)
+			continue
+		}
+		if _, exists := s.providers[provider.Name()]; exists {
+			fmt.Printf("warning: duplicate identity provider %q ignored\n", provider.Name())
+			continue
+		}
+		s.providers[provider.Name()] = provider
+		s.order = append(s.order, provider.Name())
+	}
+	return s
+}
+
+// Providers - Danh sách identity provider đã cấu hình
+func (s *FederatedService) Providers() []FederatedProvider {
+	providers := make([]FederatedProvider, 0, len(s.order))
+	for _, name := range s.order {
+		providers = append(providers, FederatedProvider{Name: name, Label: s.providers[name].Label()})
+	}
+	return providers
+}
+
+// BeginLogin - Sinh state, nonce, PKCE verifier và binding của trình duyệt, trả về URL đăng nhập của IdP.
+// linkUserID khác rỗng nghĩa là người dùng đang đăng nhập muốn liên kết thêm tài khoản IdP.
+func (s *FederatedService) BeginLogin(ctx context.Context, providerName, linkUserID string) (*FederatedLogin, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, errors.New("unknown identity provider")
+	}
+
+	state, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate state: %v", err)
+	}
+	nonce, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate nonce: %v", err)
+	}
+	verifier, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate code verifier: %v", err)
+	}
+	binding, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate login binding: %v", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(federatedState{
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		BindingHash:  hashToken(binding),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redis.SetFederatedLoginState(ctx, state, string(data), s.cfg.FederatedLoginTTL); err != nil {
+		return nil, fmt.Errorf("failed to store login state in Redis: %v", err)
+	}
+
+	return &FederatedLogin{
+		AuthURL:   authURL,
+		Binding:   binding,
+		ExpiresAt: time.Now().Add(s.cfg.FederatedLoginTTL),
+	}, nil
+}
+
+// CompleteLogin - Xử lý callback: kiểm tra state và binding của trình duyệt, đổi code lấy ID token, xác thực ID token,
+// sau đó đăng nhập (tạo tài khoản ngay nếu là lần đầu) hoặc liên kết identity vào tài khoản hiện tại
+func (s *FederatedService) CompleteLogin(ctx context.Context, providerName, state, code, binding string) (*FederatedResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, errors.New("unknown identity provider")
+	}
+	if state == "" || code == "" {
+		return nil, errors.New("state and code are required")
+	}
+
+	// State bị xóa ngay cả khi binding sai: link bị lộ không thử lại được
+	raw, err := s.redis.ConsumeFederatedLoginState(ctx, state)
+	if err != nil {
+		return nil, ErrInvalidLoginState
+	}
+	var data federatedState
+	if err := json.Unmarshal([]byte(raw), &data); err != nil || data.Provider != providerName {
+		return nil, ErrInvalidLoginState
+	}
+	if binding == "" || subtle.ConstantTimeCompare([]byte(hashToken(binding)), []byte(data.BindingHash)) != 1 {
+		return nil, ErrInvalidLoginState
+	}
+
+	claims, err := provider.Exchange(ctx, code, data.CodeVerifier, data.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.LinkUserID != "" {
+		identity, err := s.link(data.LinkUserID, providerName, claims)
+		if err != nil {
+			return nil, err
+		}
+		return &FederatedResult{Identity: identity, Linked: true}, nil
+	}
+
+	user, identity, err := s.resolveUser(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	login, err := s.users.SignIn(ctx, user, "oidc:"+providerName)
+	if err != nil {
+		return nil, err
+	}
+	return &FederatedResult{Login: login, Identity: identity}, nil
+}
+
+// ListIdentities - Các tài khoản IdP đã liên kết với người dùng
+func (s *FederatedService) ListIdentities(userID string) ([]model.Identity, error) {
+	return s.identities.ListByUser(userID)
+}
+
+// ExportUserData - Section "identities" của file export dữ liệu người dùng
+func (s *FederatedService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.identities.ListByUser(userID)
+}
+
+// Unlink - Hủy liên kết một tài khoản IdP
+func (s *FederatedService) Unlink(userID, identityID string) error {
+	return s.identities.Delete(userID, identityID)
+}
+
+// resolveUser - Tìm người dùng theo (provider, sub); lần đầu đăng nhập thì tạo tài khoản mới (JIT)
+func (s *FederatedService) resolveUser(ctx context.Context, providerName string, claims *oidc.IDTokenClaims) (*model.User, *model.Identity, error) {
+	identity, err := s.identities.FindByProviderSubject(providerName, claims.Subject)
+	if err == nil {
+		user, err := s.repo.FindByID(identity.UserID)
+		if err != nil {
+			return nil, nil, errors.New("user not found")
+		}
+		if err := s.identities.TouchLogin(identity.ID, claims.Email); err != nil {
+			fmt.Printf("warning: failed to update identity last login: %v\n", err)
+		}
+		return user, identity, nil
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" {
+		return nil, nil, errors.New("identity provider did not return an email address")
+	}
+	if _, err := s.repo.FindByEmail(email); err == nil {
+		return nil, nil, ErrIdentityEmailConflict
+	}
+
+	user, err := s.createUser(ctx, email, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	identity, err = s.identities.Create(user.ID, providerName, claims.Subject, email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to link identity: %v", err)
+	}
+	return user, identity, nil
+}
+
+// createUser - Tạo tài khoản cho người dùng đăng nhập lần đầu qua IdP.
+// Mật khẩu là chuỗi ngẫu nhiên không ai biết; người dùng có thể đặt mật khẩu qua /auth/forgot-password.
+func (s *FederatedService) createUser(ctx context.Context, email string, claims *oidc.IDTokenClaims) (*model.User, error) {
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = strings.SplitN(email, "@", 2)[0]
+	}
+
+	randomPassword, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate password: %v", err)
+	}
+	hashPassword, err := s.users.hasher.Hash(randomPassword)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash password: %v", err)
+	}
+
+	user, err := s.repo.Create(name, email, hashPassword)
+	if err != nil {
+		return nil, fmt.Errorf("error creating user: %v", err)
+	}
+
+	// Email đã được IdP xác thực thì không cần gửi link xác thực
+	if claims.IsEmailVerified() {
+		if _, err := s.repo.MarkEmailVerified(user.ID, user.Email); err != nil {
+			fmt.Printf("warning: failed to mark email as verified: %v\n", err)
+		} else {
+			now := time.Now()
+			user.EmailVerified = true
+			user.EmailVerifiedAt = &now
+		}
+	}
+
+	return user, nil
+}
+
+// link - Liên kết tài khoản IdP vào người dùng đang đăng nhập
+func (s *FederatedService) link(userID, providerName string, claims *oidc.IDTokenClaims) (*model.Identity, error) {
+	existing, err := s.identities.FindByProviderSubject(providerName, claims.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, errors.New("this account is already linked to another user")
+		}
+		return existing, nil
+	}
+
+	identity, err := s.identities.Create(userID, providerName, claims.Subject, claims.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity: %v", err)
+	}
+	return identity, nil
+}
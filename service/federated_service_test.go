@@ -0,0 +1,277 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/oidc/oidctest"
+	"base-app/pkg/token"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testClientID = "base-app-test"
+
+// federatedFixture - FederatedService chạy với IdP giả lập và repository trong bộ nhớ
+type federatedFixture struct {
+	idp        *oidctest.Server
+	service    *FederatedService
+	redis      *fakeRedis
+	users      *fakeUsers
+	identities *fakeIdentities
+	audit      *fakeAudit
+}
+
+func newFederatedFixture(t *testing.T, existing ...*model.User) *federatedFixture {
+	t.Helper()
+
+	idp, err := oidctest.NewServer(testClientID)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	t.Cleanup(idp.Close)
+
+	keys, err := token.NewKeySet(token.KeySetConfig{})
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	cfg := config.Config{
+		OIDCProviders: []config.OIDCProvider{{
+			Name:        "mock",
+			Issuer:      idp.Issuer(),
+			ClientID:    testClientID,
+			RedirectURL: "http://localhost/api/v1/auth/oidc/mock/callback",
+		}},
+		FederatedLoginTTL:    10 * time.Minute,
+		AccessTokenTTL:       15 * time.Minute,
+		RefreshTokenTTL:      24 * time.Hour,
+		AllowUnverifiedLogin: true,
+	}
+
+	f := &federatedFixture{
+		idp:        idp,
+		redis:      newFakeRedis(),
+		users:      newFakeUsers(existing...),
+		identities: &fakeIdentities{},
+		audit:      &fakeAudit{},
+	}
+	audit := NewAuditService(f.audit)
+	devices := &DeviceService{repo: &fakeDevices{}, users: f.users, redis: f.redis, audit: audit, cfg: cfg}
+	users := &UserService{
+		repo:       f.users,
+		redis:      f.redis,
+		tokens:     token.NewManager(keys, "base-app", "base-app"),
+		cfg:        cfg,
+		identities: f.identities,
+		orgs:       fakeOrganizations{},
+		audit:      audit,
+		hasher:     fakeHasher{},
+		devices:    devices,
+	}
+	f.service = NewFederatedService(f.identities, f.users, f.redis, users, cfg)
+	if len(f.service.Providers()) != 1 {
+		t.Fatalf("Providers() = %v, want the mock provider", f.service.Providers())
+	}
+	return f
+}
+
+// roundTrip - Bắt đầu đăng nhập, "đăng nhập" ở IdP rồi trả về state, code và binding của trình duyệt
+func (f *federatedFixture) roundTrip(t *testing.T, linkUserID string) (state, code, binding string) {
+	t.Helper()
+
+	login, err := f.service.BeginLogin(context.Background(), "mock", linkUserID)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	code, state, err = f.idp.Authorize(login.AuthURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	return state, code, login.Binding
+}
+
+func TestFederatedLoginCreatesUser(t *testing.T) {
+	f := newFederatedFixture(t)
+	f.idp.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true, Name: "New User"})
+
+	state, code, binding := f.roundTrip(t, "")
+	result, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding)
+	if err != nil {
+		t.Fatalf("CompleteLogin() error = %v", err)
+	}
+	if result.Linked || result.Login == nil || result.Login.Tokens == nil {
+		t.Fatalf("CompleteLogin() = %+v, want a login with tokens", result)
+	}
+
+	user, err := f.users.FindByEmail("new@example.com")
+	if err != nil {
+		t.Fatalf("user was not created: %v", err)
+	}
+	if user.Name != "New User" || !user.EmailVerified {
+		t.Errorf("created user = %+v, want name from the IdP and a verified email", user)
+	}
+	if result.Identity == nil || result.Identity.UserID != user.ID || result.Identity.Subject != "sub-1" {
+		t.Errorf("identity = %+v, want sub-1 linked to %s", result.Identity, user.ID)
+	}
+
+	// Lần đăng nhập sau tìm lại đúng tài khoản, không tạo thêm
+	state, code, binding = f.roundTrip(t, "")
+	again, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding)
+	if err != nil {
+		t.Fatalf("second CompleteLogin() error = %v", err)
+	}
+	if again.Identity.UserID != user.ID || f.users.count() != 1 {
+		t.Errorf("second login resolved to %s with %d users, want %s and 1 user", again.Identity.UserID, f.users.count(), user.ID)
+	}
+}
+
+func TestFederatedLoginEmailConflict(t *testing.T) {
+	local := &model.User{ID: "local-1", Email: "taken@example.com", Role: model.RoleUser, Status: model.UserStatusActive}
+	f := newFederatedFixture(t, local)
+	f.idp.SetIdentity(oidctest.Identity{Subject: "sub-2", Email: "taken@example.com", EmailVerified: true})
+
+	state, code, binding := f.roundTrip(t, "")
+	_, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding)
+	if !errors.Is(err, ErrIdentityEmailConflict) {
+		t.Fatalf("CompleteLogin() error = %v, want ErrIdentityEmailConflict", err)
+	}
+	if _, err := f.identities.FindByProviderSubject("mock", "sub-2"); err == nil {
+		t.Error("identity was linked to the local account without consent")
+	}
+}
+
+func TestFederatedLinkIdentity(t *testing.T) {
+	local := &model.User{ID: "local-1", Email: "me@example.com", Role: model.RoleUser, Status: model.UserStatusActive}
+	f := newFederatedFixture(t, local)
+	f.idp.SetIdentity(oidctest.Identity{Subject: "sub-3", Email: "other@example.com", EmailVerified: true})
+
+	state, code, binding := f.roundTrip(t, local.ID)
+	result, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding)
+	if err != nil {
+		t.Fatalf("CompleteLogin() error = %v", err)
+	}
+	if !result.Linked || result.Login != nil {
+		t.Fatalf("CompleteLogin() = %+v, want a linked identity without login", result)
+	}
+	if result.Identity.UserID != local.ID || result.Identity.Subject != "sub-3" {
+		t.Errorf("identity = %+v, want sub-3 linked to %s", result.Identity, local.ID)
+	}
+
+	// Tài khoản IdP đã thuộc người khác thì không liên kết lại được
+	other := &model.User{ID: "local-2", Email: "two@example.com", Role: model.RoleUser, Status: model.UserStatusActive}
+	f.users.users[other.ID] = other
+	state, code, binding = f.roundTrip(t, other.ID)
+	if _, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding); err == nil {
+		t.Error("CompleteLogin() linked an identity that belongs to another user")
+	}
+}
+
+func TestFederatedLoginRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		// tamper chỉnh sửa callback (hoặc trạng thái đã lưu) trước khi gọi CompleteLogin
+		tamper  func(f *federatedFixture, state, code, binding *string)
+		wantErr error
+	}{
+		{
+			name: "missing binding cookie",
+			tamper: func(f *federatedFixture, state, code, binding *string) {
+				*binding = ""
+			},
+			wantErr: ErrInvalidLoginState,
+		},
+		{
+			name: "binding from another browser",
+			tamper: func(f *federatedFixture, state, code, binding *string) {
+				*binding = "another-browser"
+			},
+			wantErr: ErrInvalidLoginState,
+		},
+		{
+			name: "unknown state",
+			tamper: func(f *federatedFixture, state, code, binding *string) {
+				*state = "forged-state"
+			},
+			wantErr: ErrInvalidLoginState,
+		},
+		{
+			name: "nonce mismatch",
+			tamper: func(f *federatedFixture, state, code, binding *string) {
+				f.idp.NonceOverride = "replayed-nonce"
+			},
+		},
+		{
+			name: "pkce verifier mismatch",
+			tamper: func(f *federatedFixture, state, code, binding *string) {
+				var data federatedState
+				if err := json.Unmarshal([]byte(f.redis.loginStates[*state]), &data); err != nil {
+					panic(err)
+				}
+				data.CodeVerifier = "not-the-original-verifier"
+				raw, _ := json.Marshal(data)
+				f.redis.loginStates[*state] = string(raw)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFederatedFixture(t)
+			f.idp.SetIdentity(oidctest.Identity{Subject: "sub-4", Email: "victim@example.com", EmailVerified: true})
+
+			state, code, binding := f.roundTrip(t, "")
+			tt.tamper(f, &state, &code, &binding)
+
+			result, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding)
+			if err == nil {
+				t.Fatalf("CompleteLogin() = %+v, want an error", result)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("CompleteLogin() error = %v, want %v", err, tt.wantErr)
+			}
+			if f.users.count() != 0 {
+				t.Errorf("a user was created despite the rejected callback")
+			}
+		})
+	}
+}
+
+func TestFederatedStateIsSingleUse(t *testing.T) {
+	f := newFederatedFixture(t)
+	f.idp.SetIdentity(oidctest.Identity{Subject: "sub-5", Email: "once@example.com", EmailVerified: true})
+
+	// Callback với binding sai vẫn tiêu thụ state: kẻ tấn công không thử lại được với cùng link
+	state, code, binding := f.roundTrip(t, "")
+	if _, err := f.service.CompleteLogin(context.Background(), "mock", state, code, "wrong"); !errors.Is(err, ErrInvalidLoginState) {
+		t.Fatalf("CompleteLogin() with wrong binding error = %v, want ErrInvalidLoginState", err)
+	}
+	if _, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding); !errors.Is(err, ErrInvalidLoginState) {
+		t.Fatalf("replayed CompleteLogin() error = %v, want ErrInvalidLoginState", err)
+	}
+
+	// State của provider này không dùng được cho provider khác
+	state, code, binding = f.roundTrip(t, "")
+	if _, err := f.service.CompleteLogin(context.Background(), "other", state, code, binding); err == nil {
+		t.Fatal("CompleteLogin() accepted a callback for an unknown provider")
+	}
+}
+
+func TestFederatedLoginRequiresPasswordReset(t *testing.T) {
+	local := &model.User{ID: "local-1", Email: "me@example.com", Role: model.RoleUser, Status: model.UserStatusActive, EmailVerified: true, PasswordResetRequired: true}
+	f := newFederatedFixture(t, local)
+	if _, err := f.identities.Create(local.ID, "mock", "sub-6", local.Email); err != nil {
+		t.Fatal(err)
+	}
+	f.idp.SetIdentity(oidctest.Identity{Subject: "sub-6", Email: local.Email, EmailVerified: true})
+
+	state, code, binding := f.roundTrip(t, "")
+	if _, err := f.service.CompleteLogin(context.Background(), "mock", state, code, binding); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("CompleteLogin() error = %v, want ErrPasswordResetRequired", err)
+	}
+	if len(f.redis.sessions) != 0 {
+		t.Error("a session was created for an account that must reset its password")
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/mailer"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidInvitation       = errors.New("invalid or expired invitation")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvitationEmailMismatch = errors.New("this invitation was sent to a different email address")
+	ErrInvitationExists        = errors.New("a pending invitation already exists for this email, resend it instead")
+	ErrAlreadyMember           = errors.New("user is already a member of this organization")
+)
+
+// InvitationPreview là thông tin lời mời hiển thị cho người được mời trước khi chấp nhận
+type InvitationPreview struct {
+	OrganizationID   string    `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	AccountExists    bool      `json:"account_exists"` // false => frontend hiển thị form đăng ký kèm invitation_token
+}
+
+type InvitationService struct {
+	invitations repository.InvitationRepository
+	orgs        repository.OrganizationRepository
+	repo        repository.UserRepository
+	mailer      mailer.Mailer
+	cfg         config.Config
+}
+
+func NewInvitationService(invitations repository.InvitationRepository, orgs repository.OrganizationRepository, repo repository.UserRepository, mail mailer.Mailer, cfg config.Config) *InvitationService {
+	return &InvitationService{
+		invitations: invitations,
+		orgs:        orgs,
+		repo:        repo,
+		mailer:      mail,
+		cfg:         cfg,
+	}
+}
+
+// Create - Mời một email tham gia tổ chức. Chỉ owner / admin được mời; chỉ owner được mời với vai trò owner.
+func (s *InvitationService) Create(ctx context.Context, orgID, actorID, email, role string) (*model.Invitation, error) {
+	actor, err := orgManager(s.orgs, orgID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return nil, errors.New("invalid email address")
+	}
+	email = strings.ToLower(address.Address)
+
+	if role == "" {
+		role = model.OrgRoleMember
+	}
+	if model.OrgRoleRank(role) < 0 {
+		return nil, fmt.Errorf("role must be one of %s", strings.Join(model.OrgRoles, ", "))
+	}
+	if role == model.OrgRoleOwner && actor.Role != model.OrgRoleOwner {
+		return nil, ErrOrgPermissionDenied
+	}
+
+	if user, err := s.repo.FindByEmail(email); err == nil {
+		if _, err := s.orgs.FindMembership(orgID, user.ID); err == nil {
+			return nil, ErrAlreadyMember
+		}
+	}
+	if _, err := s.invitations.FindPendingByEmail(orgID, email); err == nil {
+		return nil, ErrInvitationExists
+	}
+
+	inviteToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate invitation token: %v", err)
+	}
+
+	invitation := &model.Invitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		InvitedBy:      actorID,
+		TokenHash:      hashToken(inviteToken),
+		ExpiresAt:      time.Now().Add(s.cfg.InvitationTTL),
+	}
+	if err := s.invitations.Create(invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %v", err)
+	}
+
+	if err := s.send(ctx, invitation, inviteToken); err != nil {
+		// Lời mời vẫn được lưu, owner / admin có thể gửi lại
+		fmt.Printf("warning: failed to send invitation email: %v\n", err)
+	}
+	return invitation, nil
+}
+
+// ListPending - Các lời mời còn hiệu lực của tổ chức
+func (s *InvitationService) ListPending(orgID, actorID string) ([]model.Invitation, error) {
+	if _, err := orgManager(s.orgs, orgID, actorID); err != nil {
+		return nil, err
+	}
+	return s.invitations.ListPending(orgID)
+}
+
+// Revoke - Thu hồi lời mời, link trong email không còn dùng được
+func (s *InvitationService) Revoke(orgID, actorID, invitationID string) error {
+	if _, err := orgManager(s.orgs, orgID, actorID); err != nil {
+		return err
+	}
+	if err := s.invitations.Revoke(orgID, invitationID); err != nil {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Resend - Gửi lại lời mời với token mới và gia hạn thời hạn; link cũ mất hiệu lực
+func (s *InvitationService) Resend(ctx context.Context, orgID, actorID, invitationID string) (*model.Invitation, error) {
+	if _, err := orgManager(s.orgs, orgID, actorID); err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.invitations.FindByID(orgID, invitationID)
+	if err != nil || invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return nil, ErrInvitationNotFound
+	}
+
+	inviteToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate invitation token: %v", err)
+	}
+	expiresAt := time.Now().Add(s.cfg.InvitationTTL)
+	if err := s.invitations.Renew(orgID, invitation.ID, hashToken(inviteToken), expiresAt); err != nil {
+		return nil, ErrInvitationNotFound
+	}
+	invitation.ExpiresAt = expiresAt
+
+	if err := s.send(ctx, invitation, inviteToken); err != nil {
+		return nil, fmt.Errorf("failed to send invitation email: %v", err)
+	}
+	return invitation, nil
+}
+
+// Preview - Thông tin lời mời theo token trong link
+func (s *InvitationService) Preview(inviteToken string) (*InvitationPreview, error) {
+	invitation, err := s.pendingInvitation(inviteToken)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.orgs.FindByID(invitation.OrganizationID)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	_, userErr := s.repo.FindByEmail(invitation.Email)
+	return &InvitationPreview{
+		OrganizationID:   org.ID,
+		OrganizationName: org.Name,
+		Email:            invitation.Email,
+		Role:             invitation.Role,
+		ExpiresAt:        invitation.ExpiresAt,
+		AccountExists:    userErr == nil,
+	}, nil
+}
+
+// Accept - Người dùng đã đăng nhập chấp nhận lời mời gửi tới email của mình
+func (s *InvitationService) Accept(userID, inviteToken string) (*model.Membership, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	invitation, err := s.Validate(inviteToken, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return s.Complete(invitation, user.ID)
+}
+
+// Validate - Lời mời còn hiệu lực và được gửi tới đúng email (dùng cả khi đăng ký tài khoản mới)
+func (s *InvitationService) Validate(inviteToken, email string) (*model.Invitation, error) {
+	invitation, err := s.pendingInvitation(inviteToken)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(invitation.Email, strings.TrimSpace(email)) {
+		return nil, ErrInvitationEmailMismatch
+	}
+	return invitation, nil
+}
+
+// Complete - Đánh dấu lời mời đã dùng và thêm người dùng vào tổ chức
+func (s *InvitationService) Complete(invitation *model.Invitation, userID string) (*model.Membership, error) {
+	if err := s.invitations.MarkAccepted(invitation.ID, userID); err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	if membership, err := s.orgs.FindMembership(invitation.OrganizationID, userID); err == nil {
+		return membership, nil
+	}
+	membership, err := s.orgs.AddMember(invitation.OrganizationID, userID, invitation.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join organization: %v", err)
+	}
+	return membership, nil
+}
+
+func (s *InvitationService) pendingInvitation(inviteToken string) (*model.Invitation, error) {
+	if inviteToken == "" {
+		return nil, ErrInvalidInvitation
+	}
+	invitation, err := s.invitations.FindByTokenHash(hashToken(inviteToken))
+	if err != nil || !invitation.Pending(time.Now()) {
+		return nil, ErrInvalidInvitation
+	}
+	return invitation, nil
+}
+
+// send - Gửi email lời mời kèm link chứa token gốc
+func (s *InvitationService) send(ctx context.Context, invitation *model.Invitation, inviteToken string) error {
+	orgName := "an organization"
+	if org, err := s.orgs.FindByID(invitation.OrganizationID); err == nil {
+		orgName = org.Name
+	}
+	inviterName := "Someone"
+	if inviter, err := s.repo.FindByID(invitation.InvitedBy); err == nil {
+		inviterName = inviter.Name
+	}
+
+	link := strings.TrimRight(s.cfg.AppBaseURL, "/") + "/invitations/accept?token=" + url.QueryEscape(inviteToken)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You have been invited to join %s", orgName),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to join %s as %s. Open the link below to accept the invitation:\n\n%s\n\nIf you do not have an account yet, you can create one from the same link.\nThis invitation expires on %s.\n",
+			inviterName, orgName, invitation.Role, link, invitation.ExpiresAt.Format(time.RFC1123)),
+	})
+}
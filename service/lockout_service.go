@@ -0,0 +1,203 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrLockoutNotFound - không có khóa đăng nhập nào đang hiệu lực
+var ErrLockoutNotFound = errors.New("lockout not found")
+
+// LoginThrottledError - đăng nhập tạm thời bị từ chối vì đang bị khóa hoặc phải chờ giữa các lần thử
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	if e.Locked {
+		return fmt.Sprintf("too many failed login attempts, try again in %d seconds", seconds)
+	}
+	return fmt.Sprintf("please wait %d seconds before trying again", seconds)
+}
+
+type LockoutService struct {
+	repo  repository.LockoutRepository
+	redis repository.RedisRepository
+	audit *AuditService
+	cfg   config.Config
+}
+
+func NewLockoutService(repo repository.LockoutRepository, redisRepo repository.RedisRepository, audit *AuditService, cfg config.Config) *LockoutService {
+	return &LockoutService{
+		repo:  repo,
+		redis: redisRepo,
+		audit: audit,
+		cfg:   cfg,
+	}
+}
+
+// Check - Từ chối lần thử nếu tài khoản hoặc IP đang bị khóa, hoặc chưa hết thời gian chờ
+func (s *LockoutService) Check(ctx context.Context, email, ip string) error {
+	account := normalizeEmail(email)
+
+	if until, err := s.redis.GetLoginLock(ctx, model.LockoutKindAccount, account); err == nil {
+		return &LoginThrottledError{RetryAfter: time.Until(until), Locked: true}
+	}
+	if ip != "" {
+		if until, err := s.redis.GetLoginLock(ctx, model.LockoutKindIP, ip); err == nil {
+			return &LoginThrottledError{RetryAfter: time.Until(until), Locked: true}
+		}
+	}
+
+	delay, err := s.redis.GetLoginDelay(ctx, model.LockoutKindAccount, account)
+	if err != nil {
+		fmt.Printf("warning: failed to read login delay from Redis: %v\n", err)
+		return nil
+	}
+	if delay > 0 {
+		return &LoginThrottledError{RetryAfter: delay}
+	}
+	return nil
+}
+
+// RecordFailure - Ghi nhận một lần đăng nhập sai; trả về LoginThrottledError nếu lần sai này dẫn tới khóa.
+// userID rỗng khi email không tồn tại (vẫn đếm để không làm lộ tài khoản nào tồn tại).
+func (s *LockoutService) RecordFailure(ctx context.Context, email, ip, userID string) error {
+	account := normalizeEmail(email)
+
+	// Đếm theo IP trước: mọi lần sai đều phải được tính cho IP, kể cả khi tài khoản vừa bị khóa
+	ipLocked := false
+	if ip != "" {
+		ipFailures, err := s.redis.IncrementLoginFailures(ctx, model.LockoutKindIP, ip, s.cfg.LoginFailureWindow)
+		if err != nil {
+			fmt.Printf("warning: failed to count login failure in Redis: %v\n", err)
+		} else if ipFailures >= int64(s.cfg.LoginIPMaxFailures) {
+			s.lock(ctx, model.LockoutKindIP, ip, "", ip, ipFailures)
+			ipLocked = true
+		}
+	}
+
+	failures, err := s.redis.IncrementLoginFailures(ctx, model.LockoutKindAccount, account, s.cfg.LoginFailureWindow)
+	if err != nil {
+		fmt.Printf("warning: failed to count login failure in Redis: %v\n", err)
+		failures = 0
+	}
+
+	if failures >= int64(s.cfg.LoginMaxFailures) {
+		s.lock(ctx, model.LockoutKindAccount, account, userID, ip, failures)
+		return &LoginThrottledError{RetryAfter: s.cfg.LoginLockoutDuration, Locked: true}
+	}
+
+	// Độ trễ tăng gấp đôi sau mỗi lần sai: 0, base, 2×base, 4×base, ... (tối đa LoginDelayMax)
+	if failures >= 2 {
+		delay := s.cfg.LoginDelayBase << (failures - 2)
+		if delay <= 0 || delay > s.cfg.LoginDelayMax {
+			delay = s.cfg.LoginDelayMax
+		}
+		if err := s.redis.SetLoginDelay(ctx, model.LockoutKindAccount, account, delay); err != nil {
+			fmt.Printf("warning: failed to store login delay in Redis: %v\n", err)
+		}
+	}
+
+	if ipLocked {
+		return &LoginThrottledError{RetryAfter: s.cfg.LoginLockoutDuration, Locked: true}
+	}
+	return nil
+}
+
+// RecordSuccess - Đăng nhập đúng mật khẩu: xóa bộ đếm sai của tài khoản
+func (s *LockoutService) RecordSuccess(ctx context.Context, email string) {
+	if err := s.redis.ResetLoginFailures(ctx, model.LockoutKindAccount, normalizeEmail(email)); err != nil {
+		fmt.Printf("warning: failed to reset login failures in Redis: %v\n", err)
+	}
+}
+
+// ListLockouts - Các khóa đăng nhập đang hiệu lực
+func (s *LockoutService) ListLockouts(ctx context.Context) ([]model.Lockout, error) {
+	return s.redis.ListLoginLocks(ctx)
+}
+
+// ClearLockout - Admin mở khóa một tài khoản hoặc IP
+func (s *LockoutService) ClearLockout(ctx context.Context, kind, value, actorID string) (err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionLockoutClear, actorID, "", err, model.AuditMetadata{"kind": kind, "value": value})
+	}()
+
+	if kind != model.LockoutKindAccount && kind != model.LockoutKindIP {
+		return errors.New("kind must be account or ip")
+	}
+	if kind == model.LockoutKindAccount {
+		value = normalizeEmail(value)
+	}
+
+	cleared, err := s.redis.ClearLoginLock(ctx, kind, value)
+	if err != nil {
+		return fmt.Errorf("failed to clear lockout in Redis: %v", err)
+	}
+	if !cleared {
+		return ErrLockoutNotFound
+	}
+
+	s.recordEvent(&model.LockoutEvent{
+		Kind:    kind,
+		Value:   value,
+		Action:  model.LockoutActionCleared,
+		ActorID: actorID,
+	})
+	return nil
+}
+
+// ListEvents - Lịch sử khóa / mở khóa gần nhất
+func (s *LockoutService) ListEvents(kind, value string, limit int) ([]model.LockoutEvent, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if kind == model.LockoutKindAccount {
+		value = normalizeEmail(value)
+	}
+	return s.repo.ListEvents(kind, value, limit)
+}
+
+// ExportUserData - Section "lockout_events" của file export: các lần tài khoản bị khóa / mở khóa đăng nhập
+func (s *LockoutService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.repo.ListEventsByUser(userID)
+}
+
+// lock - Khóa tạm thời và ghi lại sự kiện
+func (s *LockoutService) lock(ctx context.Context, kind, value, userID, ip string, failures int64) {
+	until := time.Now().Add(s.cfg.LoginLockoutDuration)
+	if err := s.redis.SetLoginLock(ctx, kind, value, until); err != nil {
+		fmt.Printf("warning: failed to store login lock in Redis: %v\n", err)
+		return
+	}
+
+	s.recordEvent(&model.LockoutEvent{
+		Kind:        kind,
+		Value:       value,
+		Action:      model.LockoutActionLocked,
+		UserID:      userID,
+		IP:          ip,
+		Failures:    failures,
+		LockedUntil: &until,
+	})
+}
+
+func (s *LockoutService) recordEvent(event *model.LockoutEvent) {
+	if err := s.repo.CreateEvent(event); err != nil {
+		fmt.Printf("warning: failed to record lockout event: %v\n", err)
+	}
+}
+
+// normalizeEmail - email dùng làm khóa đếm không phân biệt hoa thường
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newTestLockoutService - khóa tài khoản sau 3 lần sai, khóa IP sau 5 lần sai
+func newTestLockoutService(redis *fakeRedis) *LockoutService {
+	return NewLockoutService(&fakeLockouts{}, redis, nil, config.Config{
+		LoginMaxFailures:     3,
+		LoginIPMaxFailures:   5,
+		LoginFailureWindow:   time.Hour,
+		LoginLockoutDuration: time.Hour,
+		LoginDelayBase:       time.Second,
+		LoginDelayMax:        time.Minute,
+	})
+}
+
+func TestRecordFailureCountsIPAfterAccountLock(t *testing.T) {
+	ctx := context.Background()
+	redis := newFakeRedis()
+	svc := newTestLockoutService(redis)
+
+	// Kẻ tấn công tiếp tục thử trên tài khoản đã bị khóa: các lần này vẫn phải tính cho IP
+	for i := 0; i < 4; i++ {
+		svc.RecordFailure(ctx, "victim@example.com", "203.0.113.7", "")
+	}
+	err := svc.RecordFailure(ctx, "victim@example.com", "203.0.113.7", "")
+
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("RecordFailure() error = %v, want locked", err)
+	}
+	if got := redis.loginFailures[model.LockoutKindIP+":203.0.113.7"]; got != 5 {
+		t.Errorf("ip failures = %d, want 5", got)
+	}
+	if _, ok := redis.loginLocks[model.LockoutKindIP+":203.0.113.7"]; !ok {
+		t.Error("ip was not locked")
+	}
+}
+
+func TestRecordFailureLocksIPAcrossAccounts(t *testing.T) {
+	ctx := context.Background()
+	redis := newFakeRedis()
+	svc := newTestLockoutService(redis)
+
+	var err error
+	for i := 0; i < 5; i++ {
+		err = svc.RecordFailure(ctx, fmt.Sprintf("user%d@example.com", i), "203.0.113.7", "")
+	}
+
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("RecordFailure() error = %v, want locked", err)
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/totp"
+	"base-app/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodeCount     = 10
+	mfaEnrollmentTTL      = 10 * time.Minute
+	mfaMaxChallengeTries  = 5
+	totpReplayWindow      = time.Duration(2*totp.Skew+1) * totp.Period * time.Second
+	mfaMethodTOTP         = "totp"
+	mfaMethodRecoveryCode = "recovery_code"
+)
+
+// TOTPEnrollment là dữ liệu trả về khi bắt đầu bật TOTP
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // dùng làm nội dung mã QR
+}
+
+type MFAService struct {
+	repo    repository.UserRepository
+	mfaRepo repository.MFARepository
+	redis   repository.RedisRepository
+	audit   *AuditService
+	cfg     config.Config
+}
+
+func NewMFAService(repo repository.UserRepository, mfaRepo repository.MFARepository, redisRepo repository.RedisRepository, audit *AuditService, cfg config.Config) *MFAService {
+	return &MFAService{
+		repo:    repo,
+		mfaRepo: mfaRepo,
+		redis:   redisRepo,
+		audit:   audit,
+		cfg:     cfg,
+	}
+}
+
+// BeginTOTPEnrollment - Sinh secret TOTP mới, chỉ có hiệu lực sau khi người dùng xác nhận bằng một mã hợp lệ
+func (s *MFAService) BeginTOTPEnrollment(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate TOTP secret: %v", err)
+	}
+
+	if err := s.redis.SetMFAPendingSecret(ctx, userID, secret, mfaEnrollmentTTL); err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret in Redis: %v", err)
+	}
+
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.cfg.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment - Xác nhận mã đầu tiên, bật MFA và trả về bộ mã khôi phục (chỉ hiển thị một lần)
+func (s *MFAService) ConfirmTOTPEnrollment(ctx context.Context, userID, code string) (codes []string, err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionMFAEnable, userID, userID, err, model.AuditMetadata{"method": mfaMethodTOTP})
+	}()
+
+	secret, err := s.redis.GetMFAPendingSecret(ctx, userID)
+	if err != nil {
+		return nil, errors.New("no pending two-factor enrollment")
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return nil, errors.New("invalid verification code")
+	}
+	if _, err := s.redis.MarkTOTPStepUsed(ctx, userID, step, totpReplayWindow); err != nil {
+		fmt.Printf("warning: failed to mark TOTP code as used in Redis: %v\n", err)
+	}
+
+	if err := s.repo.UpdateMFA(userID, true, secret); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %v", err)
+	}
+
+	if err := s.redis.DeleteMFAPendingSecret(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to delete pending TOTP secret from Redis: %v\n", err)
+	}
+
+	return s.generateRecoveryCodes(userID)
+}
+
+// DisableTOTP - Tắt MFA, yêu cầu một mã TOTP hoặc mã khôi phục hợp lệ
+func (s *MFAService) DisableTOTP(ctx context.Context, userID, code string) (err error) {
+	metadata := model.AuditMetadata{}
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionMFADisable, userID, userID, err, metadata)
+	}()
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !user.MFAEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	method, err := s.VerifyCode(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	metadata["method"] = method
+
+	if err := s.repo.UpdateMFA(userID, false, ""); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %v", err)
+	}
+	if err := s.mfaRepo.DeleteRecoveryCodes(userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes - Sinh lại bộ mã khôi phục, các mã cũ hết hiệu lực
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (codes []string, err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionRecoveryCodes, userID, userID, err, nil)
+	}()
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	if _, err := s.VerifyCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return s.generateRecoveryCodes(userID)
+}
+
+// RecoveryCodesRemaining - Số mã khôi phục còn chưa dùng
+func (s *MFAService) RecoveryCodesRemaining(userID string) (int64, error) {
+	return s.mfaRepo.CountUnusedRecoveryCodes(userID)
+}
+
+// VerifyCode - Kiểm tra mã TOTP hoặc mã khôi phục, trả về phương thức đã dùng
+func (s *MFAService) VerifyCode(ctx context.Context, user *model.User, code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", errors.New("verification code is required")
+	}
+
+	// Thử mã TOTP trước
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now()); ok && user.TOTPSecret != "" {
+		fresh, err := s.redis.MarkTOTPStepUsed(ctx, user.ID, step, totpReplayWindow)
+		if err != nil {
+			return "", fmt.Errorf("failed to check TOTP code in Redis: %v", err)
+		}
+		if !fresh {
+			return "", errors.New("verification code has already been used")
+		}
+		return mfaMethodTOTP, nil
+	}
+
+	// Mã khôi phục: dùng một lần
+	used, err := s.mfaRepo.UseRecoveryCode(user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return "", fmt.Errorf("failed to check recovery code: %v", err)
+	}
+	if used {
+		return mfaMethodRecoveryCode, nil
+	}
+
+	return "", errors.New("invalid verification code")
+}
+
+// RemoveUserData - Xóa dữ liệu MFA của người dùng (khi xóa tài khoản)
+func (s *MFAService) RemoveUserData(ctx context.Context, userID string) error {
+	if err := s.redis.DeleteMFAPendingSecret(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to delete pending TOTP secret from Redis: %v\n", err)
+	}
+	return s.mfaRepo.DeleteRecoveryCodes(userID)
+}
+
+// generateRecoveryCodes - Sinh mã khôi phục mới, chỉ lưu bản băm
+func (s *MFAService) generateRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("could not generate recovery code: %v", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %v", err)
+	}
+	return codes, nil
+}
+
+// newRecoveryCode - Sinh mã dạng "xxxxx-xxxxx" (50 bit ngẫu nhiên, base32 chữ thường)
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return raw[:5] + "-" + raw[5:], nil
+}
+
+// hashRecoveryCode - Chuẩn hóa (bỏ "-", khoảng trắng, chữ hoa) rồi băm SHA-256
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
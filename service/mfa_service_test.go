@@ -0,0 +1,82 @@
+package service
+
+import (
+	"base-app/model"
+	"base-app/pkg/totp"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestVerifyCodeRejectsReplayedTOTP(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	user := &model.User{ID: "user-1", MFAEnabled: true, TOTPSecret: secret}
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("Code() error = %v", err)
+	}
+
+	s := &MFAService{redis: newFakeRedis(), mfaRepo: &fakeMFA{recoveryCodes: map[string]bool{}}}
+
+	method, err := s.VerifyCode(context.Background(), user, code)
+	if err != nil || method != mfaMethodTOTP {
+		t.Fatalf("first VerifyCode() = (%q, %v), want (%q, nil)", method, err, mfaMethodTOTP)
+	}
+	if _, err := s.VerifyCode(context.Background(), user, code); err == nil {
+		t.Fatal("replayed VerifyCode() accepted the same TOTP code twice")
+	}
+
+	// Mỗi người dùng có bộ đánh dấu riêng: cùng bước thời gian của người khác vẫn hợp lệ
+	other := &model.User{ID: "user-2", MFAEnabled: true, TOTPSecret: secret}
+	if _, err := s.VerifyCode(context.Background(), other, code); err != nil {
+		t.Errorf("VerifyCode() for another user error = %v", err)
+	}
+}
+
+func TestVerifyCode(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	current, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("Code() error = %v", err)
+	}
+	stale, err := totp.Code(secret, totp.Step(time.Now())-5)
+	if err != nil {
+		t.Fatalf("Code() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		secret     string
+		code       string
+		wantMethod string
+		wantErr    bool
+	}{
+		{"current totp", secret, current, mfaMethodTOTP, false},
+		{"stale totp", secret, stale, "", true},
+		{"recovery code", secret, "abcd-efgh", mfaMethodRecoveryCode, false},
+		{"unknown recovery code", secret, "zzzz-zzzz", "", true},
+		{"empty code", secret, "  ", "", true},
+		{"totp without secret", "", current, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &MFAService{
+				redis:   newFakeRedis(),
+				mfaRepo: &fakeMFA{recoveryCodes: map[string]bool{hashRecoveryCode("abcd-efgh"): false}},
+			}
+			user := &model.User{ID: "user-1", MFAEnabled: true, TOTPSecret: tt.secret}
+
+			method, err := s.VerifyCode(context.Background(), user, tt.code)
+			if (err != nil) != tt.wantErr || method != tt.wantMethod {
+				t.Errorf("VerifyCode(%q) = (%q, %v), want method %q, error %v", tt.code, method, err, tt.wantMethod, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,617 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/token"
+	"base-app/repository"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Scope hỗ trợ bởi authorization server
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+var supportedScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+// OAuthError là lỗi theo định dạng RFC 6749 (error, error_description).
+// Redirectable = true nghĩa là lỗi được trả về client qua redirect_uri thay vì hiển thị cho người dùng.
+type OAuthError struct {
+	Code         string
+	Description  string
+	Status       int
+	Redirectable bool
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(code, description string, status int) *OAuthError {
+	return &OAuthError{Code: code, Description: description, Status: status}
+}
+
+// AuthorizeRequest là các tham số của authorization request (RFC 6749 mục 4.1.1, RFC 7636)
+type AuthorizeRequest struct {
+	ClientID            string `json:"client_id" query:"client_id"`
+	RedirectURI         string `json:"redirect_uri" query:"redirect_uri"`
+	ResponseType        string `json:"response_type" query:"response_type"`
+	Scope               string `json:"scope" query:"scope"`
+	State               string `json:"state" query:"state"`
+	Nonce               string `json:"nonce" query:"nonce"`
+	CodeChallenge       string `json:"code_challenge" query:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method"`
+}
+
+// AuthorizeDetails là thông tin hiển thị trên màn hình consent
+type AuthorizeDetails struct {
+	Client          *model.OAuthClient
+	Scopes          []string
+	ConsentRequired bool
+}
+
+// TokenRequest là các tham số gửi tới token endpoint
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	ClientID     string
+	ClientSecret string
+	CodeVerifier string
+}
+
+// OAuthTokenResponse là phản hồi của token endpoint
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope"`
+}
+
+// RegisterClientInput là dữ liệu đăng ký client mới
+type RegisterClientInput struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	FirstParty   bool     `json:"first_party"`
+}
+
+// authCodeData là dữ liệu gắn với authorization code, lưu trong Redis
+type authCodeData struct {
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	UserID              string `json:"user_id"`
+	Scope               string `json:"scope"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	AuthTime            int64  `json:"auth_time"`
+}
+
+// oauthAccessData là dữ liệu gắn với access token cấp cho client
+type oauthAccessData struct {
+	UserID   string `json:"user_id"`
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+}
+
+type OAuthService struct {
+	oauthRepo repository.OAuthRepository
+	repo      repository.UserRepository
+	redis     repository.RedisRepository
+	tokens    *token.Manager
+	cfg       config.Config
+}
+
+func NewOAuthService(oauthRepo repository.OAuthRepository, repo repository.UserRepository, redisRepo repository.RedisRepository, tokens *token.Manager, cfg config.Config) *OAuthService {
+	return &OAuthService{
+		oauthRepo: oauthRepo,
+		repo:      repo,
+		redis:     redisRepo,
+		tokens:    tokens,
+		cfg:       cfg,
+	}
+}
+
+// ======================= DISCOVERY =======================
+
+// Discovery - Tài liệu /.well-known/openid-configuration
+func (s *OAuthService) Discovery() map[string]interface{} {
+	issuer := strings.TrimRight(s.cfg.OIDCIssuer, "/")
+	return map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/oauth/authorize",
+		"token_endpoint":                        issuer + "/oauth/token",
+		"userinfo_endpoint":                     issuer + "/oauth/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": token.Algorithms(),
+		"scopes_supported":                      supportedScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "name", "email", "email_verified"},
+	}
+}
+
+// ======================= AUTHORIZATION =======================
+
+// ValidateAuthorizeRequest - Kiểm tra authorization request, trả về client và danh sách scope được yêu cầu
+func (s *OAuthService) ValidateAuthorizeRequest(req AuthorizeRequest) (*model.OAuthClient, []string, error) {
+	// Lỗi client_id / redirect_uri không được redirect về client (RFC 6749 mục 4.1.2.1)
+	client, err := s.oauthRepo.FindClientByID(req.ClientID)
+	if err != nil {
+		return nil, nil, newOAuthError("invalid_request", "unknown client_id", http.StatusBadRequest)
+	}
+	if !containsString(client.RedirectURIList(), req.RedirectURI) {
+		return nil, nil, newOAuthError("invalid_request", "redirect_uri is not registered for this client", http.StatusBadRequest)
+	}
+
+	redirectable := func(code, description string) error {
+		e := newOAuthError(code, description, http.StatusBadRequest)
+		e.Redirectable = true
+		return e
+	}
+
+	if req.ResponseType != "code" {
+		return nil, nil, redirectable("unsupported_response_type", "only response_type=code is supported")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		return nil, nil, redirectable("invalid_scope", "scope is required")
+	}
+	for _, scope := range scopes {
+		if !containsString(supportedScopes, scope) || !containsString(client.ScopeList(), scope) {
+			return nil, nil, redirectable("invalid_scope", "scope "+scope+" is not allowed")
+		}
+	}
+
+	// PKCE: bắt buộc với public client, chỉ hỗ trợ S256
+	if req.CodeChallenge == "" && client.IsPublic() {
+		return nil, nil, redirectable("invalid_request", "code_challenge is required for public clients")
+	}
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		return nil, nil, redirectable("invalid_request", "code_challenge_method must be S256")
+	}
+
+	return client, scopes, nil
+}
+
+// ConsentURL - URL trang consent của frontend, giữ nguyên các tham số của authorization request
+func (s *OAuthService) ConsentURL(rawQuery string) string {
+	consentURL := s.cfg.OAuthConsentURL
+	if strings.Contains(consentURL, "?") {
+		return consentURL + "&" + rawQuery
+	}
+	return consentURL + "?" + rawQuery
+}
+
+// ErrorRedirectURL - Trả lỗi về client qua redirect_uri
+func (s *OAuthService) ErrorRedirectURL(req AuthorizeRequest, oauthErr *OAuthError) string {
+	return buildRedirectURL(req.RedirectURI, map[string]string{
+		"error":             oauthErr.Code,
+		"error_description": oauthErr.Description,
+		"state":             req.State,
+	})
+}
+
+// AuthorizeDetails - Thông tin client và scope để frontend hiển thị màn hình consent
+func (s *OAuthService) AuthorizeDetails(ctx context.Context, userID string, req AuthorizeRequest) (*AuthorizeDetails, error) {
+	client, scopes, err := s.ValidateAuthorizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorizeDetails{
+		Client:          client,
+		Scopes:          scopes,
+		ConsentRequired: s.consentRequired(userID, client, scopes),
+	}, nil
+}
+
+// Authorize - Người dùng (đã đăng nhập) đồng ý hoặc từ chối; trả về URL redirect kèm code hoặc lỗi
+func (s *OAuthService) Authorize(ctx context.Context, userID string, authTime time.Time, req AuthorizeRequest, approve bool) (string, error) {
+	client, scopes, err := s.ValidateAuthorizeRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	if !approve {
+		return s.ErrorRedirectURL(req, newOAuthError("access_denied", "the user denied the request", http.StatusBadRequest)), nil
+	}
+
+	// Lưu consent để lần sau không phải hỏi lại
+	if !client.FirstParty {
+		if err := s.oauthRepo.SaveConsent(userID, client.ID, strings.Join(mergeScopes(s.grantedScopes(userID, client.ID), scopes), " ")); err != nil {
+			return "", fmt.Errorf("failed to save consent: %v", err)
+		}
+	}
+
+	code, err := generateRandomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate authorization code: %v", err)
+	}
+
+	data, err := json.Marshal(authCodeData{
+		ClientID:            client.ID,
+		RedirectURI:         req.RedirectURI,
+		UserID:              userID,
+		Scope:               strings.Join(scopes, " "),
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		AuthTime:            authTime.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.SetOAuthCode(ctx, hashToken(code), string(data), s.cfg.OAuthCodeTTL); err != nil {
+		return "", fmt.Errorf("failed to store authorization code in Redis: %v", err)
+	}
+
+	return buildRedirectURL(req.RedirectURI, map[string]string{
+		"code":  code,
+		"state": req.State,
+	}), nil
+}
+
+// ======================= TOKEN =======================
+
+// Exchange - Token endpoint: đổi authorization code lấy access token và ID token
+func (s *OAuthService) Exchange(ctx context.Context, req TokenRequest) (*OAuthTokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, newOAuthError("unsupported_grant_type", "only authorization_code is supported", http.StatusBadRequest)
+	}
+
+	client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := s.redis.ConsumeOAuthCode(ctx, hashToken(req.Code))
+	if err != nil {
+		return nil, newOAuthError("invalid_grant", "authorization code is invalid or expired", http.StatusBadRequest)
+	}
+
+	var data authCodeData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, newOAuthError("invalid_grant", "authorization code is invalid", http.StatusBadRequest)
+	}
+
+	if data.ClientID != client.ID || data.RedirectURI != req.RedirectURI {
+		return nil, newOAuthError("invalid_grant", "authorization code was issued to another client or redirect_uri", http.StatusBadRequest)
+	}
+
+	// PKCE (RFC 7636 mục 4.6)
+	if data.CodeChallenge != "" || client.IsPublic() {
+		if !verifyPKCE(req.CodeVerifier, data.CodeChallenge) {
+			return nil, newOAuthError("invalid_grant", "code_verifier does not match code_challenge", http.StatusBadRequest)
+		}
+	}
+
+	user, err := s.repo.FindByID(data.UserID)
+	if err != nil {
+		return nil, newOAuthError("invalid_grant", "user no longer exists", http.StatusBadRequest)
+	}
+	// Code cấp trước khi tài khoản bị vô hiệu hóa hoặc yêu cầu xóa thì không còn đổi được token
+	if user.Status == model.UserStatusDisabled || user.Status == model.UserStatusPendingDeletion {
+		return nil, newOAuthError("invalid_grant", "user account is not active", http.StatusBadRequest)
+	}
+
+	// Access token dạng opaque, chỉ dùng cho /oauth/userinfo, không dùng được cho API nội bộ
+	accessToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate access token: %v", err)
+	}
+	accessData, err := json.Marshal(oauthAccessData{UserID: user.ID, ClientID: client.ID, Scope: data.Scope})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redis.SetOAuthAccessToken(ctx, user.ID, client.ID, hashToken(accessToken), string(accessData), s.cfg.OAuthAccessTokenTTL); err != nil {
+		return nil, fmt.Errorf("failed to store access token in Redis: %v", err)
+	}
+
+	resp := &OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.OAuthAccessTokenTTL.Seconds()),
+		Scope:       data.Scope,
+	}
+
+	if containsString(strings.Fields(data.Scope), ScopeOpenID) {
+		idToken, err := s.issueIDToken(user, client.ID, data)
+		if err != nil {
+			return nil, err
+		}
+		resp.IDToken = idToken
+	}
+	return resp, nil
+}
+
+// UserInfo - Trả về claims của người dùng theo scope của access token
+func (s *OAuthService) UserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	raw, err := s.redis.GetOAuthAccessToken(ctx, hashToken(accessToken))
+	if err != nil {
+		return nil, newOAuthError("invalid_token", "access token is invalid or expired", http.StatusUnauthorized)
+	}
+
+	var data oauthAccessData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, newOAuthError("invalid_token", "access token is invalid", http.StatusUnauthorized)
+	}
+
+	scopes := strings.Fields(data.Scope)
+	if !containsString(scopes, ScopeOpenID) {
+		return nil, newOAuthError("insufficient_scope", "openid scope is required", http.StatusForbidden)
+	}
+
+	user, err := s.repo.FindByID(data.UserID)
+	if err != nil {
+		return nil, newOAuthError("invalid_token", "user no longer exists", http.StatusUnauthorized)
+	}
+	if user.Status == model.UserStatusDisabled || user.Status == model.UserStatusPendingDeletion {
+		return nil, newOAuthError("invalid_token", "user account is not active", http.StatusUnauthorized)
+	}
+
+	info := map[string]interface{}{"sub": user.ID}
+	if containsString(scopes, ScopeProfile) {
+		info["name"] = user.Name
+		info["updated_at"] = user.UpdatedAt.Unix()
+	}
+	if containsString(scopes, ScopeEmail) {
+		info["email"] = user.Email
+		info["email_verified"] = user.EmailVerified
+	}
+	return info, nil
+}
+
+// issueIDToken - Ký ID token cho client
+func (s *OAuthService) issueIDToken(user *model.User, clientID string, data authCodeData) (string, error) {
+	now := time.Now()
+	scopes := strings.Fields(data.Scope)
+
+	claims := &token.IDTokenClaims{
+		Nonce:    data.Nonce,
+		AuthTime: data.AuthTime,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    strings.TrimRight(s.cfg.OIDCIssuer, "/"),
+			Subject:   user.ID,
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.OAuthAccessTokenTTL)),
+			ID:        uuid.New().String(),
+		},
+	}
+	if containsString(scopes, ScopeProfile) {
+		claims.Name = user.Name
+	}
+	if containsString(scopes, ScopeEmail) {
+		verified := user.EmailVerified
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+
+	idToken, err := s.tokens.SignIDToken(claims)
+	if err != nil {
+		return "", fmt.Errorf("error signing id token: %v", err)
+	}
+	return idToken, nil
+}
+
+// authenticateClient - Xác thực client tại token endpoint (client_secret_basic / client_secret_post / none)
+func (s *OAuthService) authenticateClient(clientID, clientSecret string) (*model.OAuthClient, error) {
+	client, err := s.oauthRepo.FindClientByID(clientID)
+	if err != nil {
+		return nil, newOAuthError("invalid_client", "client authentication failed", http.StatusUnauthorized)
+	}
+
+	if client.IsPublic() {
+		return client, nil
+	}
+
+	if clientSecret == "" || bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(clientSecret)) != nil {
+		return nil, newOAuthError("invalid_client", "client authentication failed", http.StatusUnauthorized)
+	}
+	return client, nil
+}
+
+// ======================= CLIENT & CONSENT MANAGEMENT =======================
+
+// RegisterClient - Đăng ký client mới, trả về client secret (chỉ hiển thị một lần) nếu là confidential client
+func (s *OAuthService) RegisterClient(ownerID string, input RegisterClientInput) (*model.OAuthClient, string, error) {
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, "", errors.New("name is required")
+	}
+	if input.Type == "" {
+		input.Type = model.OAuthClientConfidential
+	}
+	if input.Type != model.OAuthClientConfidential && input.Type != model.OAuthClientPublic {
+		return nil, "", errors.New("type must be confidential or public")
+	}
+	if len(input.RedirectURIs) == 0 {
+		return nil, "", errors.New("at least one redirect_uri is required")
+	}
+	for _, uri := range input.RedirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return nil, "", err
+		}
+	}
+	if len(input.Scopes) == 0 {
+		input.Scopes = supportedScopes
+	}
+	for _, scope := range input.Scopes {
+		if !containsString(supportedScopes, scope) {
+			return nil, "", fmt.Errorf("unsupported scope %q", scope)
+		}
+	}
+
+	client := &model.OAuthClient{
+		ID:           uuid.New().String(),
+		Name:         strings.TrimSpace(input.Name),
+		Type:         input.Type,
+		RedirectURIs: strings.Join(input.RedirectURIs, " "),
+		Scopes:       strings.Join(input.Scopes, " "),
+		FirstParty:   input.FirstParty,
+		OwnerID:      ownerID,
+	}
+
+	var secret string
+	if client.Type == model.OAuthClientConfidential {
+		var err error
+		secret, err = generateRandomToken(32)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not generate client secret: %v", err)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not hash client secret: %v", err)
+		}
+		client.SecretHash = string(hash)
+	}
+
+	if err := s.oauthRepo.CreateClient(client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+// ListClients - Danh sách client đã đăng ký
+func (s *OAuthService) ListClients() ([]model.OAuthClient, error) {
+	return s.oauthRepo.ListClients()
+}
+
+// DeleteClient - Xóa client cùng các consent liên quan
+func (s *OAuthService) DeleteClient(clientID string) error {
+	if _, err := s.oauthRepo.FindClientByID(clientID); err != nil {
+		return err
+	}
+	return s.oauthRepo.DeleteClient(clientID)
+}
+
+// ListConsents - Các ứng dụng người dùng đã cấp quyền
+func (s *OAuthService) ListConsents(userID string) ([]model.OAuthConsent, error) {
+	return s.oauthRepo.ListConsents(userID)
+}
+
+// ExportUserData - Section "oauth_consents" của file export: các ứng dụng bên thứ ba đã được cấp quyền
+func (s *OAuthService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.ListConsents(userID)
+}
+
+// RevokeConsent - Thu hồi quyền đã cấp cho một ứng dụng (lần sau sẽ phải đồng ý lại)
+// và vô hiệu hóa các access token ứng dụng đó đang giữ
+func (s *OAuthService) RevokeConsent(ctx context.Context, userID, clientID string) error {
+	if err := s.oauthRepo.DeleteConsent(userID, clientID); err != nil {
+		return err
+	}
+	if err := s.redis.RevokeOAuthAccessTokens(ctx, userID, clientID); err != nil {
+		return fmt.Errorf("failed to revoke oauth access tokens in Redis: %v", err)
+	}
+	return nil
+}
+
+// consentRequired - Ứng dụng nội bộ hoặc scope đã được đồng ý trước đó thì không cần hỏi lại
+func (s *OAuthService) consentRequired(userID string, client *model.OAuthClient, scopes []string) bool {
+	if client.FirstParty {
+		return false
+	}
+	granted := s.grantedScopes(userID, client.ID)
+	for _, scope := range scopes {
+		if !containsString(granted, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *OAuthService) grantedScopes(userID, clientID string) []string {
+	consent, err := s.oauthRepo.FindConsent(userID, clientID)
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(consent.Scopes)
+}
+
+// ======================= HELPERS =======================
+
+// verifyPKCE - BASE64URL(SHA256(code_verifier)) == code_challenge
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 || challenge == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// validateRedirectURI - URL tuyệt đối, không có fragment, bắt buộc https trừ localhost
+func validateRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid redirect_uri %q", raw)
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("redirect_uri %q must not contain a fragment", raw)
+	}
+	host := u.Hostname()
+	if u.Scheme != "https" && host != "localhost" && host != "127.0.0.1" && host != "::1" {
+		return fmt.Errorf("redirect_uri %q must use https", raw)
+	}
+	return nil
+}
+
+// buildRedirectURL - Thêm tham số vào redirect_uri, giữ nguyên query sẵn có
+func buildRedirectURL(base string, params map[string]string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	query := u.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeScopes(a, b []string) []string {
+	merged := append([]string{}, a...)
+	for _, scope := range b {
+		if !containsString(merged, scope) {
+			merged = append(merged, scope)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// Ví dụ ở phụ lục B RFC 7636
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"rfc 7636 vector", verifier, challenge, true},
+		{"wrong verifier", strings.Replace(verifier, "d", "e", 1), challenge, false},
+		{"plain method is not accepted", verifier, verifier, false},
+		{"padded challenge", verifier, challenge + "=", false},
+		{"empty challenge", verifier, "", false},
+		{"empty verifier", "", challenge, false},
+		{"verifier shorter than 43 characters", verifier[:42], challenge, false},
+		{"verifier longer than 128 characters", strings.Repeat("a", 129), challenge, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPKCE(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("verifyPKCE(%q, %q) = %v, want %v", tt.verifier, tt.challenge, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRedirectURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		wantErr bool
+	}{
+		{"https://app.example.com/callback", false},
+		{"http://localhost:3000/callback", false},
+		{"http://127.0.0.1/callback", false},
+		{"http://app.example.com/callback", true},
+		{"https://app.example.com/callback#token", true},
+		{"/callback", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if err := validateRedirectURI(tt.uri); (err != nil) != tt.wantErr {
+			t.Errorf("validateRedirectURI(%q) error = %v, want error %v", tt.uri, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUserInfoRejectsInactiveAccount(t *testing.T) {
+	statuses := []string{model.UserStatusActive, model.UserStatusDisabled, model.UserStatusPendingDeletion}
+
+	for _, status := range statuses {
+		t.Run(status, func(t *testing.T) {
+			ctx := context.Background()
+			user := &model.User{ID: "user-1", Email: "user@example.com", Status: status}
+			redis := newFakeRedis()
+			svc := NewOAuthService(nil, newFakeUsers(user), redis, nil, config.Config{})
+
+			data, _ := json.Marshal(oauthAccessData{UserID: user.ID, ClientID: "client-1", Scope: "openid email"})
+			if err := redis.SetOAuthAccessToken(ctx, user.ID, "client-1", hashToken("access-token"), string(data), time.Hour); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := svc.UserInfo(ctx, "access-token")
+			if status == model.UserStatusActive {
+				if err != nil {
+					t.Fatalf("UserInfo() error = %v", err)
+				}
+				if info["sub"] != user.ID {
+					t.Errorf("sub = %v, want %s", info["sub"], user.ID)
+				}
+				return
+			}
+
+			var oauthErr *OAuthError
+			if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_token" || oauthErr.Status != http.StatusUnauthorized {
+				t.Fatalf("UserInfo() error = %v, want invalid_token", err)
+			}
+		})
+	}
+}
+
+func TestRevokeConsentRevokesAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: "user-1", Email: "user@example.com", Status: model.UserStatusActive}
+	redis := newFakeRedis()
+	svc := NewOAuthService(fakeOAuth{}, newFakeUsers(user), redis, nil, config.Config{})
+
+	for token, clientID := range map[string]string{"revoked-token": "client-1", "other-token": "client-2"} {
+		data, _ := json.Marshal(oauthAccessData{UserID: user.ID, ClientID: clientID, Scope: "openid"})
+		if err := redis.SetOAuthAccessToken(ctx, user.ID, clientID, hashToken(token), string(data), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := svc.RevokeConsent(ctx, user.ID, "client-1"); err != nil {
+		t.Fatalf("RevokeConsent() error = %v", err)
+	}
+
+	if _, err := svc.UserInfo(ctx, "revoked-token"); err == nil {
+		t.Error("access token of the revoked client still works")
+	}
+	if _, err := svc.UserInfo(ctx, "other-token"); err != nil {
+		t.Errorf("access token of another client was revoked: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"base-app/model"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrNotOrganizationMember = errors.New("you are not a member of this organization")
+	ErrOrgPermissionDenied   = errors.New("your organization role does not allow this action")
+	ErrLastOrganizationOwner = errors.New("an organization must keep at least one owner")
+	ErrMemberNotFound        = errors.New("member not found")
+)
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+type OrganizationService struct {
+	orgs  repository.OrganizationRepository
+	repo  repository.UserRepository
+	redis repository.RedisRepository
+	users *UserService
+}
+
+func NewOrganizationService(orgs repository.OrganizationRepository, repo repository.UserRepository, redisRepo repository.RedisRepository, users *UserService) *OrganizationService {
+	return &OrganizationService{
+		orgs:  orgs,
+		repo:  repo,
+		redis: redisRepo,
+		users: users,
+	}
+}
+
+// Create - Tạo tổ chức mới, người tạo trở thành owner
+func (s *OrganizationService) Create(userID, name string) (*model.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, errors.New("name must be between 1 and 100 characters")
+	}
+
+	slug, err := s.uniqueSlug(name)
+	if err != nil {
+		return nil, err
+	}
+
+	org := &model.Organization{
+		Name:      name,
+		Slug:      slug,
+		CreatedBy: userID,
+	}
+	if err := s.orgs.Create(org, userID); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %v", err)
+	}
+	return org, nil
+}
+
+// List - Các tổ chức người dùng tham gia, đánh dấu tổ chức đang hoạt động của phiên hiện tại
+func (s *OrganizationService) List(userID, activeOrgID string) ([]model.OrganizationMembership, error) {
+	orgs, err := s.orgs.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orgs {
+		orgs[i].Active = orgs[i].ID == activeOrgID
+	}
+	return orgs, nil
+}
+
+// ExportUserData - Section "organizations" của file export: các tổ chức và vai trò của người dùng
+func (s *OrganizationService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.orgs.ListByUser(userID)
+}
+
+// Switch - Đổi tổ chức đang hoạt động của phiên và cấp access token mới mang org_id / org_role mới.
+// Access token cũ bị thu hồi; refresh token của phiên vẫn dùng được và sẽ cấp token cho tổ chức mới.
+func (s *OrganizationService) Switch(ctx context.Context, userID, sessionID, currentToken, orgID string) (*AuthTokens, error) {
+	if _, err := s.orgs.FindMembership(orgID, userID); err != nil {
+		return nil, ErrNotOrganizationMember
+	}
+
+	if err := s.redis.SetSessionOrganization(ctx, sessionID, orgID); err != nil {
+		return nil, fmt.Errorf("failed to store active organization in Redis: %v", err)
+	}
+	if err := s.repo.SetActiveOrganization(userID, orgID); err != nil {
+		fmt.Printf("warning: failed to remember active organization: %v\n", err)
+	}
+
+	tokens, err := s.users.ReissueAccessToken(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.redis.RevokeToken(ctx, currentToken); err != nil {
+		fmt.Printf("warning: failed to revoke previous access token: %v\n", err)
+	}
+	if err := s.redis.RemoveTokenFromUser(ctx, userID, currentToken); err != nil {
+		fmt.Printf("warning: failed to remove token from user's list in Redis: %v\n", err)
+	}
+	return tokens, nil
+}
+
+// Current - Tổ chức đang hoạt động kèm vai trò hiện tại của người dùng
+func (s *OrganizationService) Current(orgID, userID string) (*model.OrganizationMembership, error) {
+	membership, err := s.membership(orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.orgs.FindByID(orgID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return &model.OrganizationMembership{Organization: *org, Role: membership.Role, Active: true}, nil
+}
+
+// ListMembers - Thành viên của tổ chức, chỉ thành viên mới xem được
+func (s *OrganizationService) ListMembers(orgID, actorID string) ([]model.Member, error) {
+	if _, err := s.membership(orgID, actorID); err != nil {
+		return nil, err
+	}
+	return s.orgs.ListMembers(orgID)
+}
+
+// UpdateMemberRole - Đổi vai trò thành viên. Owner / admin mới được đổi; chỉ owner mới được
+// cấp hoặc thu hồi vai trò owner và tổ chức luôn phải còn ít nhất một owner.
+func (s *OrganizationService) UpdateMemberRole(orgID, actorID, userID, role string) error {
+	if model.OrgRoleRank(role) < 0 {
+		return fmt.Errorf("role must be one of %s", strings.Join(model.OrgRoles, ", "))
+	}
+
+	actor, err := s.membership(orgID, actorID)
+	if err != nil {
+		return err
+	}
+	target, err := s.orgs.FindMembership(orgID, userID)
+	if err != nil {
+		return ErrMemberNotFound
+	}
+	if err := s.canManage(actor, target, role); err != nil {
+		return err
+	}
+	if target.Role == model.OrgRoleOwner && role != model.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(orgID); err != nil {
+			return err
+		}
+	}
+
+	return s.orgs.UpdateMemberRole(orgID, userID, role)
+}
+
+// RemoveMember - Xóa thành viên khỏi tổ chức (owner / admin), hoặc tự rời tổ chức
+func (s *OrganizationService) RemoveMember(orgID, actorID, userID string) error {
+	actor, err := s.membership(orgID, actorID)
+	if err != nil {
+		return err
+	}
+	target, err := s.orgs.FindMembership(orgID, userID)
+	if err != nil {
+		return ErrMemberNotFound
+	}
+	if actorID != userID {
+		if err := s.canManage(actor, target, target.Role); err != nil {
+			return err
+		}
+	}
+	if target.Role == model.OrgRoleOwner {
+		if err := s.ensureAnotherOwner(orgID); err != nil {
+			return err
+		}
+	}
+
+	return s.orgs.RemoveMember(orgID, userID)
+}
+
+// membership - Tư cách thành viên của người dùng trong tổ chức (đọc từ Postgres, không tin org_role trong token)
+func (s *OrganizationService) membership(orgID, userID string) (*model.Membership, error) {
+	if orgID == "" {
+		return nil, ErrOrganizationNotFound
+	}
+	membership, err := s.orgs.FindMembership(orgID, userID)
+	if err != nil {
+		return nil, ErrNotOrganizationMember
+	}
+	return membership, nil
+}
+
+// canManage - actor phải là owner / admin; admin không được tác động tới owner hoặc cấp vai trò owner
+func (s *OrganizationService) canManage(actor, target *model.Membership, newRole string) error {
+	if actor.Role == model.OrgRoleOwner {
+		return nil
+	}
+	if actor.Role != model.OrgRoleAdmin {
+		return ErrOrgPermissionDenied
+	}
+	if target.Role == model.OrgRoleOwner || newRole == model.OrgRoleOwner {
+		return ErrOrgPermissionDenied
+	}
+	return nil
+}
+
+// ensureAnotherOwner - Tổ chức còn owner khác ngoài owner sắp bị đổi vai trò / xóa
+func (s *OrganizationService) ensureAnotherOwner(orgID string) error {
+	owners, err := s.orgs.CountMembersWithRole(orgID, model.OrgRoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to count owners: %v", err)
+	}
+	if owners <= 1 {
+		return ErrLastOrganizationOwner
+	}
+	return nil
+}
+
+// orgManager - Tư cách thành viên owner / admin của người dùng trong tổ chức (quản lý thành viên, lời mời)
+func orgManager(orgs repository.OrganizationRepository, orgID, userID string) (*model.Membership, error) {
+	if orgID == "" {
+		return nil, ErrOrganizationNotFound
+	}
+	membership, err := orgs.FindMembership(orgID, userID)
+	if err != nil {
+		return nil, ErrNotOrganizationMember
+	}
+	if membership.Role != model.OrgRoleOwner && membership.Role != model.OrgRoleAdmin {
+		return nil, ErrOrgPermissionDenied
+	}
+	return membership, nil
+}
+
+// uniqueSlug - Sinh slug từ tên, thêm hậu tố ngẫu nhiên nếu đã tồn tại
+func (s *OrganizationService) uniqueSlug(name string) (string, error) {
+	base := strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if base == "" {
+		base = "org"
+	}
+	if len(base) > 40 {
+		base = strings.TrimRight(base[:40], "-")
+	}
+
+	slug := base
+	for i := 0; i < 5; i++ {
+		exists, err := s.orgs.SlugExists(slug)
+		if err != nil {
+			return "", fmt.Errorf("failed to check organization slug: %v", err)
+		}
+		if !exists {
+			return slug, nil
+		}
+		suffix, err := generateRandomToken(4)
+		if err != nil {
+			return "", err
+		}
+		slug = base + "-" + strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(suffix))
+	}
+	return "", errors.New("could not generate a unique organization slug")
+}
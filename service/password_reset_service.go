@@ -0,0 +1,162 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/mailer"
+	"base-app/pkg/passwordhash"
+	"base-app/pkg/passwordpolicy"
+	"base-app/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Giới hạn số lần yêu cầu đặt lại mật khẩu cho mỗi email
+	forgotPasswordLimit  = 5
+	forgotPasswordWindow = time.Hour
+)
+
+// ErrInvalidResetToken - token đặt lại mật khẩu không tồn tại, hết hạn hoặc đã dùng
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+type PasswordResetService struct {
+	repo      repository.UserRepository
+	redis     repository.RedisRepository
+	mailer    mailer.Mailer
+	passwords *passwordpolicy.Policy
+	hasher    passwordhash.PasswordHasher
+	audit     *AuditService
+	cfg       config.Config
+}
+
+func NewPasswordResetService(repo repository.UserRepository, redisRepo repository.RedisRepository, mail mailer.Mailer, passwords *passwordpolicy.Policy, hasher passwordhash.PasswordHasher, audit *AuditService, cfg config.Config) *PasswordResetService {
+	return &PasswordResetService{
+		repo:      repo,
+		redis:     redisRepo,
+		mailer:    mail,
+		passwords: passwords,
+		hasher:    hasher,
+		audit:     audit,
+		cfg:       cfg,
+	}
+}
+
+// ForgotPassword - Gửi link đặt lại mật khẩu. Kết quả trả về giống nhau dù email có tồn tại hay không.
+func (s *PasswordResetService) ForgotPassword(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("email is required")
+	}
+
+	count, err := s.redis.IncrementRate(ctx, "rate:forgot_password:"+strings.ToLower(email), forgotPasswordWindow)
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit in Redis: %v", err)
+	}
+	if count > forgotPasswordLimit {
+		return ErrTooManyRequests
+	}
+
+	user, err := s.repo.FindByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	// Token ngẫu nhiên gửi cho người dùng, Redis chỉ lưu bản băm
+	resetToken, err := generateRandomToken(32)
+	if err != nil {
+		return fmt.Errorf("could not generate reset token: %v", err)
+	}
+	if err := s.redis.SetPasswordResetToken(ctx, hashToken(resetToken), user.ID, s.cfg.PasswordResetTTL); err != nil {
+		return fmt.Errorf("failed to store reset token in Redis: %v", err)
+	}
+
+	link := strings.TrimRight(s.cfg.AppBaseURL, "/") + "/reset-password?token=" + url.QueryEscape(resetToken)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThis link expires in %s and can only be used once.\nIf you did not request a password reset, you can ignore this email.\n",
+			user.Name, link, s.cfg.PasswordResetTTL),
+	}
+
+	// Gửi email ở nền để thời gian phản hồi không tiết lộ email có tồn tại hay không
+	go func() {
+		if err := s.mailer.Send(context.Background(), msg); err != nil {
+			fmt.Printf("warning: failed to send password reset email: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword - Đặt mật khẩu mới bằng token một lần, sau đó thu hồi toàn bộ phiên đăng nhập
+func (s *PasswordResetService) ResetPassword(ctx context.Context, resetToken, newPassword string) (err error) {
+	tokenHash := hashToken(resetToken)
+
+	// Token không hợp lệ thì không biết tài khoản nào, sự kiện ghi với actor rỗng
+	var userID string
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionPasswordReset, userID, userID, err, nil)
+	}()
+
+	// Kiểm tra chính sách mật khẩu trước khi dùng token, mật khẩu không đạt thì người dùng vẫn thử lại được với link cũ
+	userID, err = s.redis.GetPasswordResetToken(ctx, tokenHash)
+	if errors.Is(err, repository.ErrPasswordResetTokenNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read reset token from Redis: %v", err)
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if err := s.passwords.Validate("new_password", passwordpolicy.Input{Password: newPassword, Email: user.Email, Name: user.Name}); err != nil {
+		return err
+	}
+
+	// Token có thể vừa được dùng bởi một request khác
+	consumedUserID, err := s.redis.ConsumePasswordResetToken(ctx, tokenHash)
+	if errors.Is(err, repository.ErrPasswordResetTokenNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read reset token from Redis: %v", err)
+	}
+	if consumedUserID != userID {
+		return ErrInvalidResetToken
+	}
+
+	// Mã hóa mật khẩu mới
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	if err := s.repo.ResetPassword(userID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+
+	// Đăng xuất mọi phiên: kẻ đã chiếm tài khoản (nếu có) không còn giữ được token cũ
+	if err := s.redis.RevokeAllUserTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke user tokens in Redis: %v\n", err)
+	}
+	if err := s.redis.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke user refresh tokens in Redis: %v\n", err)
+	}
+	if err := s.redis.DeleteUserProfile(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to clear cached profile: %v\n", err)
+	}
+	return nil
+}
+
+// hashToken - Băm SHA-256 token ngẫu nhiên trước khi lưu (token có entropy cao nên không cần salt)
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExists   = errors.New("role already exists")
+	ErrSystemRole   = errors.New("built-in roles cannot be deleted or have their permissions changed")
+	ErrRoleInUse    = errors.New("role is still assigned to users")
+)
+
+var (
+	roleNamePattern   = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+	permissionPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*:([a-z][a-z0-9_]*|\*)$`)
+)
+
+// defaultRoles - vai trò luôn tồn tại; admin có mọi quyền, user không có quyền quản trị nào
+var defaultRoles = []model.Role{
+	{Name: model.RoleAdmin, Description: "Administrator", System: true, Permissions: []string{model.PermissionAll}},
+	{Name: model.RoleUser, Description: "Regular user", System: true, Permissions: []string{}},
+}
+
+// CreateRoleInput là dữ liệu tạo vai trò mới
+type CreateRoleInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleService struct {
+	roles repository.RoleRepository
+	users repository.UserRepository
+	redis repository.RedisRepository
+	audit *AuditService
+	cfg   config.Config
+}
+
+func NewRoleService(roles repository.RoleRepository, users repository.UserRepository, redisRepo repository.RedisRepository, audit *AuditService, cfg config.Config) *RoleService {
+	return &RoleService{
+		roles: roles,
+		users: users,
+		redis: redisRepo,
+		audit: audit,
+		cfg:   cfg,
+	}
+}
+
+// EnsureDefaults - Tạo các vai trò mặc định nếu chưa có (gọi lúc khởi động)
+func (s *RoleService) EnsureDefaults() error {
+	for _, role := range defaultRoles {
+		if _, err := s.roles.FindByName(role.Name); err == nil {
+			continue
+		}
+		role := role
+		if err := s.roles.Create(&role); err != nil {
+			return fmt.Errorf("failed to create role %q: %v", role.Name, err)
+		}
+	}
+	return nil
+}
+
+// HasPermission - Kiểm tra vai trò có quyền hay không, đọc từ cache Redis, cache miss thì đọc Postgres
+func (s *RoleService) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	permissions, err := s.permissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	for _, granted := range permissions {
+		if model.PermissionGrants(granted, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasAnyPermission - Vai trò có ít nhất một quyền quản trị hay không
+func (s *RoleService) HasAnyPermission(ctx context.Context, role string) (bool, error) {
+	permissions, err := s.permissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return len(permissions) > 0, nil
+}
+
+// List - Danh sách vai trò kèm quyền
+func (s *RoleService) List() ([]model.Role, error) {
+	return s.roles.List()
+}
+
+// Get - Chi tiết một vai trò
+func (s *RoleService) Get(name string) (*model.Role, error) {
+	role, err := s.roles.FindByName(name)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// Create - Tạo vai trò mới
+func (s *RoleService) Create(ctx context.Context, actorID string, input CreateRoleInput) (role *model.Role, err error) {
+	name := strings.TrimSpace(input.Name)
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionRoleCreate, actorID, "", err, model.AuditMetadata{
+			"role":        name,
+			"permissions": input.Permissions,
+		})
+	}()
+
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("name must be 2-32 lowercase letters, digits, '-' or '_' and start with a letter")
+	}
+	if _, err := s.roles.FindByName(name); err == nil {
+		return nil, ErrRoleExists
+	}
+
+	permissions := []string{}
+	for _, permission := range input.Permissions {
+		if err := validatePermission(permission); err != nil {
+			return nil, err
+		}
+		if !containsString(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	role = &model.Role{
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+		Permissions: permissions,
+	}
+	if err := s.roles.Create(role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %v", err)
+	}
+
+	s.invalidate(ctx, name)
+	return role, nil
+}
+
+// Delete - Xóa vai trò không phải mặc định và không còn người dùng nào được gán
+func (s *RoleService) Delete(ctx context.Context, actorID, name string) (err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionRoleDelete, actorID, "", err, model.AuditMetadata{"role": name})
+	}()
+
+	role, err := s.roles.FindByName(name)
+	if err != nil {
+		return ErrRoleNotFound
+	}
+	if role.System {
+		return ErrSystemRole
+	}
+
+	count, err := s.users.CountByRole(name)
+	if err != nil {
+		return fmt.Errorf("failed to count users: %v", err)
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := s.roles.Delete(name); err != nil {
+		return err
+	}
+	s.invalidate(ctx, name)
+	return nil
+}
+
+// Grant - Cấp quyền cho vai trò
+func (s *RoleService) Grant(ctx context.Context, actorID, name, permission string) (result *model.Role, err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionRoleGrant, actorID, "", err, model.AuditMetadata{"role": name, "permission": permission})
+	}()
+
+	role, err := s.editableRole(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePermission(permission); err != nil {
+		return nil, err
+	}
+
+	if err := s.roles.AddPermission(role.Name, permission); err != nil {
+		return nil, fmt.Errorf("failed to grant permission: %v", err)
+	}
+	s.invalidate(ctx, role.Name)
+	return s.Get(role.Name)
+}
+
+// Revoke - Thu hồi quyền của vai trò
+func (s *RoleService) Revoke(ctx context.Context, actorID, name, permission string) (result *model.Role, err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionRoleRevoke, actorID, "", err, model.AuditMetadata{"role": name, "permission": permission})
+	}()
+
+	role, err := s.editableRole(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roles.RemovePermission(role.Name, permission); err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, role.Name)
+	return s.Get(role.Name)
+}
+
+// AssignRole - Gán vai trò cho người dùng. Mọi phiên đăng nhập của người dùng bị thu hồi
+// để token mang vai trò cũ không còn dùng được.
+func (s *RoleService) AssignRole(ctx context.Context, actorID, userID, name string) (user *model.User, err error) {
+	metadata := model.AuditMetadata{"role": name}
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionRoleAssign, actorID, userID, err, metadata)
+	}()
+
+	if actorID == userID {
+		return nil, errors.New("you cannot change your own role")
+	}
+	if _, err := s.roles.FindByName(name); err != nil {
+		return nil, ErrRoleNotFound
+	}
+
+	previous, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	metadata["previous_role"] = previous.Role
+
+	if err := s.users.UpdateRole(userID, name); err != nil {
+		return nil, err
+	}
+	user, err = s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.redis.DeleteUserProfile(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to clear cached profile: %v\n", err)
+	}
+	if err := s.redis.RevokeAllUserTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke tokens in Redis: %v\n", err)
+	}
+	if err := s.redis.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke refresh tokens in Redis: %v\n", err)
+	}
+	return user, nil
+}
+
+// permissions - Quyền của vai trò; Redis lỗi thì vẫn đọc được từ Postgres
+func (s *RoleService) permissions(ctx context.Context, role string) ([]string, error) {
+	permissions, err := s.redis.GetRolePermissions(ctx, role)
+	if err == nil {
+		return permissions, nil
+	}
+	if !errors.Is(err, repository.ErrRolePermissionsNotCached) {
+		fmt.Printf("warning: failed to read role permissions from Redis: %v\n", err)
+	}
+
+	permissions, err = s.roles.ListPermissions(role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load role permissions: %v", err)
+	}
+	if err := s.redis.SetRolePermissions(ctx, role, permissions, s.cfg.RolePermissionCacheTTL); err != nil {
+		fmt.Printf("warning: failed to cache role permissions in Redis: %v\n", err)
+	}
+	return permissions, nil
+}
+
+// editableRole - Vai trò tồn tại và không phải vai trò admin mặc định
+// (tránh admin tự thu hồi quyền quản trị của chính mình)
+func (s *RoleService) editableRole(name string) (*model.Role, error) {
+	role, err := s.roles.FindByName(name)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+	if role.Name == model.RoleAdmin {
+		return nil, ErrSystemRole
+	}
+	return role, nil
+}
+
+// invalidate - Xóa cache quyền sau khi thay đổi trong Postgres
+func (s *RoleService) invalidate(ctx context.Context, role string) {
+	if err := s.redis.DeleteRolePermissions(ctx, role); err != nil {
+		fmt.Printf("warning: failed to clear cached role permissions: %v\n", err)
+	}
+}
+
+// validatePermission - Quyền dạng "<tài nguyên>:<hành động>", "<tài nguyên>:*" hoặc "*"
+func validatePermission(permission string) error {
+	if permission == model.PermissionAll || permissionPattern.MatchString(permission) {
+		return nil
+	}
+	return fmt.Errorf("invalid permission %q, expected \"resource:action\"", permission)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"base-app/model"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrSessionNotFound - phiên không tồn tại hoặc không thuộc về người dùng
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionService struct {
+	redis repository.RedisRepository
+	audit *AuditService
+}
+
+func NewSessionService(redisRepo repository.RedisRepository, audit *AuditService) *SessionService {
+	return &SessionService{redis: redisRepo, audit: audit}
+}
+
+// List - Các phiên đăng nhập còn hiệu lực của người dùng, phiên hoạt động gần nhất đứng đầu
+func (s *SessionService) List(ctx context.Context, userID, currentSessionID string) ([]model.Session, error) {
+	sessions, err := s.redis.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions from Redis: %v", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Revoke - Đăng xuất một phiên từ xa: thu hồi access token, refresh token và xóa bản ghi phiên
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID string) (err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionSessionRevoke, userID, userID, err, model.AuditMetadata{"session_id": sessionID})
+	}()
+
+	session, err := s.redis.GetSession(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.redis.RevokeRefreshTokenFamily(ctx, userID, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session in Redis: %v", err)
+	}
+	return nil
+}
+
+// ExportUserData - Section "sessions" của file export dữ liệu người dùng
+func (s *SessionService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	return s.List(ctx, userID, "")
+}
@@ -3,57 +3,147 @@ package service
 import (
 	"base-app/config"
 	"base-app/model"
+	"base-app/pkg/clientinfo"
+	"base-app/pkg/passwordhash"
+	"base-app/pkg/passwordpolicy"
+	"base-app/pkg/token"
+	"base-app/pkg/useragent"
 	"base-app/repository"
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
 )
 
+// AuthTokens là cặp token trả về sau khi đăng nhập hoặc làm mới token
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // số giây access token còn hiệu lực
+}
+
+// ErrEmailNotVerified - tài khoản chưa xác thực email nên không được đăng nhập
+var ErrEmailNotVerified = errors.New("email address has not been verified")
+
+// ErrAccountDisabled - tài khoản đã bị quản trị viên vô hiệu hóa
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// ErrAccountPendingDeletion - tài khoản đang chờ xóa vĩnh viễn
+var ErrAccountPendingDeletion = errors.New("account is pending deletion")
+
+// ErrPasswordResetRequired - người dùng đã báo một lần đăng nhập không phải của mình, phải đặt lại mật khẩu trước
+var ErrPasswordResetRequired = errors.New("a password reset is required, check your email for the reset link")
+
+// ErrReauthenticationRequired - thao tác nhạy cảm cần người dùng xác thực lại (POST /auth/reauthenticate)
+var ErrReauthenticationRequired = errors.New("recent authentication is required, please reauthenticate")
+
+// Phương thức xác thực ghi trong claim amr (RFC 8176)
+const (
+	amrPassword  = "pwd"
+	amrOTP       = "otp"
+	amrMFA       = "mfa"
+	amrFederated = "fed"
+)
+
+// LoginResult là kết quả bước đăng nhập bằng mật khẩu.
+// Nếu người dùng đã bật MFA, Tokens rỗng và client phải gửi MFAToken cùng mã TOTP tới /auth/mfa/verify.
+type LoginResult struct {
+	Tokens      *AuthTokens
+	MFARequired bool
+	MFAToken    string
+}
+
 type UserService struct {
-	repo  repository.UserRepository
-	redis repository.RedisRepository
-	cfg   config.Config
+	repo   repository.UserRepository
+	redis  repository.RedisRepository
+	tokens *token.Manager
+	mfa    *MFAService
+	verify *VerificationService
+	cfg    config.Config
+
+	identities repository.IdentityRepository
+	lockout    *LockoutService
+	orgs       repository.OrganizationRepository
+	invites    *InvitationService
+	audit      *AuditService
+	passwords  *passwordpolicy.Policy
+	hasher     passwordhash.PasswordHasher
+	devices    *DeviceService
 }
 
-func NewUserService(repo repository.UserRepository, redisRepo repository.RedisRepository, cfg config.Config) *UserService {
+func NewUserService(repo repository.UserRepository, redisRepo repository.RedisRepository, tokens *token.Manager, mfa *MFAService, verify *VerificationService, identities repository.IdentityRepository, lockout *LockoutService, orgs repository.OrganizationRepository, invites *InvitationService, audit *AuditService, passwords *passwordpolicy.Policy, hasher passwordhash.PasswordHasher, devices *DeviceService, cfg config.Config) *UserService {
 	return &UserService{
-		repo:  repo,
-		redis: redisRepo,
-		cfg:   cfg,
+		repo:   repo,
+		redis:  redisRepo,
+		tokens: tokens,
+		mfa:    mfa,
+		verify: verify,
+		cfg:    cfg,
+
+		identities: identities,
+		lockout:    lockout,
+		orgs:       orgs,
+		invites:    invites,
+		audit:      audit,
+		passwords:  passwords,
+		hasher:     hasher,
+		devices:    devices,
 	}
 }
 
-// Register - Đăng ký người dùng mới
-func (s *UserService) Register(ctx context.Context, name, email, password string) (*model.User, error) {
+// Register - Đăng ký người dùng mới. invitationToken khác rỗng khi đăng ký từ lời mời tham gia tổ chức:
+// tài khoản được thêm vào tổ chức và email coi như đã xác thực (link mời được gửi tới chính email này).
+func (s *UserService) Register(ctx context.Context, name, email, password, invitationToken string) (newUser *model.User, err error) {
+	defer func() {
+		var userID string
+		if newUser != nil {
+			userID = newUser.ID
+		}
+		s.audit.Record(ctx, model.AuditActionRegister, userID, userID, err, model.AuditMetadata{
+			"email":      email,
+			"invitation": invitationToken != "",
+		})
+	}()
+
 	// Check email đã tồn tại trong DB
-	_, err := s.repo.FindByEmail(email)
+	_, err = s.repo.FindByEmail(email)
 	if err == nil {
 		return nil, errors.New("email already exists")
 	}
 
+	// Lời mời phải còn hiệu lực và được gửi tới đúng email đăng ký
+	var invitation *model.Invitation
+	if invitationToken != "" {
+		invitation, err = s.invites.Validate(invitationToken, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Mật khẩu phải đạt chính sách mật khẩu
+	if err = s.passwords.Validate("password", passwordpolicy.Input{Password: password, Email: email, Name: name}); err != nil {
+		return nil, err
+	}
+
 	// Hash mật khẩu
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return nil, fmt.Errorf("could not hash password: %v", err)
 	}
 
 	// Tạo user mới trong DB
-	newUser, err := s.repo.Create(name, email, string(hashPassword))
+	newUser, err = s.repo.Create(name, email, hashPassword)
 	if err != nil {
 		return nil, fmt.Errorf("error creating user: %v", err)
 	}
 
-	// Lưu email vào Redis (danh sách email người dùng)
-	err = s.redis.AddUserEmailToList(ctx, email)
-	if err != nil {
-		// Cảnh báo nếu Redis gặp lỗi nhưng không làm gián đoạn quá trình
-		fmt.Printf("warning: failed to store email in Redis list: %v\n", err)
-	}
-
 	// Lưu thông tin user vào Redis (cache user profile)
 	err = s.redis.SetUserProfile(ctx, newUser.ID, newUser.Email, 10*time.Minute) // 10 phút cache
 	if err != nil {
@@ -61,52 +151,573 @@ func (s *UserService) Register(ctx context.Context, name, email, password string
 		fmt.Printf("warning: failed to cache user profile in Redis: %v\n", err)
 	}
 
+	if invitation != nil {
+		s.acceptInvitation(newUser, invitation)
+	}
+
+	// Tài khoản mới chưa được xác thực email, gửi link xác thực
+	if newUser.EmailVerified {
+		return newUser, nil
+	}
+	if err := s.verify.SendVerificationEmail(ctx, newUser); err != nil {
+		// Người dùng có thể yêu cầu gửi lại qua /auth/resend-verification
+		fmt.Printf("warning: failed to send verification email: %v\n", err)
+	}
+
 	return newUser, nil
 }
 
-// Login - Xác thực người dùng và sinh JWT
-func (s *UserService) Login(ctx context.Context, email string, password string) (string, error) {
+// acceptInvitation - Hoàn tất lời mời cho tài khoản vừa đăng ký
+func (s *UserService) acceptInvitation(user *model.User, invitation *model.Invitation) {
+	if _, err := s.invites.Complete(invitation, user.ID); err != nil {
+		fmt.Printf("warning: failed to accept invitation: %v\n", err)
+		return
+	}
+
+	updated, err := s.repo.MarkEmailVerified(user.ID, user.Email)
+	if err != nil {
+		fmt.Printf("warning: failed to mark email as verified: %v\n", err)
+		return
+	}
+	if updated {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+}
+
+// Login - Xác thực người dùng và sinh cặp access token / refresh token.
+// Với tài khoản đã bật MFA, chỉ trả về token "MFA pending" ngắn hạn.
+func (s *UserService) Login(ctx context.Context, email string, password string) (*LoginResult, error) {
+	// Chặn nếu tài khoản / IP đang bị khóa hoặc chưa hết thời gian chờ sau lần sai trước
+	ip := clientinfo.From(ctx).IP
+	metadata := model.AuditMetadata{"email": email, "method": "password"}
+	if err := s.lockout.Check(ctx, email, ip); err != nil {
+		s.audit.Record(ctx, model.AuditActionLogin, "", "", err, metadata)
+		return nil, err
+	}
+
 	// Kiểm tra user trong PostgreSQL
 	user, err := s.repo.FindByEmail(email) // PostgreSQL
 	if err != nil {
-		return "", errors.New("user not found")
+		err = errors.New("user not found")
+		if lockErr := s.lockout.RecordFailure(ctx, email, ip, ""); lockErr != nil {
+			err = lockErr
+		}
+		s.audit.Record(ctx, model.AuditActionLogin, "", "", err, metadata)
+		return nil, err
 	}
 
 	// Kiểm tra mật khẩu
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("invalid password")
+	match, needsRehash, err := s.hasher.Verify(password, user.Password)
+	if err != nil {
+		fmt.Printf("warning: failed to verify password hash of user %s: %v\n", user.ID, err)
+	}
+	if !match {
+		err = errors.New("invalid password")
+		if lockErr := s.lockout.RecordFailure(ctx, email, ip, user.ID); lockErr != nil {
+			err = lockErr
+		}
+		s.audit.Record(ctx, model.AuditActionLogin, user.ID, user.ID, err, metadata)
+		return nil, err
+	}
+
+	// Đúng mật khẩu: xóa bộ đếm sai của tài khoản
+	s.lockout.RecordSuccess(ctx, email)
+
+	// Mật khẩu băm bằng thuật toán / tham số cũ: băm lại theo cấu hình hiện tại
+	if needsRehash {
+		s.rehashPassword(user, password)
+	}
+
+	return s.SignIn(ctx, user, "password")
+}
+
+// rehashPassword - Lưu lại mật khẩu với thuật toán / tham số băm hiện tại; lỗi không ảnh hưởng tới đăng nhập
+func (s *UserService) rehashPassword(user *model.User, password string) {
+	hashed, err := s.hasher.Hash(password)
+	if err != nil {
+		fmt.Printf("warning: failed to rehash password: %v\n", err)
+		return
+	}
+	if err := s.repo.UpdatePassword(user.ID, hashed); err != nil {
+		fmt.Printf("warning: failed to store rehashed password: %v\n", err)
+		return
+	}
+	user.Password = hashed
+}
+
+// SignIn - Mở phiên đăng nhập cho người dùng đã chứng minh danh tính (mật khẩu hoặc identity provider bên ngoài).
+// Vẫn áp dụng yêu cầu xác thực email và MFA của tài khoản. method ("password", "oidc:<provider>") được ghi vào audit log.
+func (s *UserService) SignIn(ctx context.Context, user *model.User, method string) (result *LoginResult, err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionLogin, user.ID, user.ID, err, model.AuditMetadata{
+			"email":        user.Email,
+			"method":       method,
+			"mfa_required": result != nil && result.MFARequired,
+		})
+	}()
+
+	if user.Status == model.UserStatusDisabled {
+		return nil, ErrAccountDisabled
+	}
+	// Tài khoản chờ xóa chỉ khôi phục được trong thời gian ân hạn; quá hạn thì coi như đã bị xóa
+	if user.Status == model.UserStatusPendingDeletion && !s.restorable(user) {
+		return nil, errors.New("user not found")
+	}
+
+	// Tài khoản có thể đã bị chiếm (người dùng báo "không phải tôi"): chỉ mở lại sau khi đặt mật khẩu mới qua email,
+	// với mọi cách đăng nhập kể cả identity provider bên ngoài
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	// Chặn tài khoản chưa xác thực email nếu cấu hình không cho phép
+	if !user.EmailVerified && !s.cfg.AllowUnverifiedLogin {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Tài khoản bật MFA: cấp challenge, chờ mã TOTP / mã khôi phục
+	if user.MFAEnabled {
+		challenge, err := generateRandomToken(32)
+		if err != nil {
+			return nil, fmt.Errorf("error generating MFA token: %v", err)
+		}
+		if err := s.redis.SetMFAChallenge(ctx, challenge, user.ID, method, s.cfg.MFAChallengeTTL); err != nil {
+			return nil, fmt.Errorf("failed to store MFA token in Redis: %v", err)
+		}
+		return &LoginResult{MFARequired: true, MFAToken: challenge}, nil
+	}
+
+	tokens, err := s.completeLogin(ctx, user, []string{amrForMethod(method)})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// VerifyMFALogin - Bước 2 của đăng nhập: đổi token "MFA pending" + mã TOTP (hoặc mã khôi phục) lấy cặp token
+func (s *UserService) VerifyMFALogin(ctx context.Context, mfaToken, code string) (tokens *AuthTokens, err error) {
+	userID, method, err := s.redis.GetMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionMFAVerify, userID, userID, err, nil)
+	}()
+
+	// Giới hạn số lần thử cho mỗi challenge để chống dò mã
+	attempts, err := s.redis.IncrementRate(ctx, "auth:mfa:attempts:"+mfaToken, s.cfg.MFAChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check MFA attempts in Redis: %v", err)
+	}
+	if attempts > mfaMaxChallengeTries {
+		if err := s.redis.DeleteMFAChallenge(ctx, mfaToken); err != nil {
+			fmt.Printf("warning: failed to delete MFA token from Redis: %v\n", err)
+		}
+		return nil, errors.New("too many invalid codes, please log in again")
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status == model.UserStatusDisabled {
+		return nil, ErrAccountDisabled
+	}
+	if user.Status == model.UserStatusPendingDeletion && !s.restorable(user) {
+		return nil, errors.New("user not found")
+	}
+	// Challenge cấp trước khi người dùng báo "không phải tôi" không được hoàn tất
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	if _, err := s.mfa.VerifyCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	// Challenge chỉ dùng một lần
+	if err := s.redis.DeleteMFAChallenge(ctx, mfaToken); err != nil {
+		fmt.Printf("warning: failed to delete MFA token from Redis: %v\n", err)
+	}
+
+	return s.completeLogin(ctx, user, []string{amrForMethod(method), amrOTP, amrMFA})
+}
+
+// amrForMethod - Giá trị amr của bước thứ nhất khi đăng nhập: mật khẩu hoặc identity provider bên ngoài
+func amrForMethod(method string) string {
+	if strings.HasPrefix(method, "oidc:") {
+		return amrFederated
+	}
+	return amrPassword
+}
+
+// completeLogin - Phát hành token cho người dùng đã xác thực đầy đủ và cập nhật cache.
+// amr là các phương thức xác thực đã dùng, được ghi vào phiên và đưa vào access token.
+func (s *UserService) completeLogin(ctx context.Context, user *model.User, amr []string) (*AuthTokens, error) {
+	// Đăng nhập lại trong thời gian ân hạn => hủy yêu cầu xóa tài khoản
+	if user.Status == model.UserStatusPendingDeletion {
+		if _, err := s.repo.RestorePendingDeletion(user.ID); err != nil {
+			return nil, fmt.Errorf("failed to restore account: %v", err)
+		}
+		user.Status = model.UserStatusActive
+		user.DeletionRequestedAt = nil
+		s.audit.Record(ctx, model.AuditActionAccountRestore, user.ID, user.ID, nil, nil)
+	}
+
+	// Mỗi lần đăng nhập mở ra một phiên mới; ID phiên cũng là family của refresh token
+	sessionID := uuid.New().String()
+	if err := s.createSession(ctx, user.ID, sessionID, amr); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.issueTokens(ctx, user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Thiết bị mới => email cảnh báo kèm link "không phải tôi"
+	s.devices.RecordLogin(ctx, user, sessionID)
+
+	// Lưu thông tin người dùng vào Redis (cache profile)
+	if err := s.redis.SetUserProfileFull(ctx, user, time.Hour*24); err != nil {
+		// Log cảnh báo nhưng không làm gián đoạn quá trình đăng nhập
+		fmt.Printf("warning: failed to cache user profile in Redis: %v\n", err)
+	}
+
+	return tokens, nil
+}
+
+// RefreshToken - Đổi refresh token lấy cặp token mới (refresh token chỉ dùng được một lần)
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*AuthTokens, error) {
+	userID, familyID, err := s.redis.ConsumeRefreshToken(ctx, refreshToken, s.cfg.RefreshTokenTTL)
+	if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+		// Token đã bị xoay vòng trước đó mà vẫn được gửi lại => có thể đã bị đánh cắp, thu hồi cả family
+		usedUserID, usedFamilyID, usedErr := s.redis.FindUsedRefreshToken(ctx, refreshToken)
+		if usedErr == nil {
+			if err := s.redis.RevokeRefreshTokenFamily(ctx, usedUserID, usedFamilyID); err != nil {
+				fmt.Printf("warning: failed to revoke refresh token family in Redis: %v\n", err)
+			}
+			err := errors.New("refresh token reuse detected")
+			s.audit.Record(ctx, model.AuditActionTokenRefresh, "", usedUserID, err, model.AuditMetadata{"session_id": usedFamilyID})
+			return nil, err
+		}
+		return nil, errors.New("invalid refresh token")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refresh token from Redis: %v", err)
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status == model.UserStatusDisabled || user.Status == model.UserStatusPendingDeletion {
+		// Refresh token còn sót lại của tài khoản đã bị vô hiệu hóa thì thu hồi luôn cả family
+		if err := s.redis.RevokeRefreshTokenFamily(ctx, userID, familyID); err != nil {
+			fmt.Printf("warning: failed to revoke refresh token family in Redis: %v\n", err)
+		}
+		if user.Status == model.UserStatusPendingDeletion {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, ErrAccountDisabled
+	}
+
+	// Gia hạn phiên cùng với refresh token mới
+	if err := s.redis.TouchSession(ctx, familyID, clientinfo.From(ctx).IP, time.Now(), s.cfg.RefreshTokenTTL); err != nil {
+		fmt.Printf("warning: failed to update session in Redis: %v\n", err)
+	}
+
+	return s.issueTokens(ctx, user, familyID)
+}
+
+// Logout - Thu hồi access token hiện tại cùng refresh token family của phiên đăng nhập đó
+func (s *UserService) Logout(ctx context.Context, token string) error {
+	userID, _, familyID, err := s.redis.GetAccessToken(ctx, token)
+	if err != nil {
+		return errors.New("invalid token")
+	}
+
+	if err := s.redis.RevokeToken(ctx, token); err != nil {
+		return fmt.Errorf("failed to revoke token in Redis: %v", err)
+	}
+
+	if err := s.redis.RemoveTokenFromUser(ctx, userID, token); err != nil {
+		fmt.Printf("warning: failed to remove token from user's list in Redis: %v\n", err)
+	}
+
+	if familyID != "" {
+		if err := s.redis.RevokeRefreshTokenFamily(ctx, userID, familyID); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens in Redis: %v", err)
+		}
+	}
+
+	s.audit.Record(ctx, model.AuditActionLogout, userID, userID, nil, model.AuditMetadata{"session_id": familyID})
+	return nil
+}
+
+// LogoutAll - Thu hồi tất cả access token và refresh token của người dùng sở hữu token hiện tại
+func (s *UserService) LogoutAll(ctx context.Context, token string) error {
+	userID, _, _, err := s.redis.GetAccessToken(ctx, token)
+	if err != nil {
+		return errors.New("invalid token")
+	}
+
+	if err := s.redis.RevokeAllUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke tokens in Redis: %v", err)
+	}
+
+	if err := s.redis.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens in Redis: %v", err)
+	}
+
+	s.audit.Record(ctx, model.AuditActionLogoutAll, userID, userID, nil, nil)
+	return nil
+}
+
+// Reauthenticate - Xác thực lại trong phiên hiện tại mà không mở phiên mới: cập nhật auth_time / amr của phiên
+// và cấp access token mới (refresh token giữ nguyên). Tài khoản bật MFA phải nhập mã TOTP / mã khôi phục,
+// tài khoản còn lại phải nhập mật khẩu. Sai quá nhiều lần thì bị khóa như khi đăng nhập.
+func (s *UserService) Reauthenticate(ctx context.Context, userID, sessionID, password, code string) (tokens *AuthTokens, err error) {
+	var amr []string
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionReauthenticate, userID, userID, err, model.AuditMetadata{
+			"session_id": sessionID,
+			"amr":        amr,
+		})
+	}()
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	ip := clientinfo.From(ctx).IP
+	if err := s.lockout.Check(ctx, user.Email, ip); err != nil {
+		return nil, err
+	}
+
+	// failed - Ghi nhận lần xác thực sai vào bộ đếm khóa tài khoản
+	failed := func(err error) error {
+		if lockErr := s.lockout.RecordFailure(ctx, user.Email, ip, user.ID); lockErr != nil {
+			return lockErr
+		}
+		return err
+	}
+
+	if password != "" {
+		match, _, err := s.hasher.Verify(password, user.Password)
+		if err != nil || !match {
+			return nil, failed(errors.New("invalid password"))
+		}
+		amr = append(amr, amrPassword)
+	}
+	if user.MFAEnabled {
+		if _, err := s.mfa.VerifyCode(ctx, user, code); err != nil {
+			return nil, failed(err)
+		}
+		amr = append(amr, amrOTP)
+		if password != "" {
+			amr = append(amr, amrMFA)
+		}
+	}
+	if len(amr) == 0 {
+		return nil, errors.New("password is required")
+	}
+	s.lockout.RecordSuccess(ctx, user.Email)
+
+	if err := s.redis.SetSessionAuthentication(ctx, sessionID, time.Now(), amr); err != nil {
+		return nil, fmt.Errorf("failed to update session in Redis: %v", err)
+	}
+	return s.issueAccessToken(ctx, user, sessionID)
+}
+
+// issueTokens - Sinh access token và refresh token mới thuộc family cho trước
+func (s *UserService) issueTokens(ctx context.Context, user *model.User, familyID string) (*AuthTokens, error) {
+	tokens, err := s.issueAccessToken(ctx, user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sinh refresh token ngẫu nhiên (opaque) và lưu vào Redis
+	refreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("error generating refresh token: %v", err)
+	}
+	if err := s.redis.SetRefreshToken(ctx, refreshToken, user.ID, familyID, s.cfg.RefreshTokenTTL); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token in Redis: %v", err)
+	}
+
+	tokens.RefreshToken = refreshToken
+	return tokens, nil
+}
+
+// issueAccessToken - Sinh access token cho phiên, mang tổ chức đang hoạt động của phiên
+func (s *UserService) issueAccessToken(ctx context.Context, user *model.User, familyID string) (*AuthTokens, error) {
+	claims := &token.Claims{
+		Role:             user.Role,
+		SessionID:        familyID,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: user.ID},
+	}
+
+	// auth_time / amr lấy từ phiên nên được giữ nguyên qua các lần refresh
+	var session *model.Session
+	if found, err := s.redis.GetSession(ctx, familyID); err == nil {
+		session = found
+		if !session.AuthTime.IsZero() {
+			claims.AuthTime = jwt.NewNumericDate(session.AuthTime)
+			claims.AMR = session.AMR
+		}
+	}
+	if membership := s.activeOrganization(ctx, user, session); membership != nil {
+		claims.OrgID = membership.OrganizationID
+		claims.OrgRole = membership.Role
 	}
 
 	// Sinh JWT token
-	token, err := s.generateJWT(user.ID, user.Role, time.Hour*1)
+	accessToken, err := s.tokens.Issue(claims, s.cfg.AccessTokenTTL)
 	if err != nil {
-		return "", fmt.Errorf("error generating token: %v", err)
+		return nil, fmt.Errorf("error generating token: %v", err)
 	}
 
 	// Lưu token vào Redis (để xác thực nhanh chóng)
-	if err := s.redis.SetAccessToken(ctx, token, user.ID, user.Role, time.Hour*1); err != nil {
-		return "", fmt.Errorf("failed to store token in Redis: %v", err)
+	if err := s.redis.SetAccessToken(ctx, accessToken, user.ID, user.Role, familyID, s.cfg.AccessTokenTTL); err != nil {
+		return nil, fmt.Errorf("failed to store token in Redis: %v", err)
 	}
 
 	// Lưu token vào danh sách của user (phục vụ logout all)
-	if err := s.redis.AddTokenToUser(ctx, user.ID, token); err != nil {
+	if err := s.redis.AddTokenToUser(ctx, user.ID, accessToken); err != nil {
 		// Tùy chiến lược: có thể log lại hoặc fail luôn
 		fmt.Printf("warning: failed to add token to user's list in Redis: %v\n", err)
 	}
 
-	// Optional: Lưu email vào Redis danh sách người dùng (nếu chưa có)
-	if err := s.redis.AddUserEmailToList(ctx, email); err != nil {
-		// Không làm gián đoạn quá trình đăng nhập nếu Redis có lỗi
-		fmt.Printf("warning: failed to store email in Redis list: %v\n", err)
+	// Gắn token vào phiên để đăng xuất phiên từ xa thu hồi được cả access token
+	if err := s.redis.AddTokenToSession(ctx, familyID, accessToken, s.cfg.AccessTokenTTL); err != nil {
+		fmt.Printf("warning: failed to add token to session in Redis: %v\n", err)
 	}
 
-	// Lưu thông tin người dùng vào Redis (cache profile)
-	if err := s.redis.SetUserProfileFull(ctx, user, time.Hour*24); err != nil {
-		// Log cảnh báo nhưng không làm gián đoạn quá trình đăng nhập
-		fmt.Printf("warning: failed to cache user profile in Redis: %v\n", err)
+	return &AuthTokens{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// ReissueAccessToken - Cấp access token mới cho phiên hiện tại (ví dụ sau khi đổi tổ chức đang hoạt động);
+// refresh token của phiên giữ nguyên
+func (s *UserService) ReissueAccessToken(ctx context.Context, userID, sessionID string) (*AuthTokens, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.issueAccessToken(ctx, user, sessionID)
+}
+
+// IssueImpersonationToken - Cấp access token ngắn hạn cho quản trị viên actorID đăng nhập dưới danh nghĩa user.
+// Token mang claim act, không thuộc phiên đăng nhập nào và không có refresh token; token nằm trong danh sách token
+// của cả người dùng lẫn quản trị viên nên bị thu hồi khi một trong hai đăng xuất khỏi mọi phiên hoặc bị vô hiệu hóa.
+func (s *UserService) IssueImpersonationToken(ctx context.Context, actorID string, user *model.User) (*AuthTokens, error) {
+	claims := &token.Claims{
+		Role:             user.Role,
+		Actor:            &token.Actor{Subject: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{Subject: user.ID},
+	}
+	if membership := s.activeOrganization(ctx, user, nil); membership != nil {
+		claims.OrgID = membership.OrganizationID
+		claims.OrgRole = membership.Role
+	}
+
+	accessToken, err := s.tokens.Issue(claims, s.cfg.ImpersonationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error generating token: %v", err)
+	}
+	if err := s.redis.SetAccessToken(ctx, accessToken, user.ID, user.Role, "", s.cfg.ImpersonationTTL); err != nil {
+		return nil, fmt.Errorf("failed to store token in Redis: %v", err)
+	}
+	for _, ownerID := range []string{user.ID, actorID} {
+		if err := s.redis.AddTokenToUser(ctx, ownerID, accessToken); err != nil {
+			fmt.Printf("warning: failed to add token to user's list in Redis: %v\n", err)
+		}
 	}
 
-	return token, nil
+	return &AuthTokens{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.ImpersonationTTL.Seconds()),
+	}, nil
+}
+
+// activeOrganization - Tổ chức đang hoạt động của phiên: tổ chức đã chọn trong phiên, nếu không thì tổ chức
+// người dùng chọn gần nhất, cuối cùng là tổ chức tham gia đầu tiên. Tư cách thành viên luôn được kiểm tra lại
+// trong Postgres nên người đã bị xóa khỏi tổ chức không còn nhận token của tổ chức đó.
+// session là nil khi token không thuộc phiên đăng nhập nào (impersonation) hoặc phiên không còn.
+func (s *UserService) activeOrganization(ctx context.Context, user *model.User, session *model.Session) *model.Membership {
+	sessionOrgID := ""
+	if session != nil {
+		sessionOrgID = session.OrganizationID
+	}
+
+	var membership *model.Membership
+	for _, orgID := range []string{sessionOrgID, user.ActiveOrganizationID} {
+		if orgID == "" {
+			continue
+		}
+		if m, err := s.orgs.FindMembership(orgID, user.ID); err == nil {
+			membership = m
+			break
+		}
+	}
+	if membership == nil {
+		memberships, err := s.orgs.ListMembershipsByUser(user.ID)
+		if err != nil {
+			fmt.Printf("warning: failed to load organization memberships: %v\n", err)
+		}
+		if len(memberships) > 0 {
+			membership = &memberships[0]
+		}
+	}
+
+	orgID := ""
+	if membership != nil {
+		orgID = membership.OrganizationID
+	}
+	if session != nil && orgID != sessionOrgID {
+		if err := s.redis.SetSessionOrganization(ctx, session.ID, orgID); err != nil {
+			fmt.Printf("warning: failed to store active organization in Redis: %v\n", err)
+		}
+	}
+	return membership
+}
+
+// createSession - Ghi nhận phiên đăng nhập mới cùng thiết bị, IP của request và phương thức xác thực
+func (s *UserService) createSession(ctx context.Context, userID, sessionID string, amr []string) error {
+	info := clientinfo.From(ctx)
+	now := time.Now()
+
+	session := &model.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		UserAgent:  info.UserAgent,
+		Device:     useragent.Parse(info.UserAgent).Name(),
+		IP:         info.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		AuthTime:   now,
+		AMR:        amr,
+	}
+	if err := s.redis.CreateSession(ctx, session, s.cfg.RefreshTokenTTL); err != nil {
+		return fmt.Errorf("failed to store session in Redis: %v", err)
+	}
+	return nil
+}
+
+// generateRandomToken - Sinh chuỗi ngẫu nhiên an toàn, mã hóa base64url
+func generateRandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
 
 // GetUserProfile - Lấy thông tin người dùng theo ID
@@ -135,32 +746,44 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID string) (*model
 	return user, nil
 }
 
-// generateJWT - Tạo access token JWT
-func (s *UserService) generateJWT(userID string, role string, ttl time.Duration) (string, error) {
-	// Key bí mật từ cấu hình
-	secretKey := []byte(s.cfg.JWTSecret)
-
-	// Khai báo claims
-	claims := jwt.MapClaims{
-		"sub":  userID,                     // user ID (subject)
-		"role": role,                       // role của người dùng
-		"iat":  time.Now().Unix(),          // Thời gian tạo token
-		"exp":  time.Now().Add(ttl).Unix(), // Thời gian hết hạn (ttl được truyền vào)
+// ExportUserData - Section "profile" của file export dữ liệu người dùng (không có mật khẩu / secret)
+func (s *UserService) ExportUserData(ctx context.Context, userID string) (interface{}, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Tạo JWT token với phương thức HS256
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return map[string]interface{}{
+		"id":                     user.ID,
+		"name":                   user.Name,
+		"email":                  user.Email,
+		"role":                   user.Role,
+		"status":                 user.Status,
+		"email_verified":         user.EmailVerified,
+		"email_verified_at":      user.EmailVerifiedAt,
+		"mfa_enabled":            user.MFAEnabled,
+		"active_organization_id": user.ActiveOrganizationID,
+		"created_at":             user.CreatedAt,
+		"updated_at":             user.UpdatedAt,
+	}, nil
+}
 
-	// Ký token và trả về
-	signedToken, err := token.SignedString(secretKey)
+// UpdateUserProfile - Cập nhật thông tin người dùng. Đổi email là thao tác nhạy cảm: authTime (claim auth_time
+// của token) phải nằm trong STEP_UP_MAX_AGE, nếu không người dùng phải xác thực lại.
+func (s *UserService) UpdateUserProfile(ctx context.Context, userID, name, email string, authTime time.Time) (user *model.User, err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionProfileUpdate, userID, userID, err, model.AuditMetadata{"name": name, "email": email})
+	}()
+
+	current, err := s.repo.FindByID(userID)
 	if err != nil {
-		return "", fmt.Errorf("error signing token: %v", err)
+		return nil, errors.New("user not found")
+	}
+	recentAuth := !authTime.IsZero() && time.Since(authTime) <= s.cfg.StepUpMaxAge
+	if !strings.EqualFold(current.Email, email) && !recentAuth {
+		return nil, ErrReauthenticationRequired
 	}
-	return signedToken, nil
-}
 
-// UpdateUserProfile - Cập nhật thông tin người dùng
-func (s *UserService) UpdateUserProfile(ctx context.Context, userID, name, email string) (*model.User, error) {
 	// Kiểm tra email mới có bị trùng với người dùng khác không
 	existingUser, err := s.repo.FindByEmail(email)
 	if err == nil && existingUser != nil && existingUser.ID != userID {
@@ -169,11 +792,18 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, userID, name, email
 	}
 
 	// Cập nhật thông tin người dùng trong DB
-	user, err := s.repo.Update(userID, name, email)
+	user, err = s.repo.Update(userID, name, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %v", err)
 	}
 
+	// Email mới cần được xác thực lại
+	if !user.EmailVerified {
+		if err := s.verify.SendVerificationEmail(ctx, user); err != nil {
+			fmt.Printf("warning: failed to send verification email: %v\n", err)
+		}
+	}
+
 	// Cập nhật lại thông tin trong Redis để đồng bộ
 	err = s.redis.SetUserProfileFull(ctx, user, time.Hour*24) // Cập nhật cache trong 24h
 	if err != nil {
@@ -185,7 +815,11 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, userID, name, email
 }
 
 // ChangePassword - Thay đổi mật khẩu người dùng
-func (s *UserService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
+func (s *UserService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) (err error) {
+	defer func() {
+		s.audit.Record(ctx, model.AuditActionPasswordChange, userID, userID, err, nil)
+	}()
+
 	// Lấy thông tin người dùng từ DB
 	user, err := s.repo.FindByID(userID)
 	if err != nil {
@@ -193,43 +827,69 @@ func (s *UserService) ChangePassword(ctx context.Context, userID, oldPassword, n
 	}
 
 	// Kiểm tra mật khẩu cũ
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword))
-	if err != nil {
+	match, _, err := s.hasher.Verify(oldPassword, user.Password)
+	if err != nil || !match {
 		return errors.New("incorrect old password")
 	}
 
+	// Mật khẩu mới phải đạt chính sách mật khẩu
+	if err = s.passwords.Validate("new_password", passwordpolicy.Input{Password: newPassword, Email: user.Email, Name: user.Name}); err != nil {
+		return err
+	}
+
 	// Mã hóa mật khẩu mới
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(newPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %v", err)
 	}
 
 	// Cập nhật mật khẩu mới vào DB
-	err = s.repo.UpdatePassword(userID, string(hashedPassword))
+	err = s.repo.UpdatePassword(userID, hashedPassword)
 	if err != nil {
 		return fmt.Errorf("failed to update password: %v", err)
 	}
 
-	// Cập nhật lại thông tin mật khẩu trong Redis nếu có lưu
-	// (Nếu có lưu thông tin người dùng trong Redis, cần cập nhật lại hoặc xóa cache nếu cần)
-	err = s.redis.RemoveTokenFromUser(ctx, userID, "") // Xóa tất cả các token của user nếu cần
-	if err != nil {
-		// Log cảnh báo nếu có lỗi khi xóa token trong Redis
-		fmt.Printf("warning: failed to remove user tokens from Redis: %v\n", err)
+	// Mật khẩu cũ có thể đã lộ: đăng xuất mọi phiên (kể cả phiên hiện tại) và thu hồi mọi refresh token
+	if err := s.redis.RevokeAllUserTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke tokens in Redis: %v", err)
 	}
-
-	// // Nếu có lưu thông tin người dùng trong Redis (cache), có thể cập nhật lại hoặc xóa cache
-	// err = s.redis.DeletedUserAccount(ctx, "user:profile:"+userID) // Xóa cache của user
-	// khon lu password trong redis nen khong can xoas nos
-	if err != nil {
-		// Log cảnh báo nếu có lỗi khi xóa cache
-		fmt.Printf("warning: failed to remove user profile from Redis: %v\n", err)
+	if err := s.redis.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens in Redis: %v", err)
 	}
 
 	return nil
 }
 
-// DeleteUserAccount - Xóa tài khoản người dùng
+// DeleteAccount - Người dùng tự xóa tài khoản: đánh dấu chờ xóa và đăng xuất mọi phiên.
+// Tài khoản được khôi phục nếu đăng nhập lại trước thời điểm trả về, sau đó bị xóa vĩnh viễn (xem AccountDeletionService).
+func (s *UserService) DeleteAccount(ctx context.Context, userID string) (time.Time, error) {
+	if err := s.repo.MarkPendingDeletion(userID); err != nil {
+		err = fmt.Errorf("failed to delete user account: %v", err)
+		s.audit.Record(ctx, model.AuditActionAccountDelete, userID, userID, err, nil)
+		return time.Time{}, err
+	}
+
+	if err := s.redis.RevokeAllUserTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke tokens in Redis: %v\n", err)
+	}
+	if err := s.redis.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to revoke refresh tokens in Redis: %v\n", err)
+	}
+	if err := s.redis.DeleteUserProfile(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to clear cached profile: %v\n", err)
+	}
+
+	purgeAt := time.Now().Add(s.cfg.AccountDeletionGracePeriod)
+	s.audit.Record(ctx, model.AuditActionAccountDelete, userID, userID, nil, model.AuditMetadata{"purge_at": purgeAt})
+	return purgeAt, nil
+}
+
+// restorable - Tài khoản chờ xóa còn trong thời gian ân hạn
+func (s *UserService) restorable(user *model.User) bool {
+	return user.DeletionRequestedAt != nil && time.Since(*user.DeletionRequestedAt) < s.cfg.AccountDeletionGracePeriod
+}
+
+// ForceDeletedUserAccount - Xóa vĩnh viễn tài khoản người dùng
 func (s *UserService) ForceDeletedUserAccount(ctx context.Context, userID string) error {
 	err := s.repo.Delete(userID)
 	if err != nil {
@@ -241,5 +901,17 @@ func (s *UserService) ForceDeletedUserAccount(ctx context.Context, userID string
 		// Log cảnh báo nếu có lỗi trong việc xóa cache, nhưng không ngừng thực hiện
 		fmt.Printf("warning: failed to delete user data from Redis: %v\n", err)
 	}
+
+	if err := s.mfa.RemoveUserData(ctx, userID); err != nil {
+		fmt.Printf("warning: failed to delete user MFA data: %v\n", err)
+	}
+
+	if err := s.identities.DeleteByUser(userID); err != nil {
+		fmt.Printf("warning: failed to delete linked identities: %v\n", err)
+	}
+
+	if err := s.orgs.DeleteMembershipsByUser(userID); err != nil {
+		fmt.Printf("warning: failed to delete organization memberships: %v\n", err)
+	}
 	return nil
 }
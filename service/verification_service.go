@@ -0,0 +1,123 @@
+package service
+
+import (
+	"base-app/config"
+	"base-app/model"
+	"base-app/pkg/mailer"
+	"base-app/pkg/signer"
+	"base-app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	purposeEmailVerification = "email_verification"
+
+	// Giới hạn gửi lại email xác thực cho mỗi địa chỉ
+	resendVerificationLimit  = 3
+	resendVerificationWindow = time.Hour
+)
+
+// ErrTooManyRequests - vượt quá giới hạn số lần thao tác
+var ErrTooManyRequests = errors.New("too many requests, please try again later")
+
+type VerificationService struct {
+	repo   repository.UserRepository
+	redis  repository.RedisRepository
+	mailer mailer.Mailer
+	signer *signer.Signer
+	cfg    config.Config
+}
+
+func NewVerificationService(repo repository.UserRepository, redisRepo repository.RedisRepository, mail mailer.Mailer, sign *signer.Signer, cfg config.Config) *VerificationService {
+	return &VerificationService{
+		repo:   repo,
+		redis:  redisRepo,
+		mailer: mail,
+		signer: sign,
+		cfg:    cfg,
+	}
+}
+
+// SendVerificationEmail - Gửi link xác thực (đã ký, có thời hạn) tới email hiện tại của người dùng
+func (s *VerificationService) SendVerificationEmail(ctx context.Context, user *model.User) error {
+	// Gắn email vào token để link cũ tự vô hiệu khi người dùng đổi email
+	token, err := s.signer.Sign(purposeEmailVerification, user.ID, map[string]string{"email": user.Email}, s.cfg.EmailVerificationTTL)
+	if err != nil {
+		return fmt.Errorf("could not sign verification token: %v", err)
+	}
+
+	link := strings.TrimRight(s.cfg.AppBaseURL, "/") + "/verify-email?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThis link expires in %s.\nIf you did not create an account, you can ignore this email.\n",
+			user.Name, link, s.cfg.EmailVerificationTTL),
+	})
+}
+
+// VerifyEmail - Xác thực email bằng token trong link
+func (s *VerificationService) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	payload, err := s.signer.Verify(purposeEmailVerification, token)
+	if errors.Is(err, signer.ErrExpiredToken) {
+		return nil, errors.New("verification link has expired")
+	}
+	if err != nil {
+		return nil, errors.New("invalid verification link")
+	}
+
+	user, err := s.repo.FindByID(payload.Subject)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	updated, err := s.repo.MarkEmailVerified(user.ID, payload.Data["email"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify email: %v", err)
+	}
+	if !updated {
+		// Email đã đổi sau khi link được gửi
+		return nil, errors.New("invalid verification link")
+	}
+
+	return s.repo.FindByID(user.ID)
+}
+
+// ResendVerification - Gửi lại email xác thực. Luôn trả về thành công nếu email không tồn tại
+// hoặc đã xác thực, để không làm lộ thông tin tài khoản.
+func (s *VerificationService) ResendVerification(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("email is required")
+	}
+
+	count, err := s.redis.IncrementRate(ctx, "rate:resend_verification:"+strings.ToLower(email), resendVerificationWindow)
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit in Redis: %v", err)
+	}
+	if count > resendVerificationLimit {
+		return ErrTooManyRequests
+	}
+
+	user, err := s.repo.FindByEmail(email)
+	if err != nil || user.EmailVerified {
+		return nil
+	}
+
+	// Gửi email ở nền và luôn trả về thành công để thời gian phản hồi hay lỗi gửi mail
+	// không tiết lộ email có tồn tại / đã xác thực hay chưa
+	go func() {
+		if err := s.SendVerificationEmail(context.Background(), user); err != nil {
+			fmt.Printf("warning: failed to send verification email: %v\n", err)
+		}
+	}()
+	return nil
+}